@@ -0,0 +1,131 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// applySummaryCmd represents the apply summary command
+var applySummaryCmd = &cobra.Command{
+	Use:   "summary [apply-log]",
+	Short: "Generate a summary of the results of a Terraform apply",
+	Long: `Generate a summary of what a Terraform apply actually did.
+
+This command reads the machine-readable output of terraform apply -json and
+reports the result and duration of every resource operation, the errors and
+warnings reported by Terraform, and the totals of the apply. Use - to read
+the log from stdin.
+
+When a plan file is provided with --plan, the apply is reconciled against it
+and planned changes that failed or never started are listed, along with any
+changes that weren't part of the plan. --plan also accepts a summary saved
+with --save-summary, which is recognised by its schema_version, so the plan
+doesn't have to be kept or analysed again.
+
+Examples:
+  # Summarise a saved apply log
+  terraform apply -json terraform.tfplan > apply.log
+  strata apply summary apply.log
+
+  # Summarise while applying
+  terraform apply -json terraform.tfplan | strata apply summary -
+
+  # Show planned changes that weren't applied
+  strata apply summary --plan terraform.tfplan apply.log
+
+  # Reconcile against a summary saved when the plan was reviewed
+  strata plan summary --save-summary plan-summary.json terraform.tfplan
+  strata apply summary --plan plan-summary.json apply.log
+
+  # Save the report as markdown
+  strata apply summary --file apply-report.md --file-format markdown apply.log`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApplySummary,
+}
+
+var applyPlanFile string
+
+func runApplySummary(cmd *cobra.Command, args []string) error {
+	logFile := args[0]
+
+	applyLog, err := plan.NewApplyLogParser(logFile).LoadApplyLog()
+	if err != nil {
+		return fmt.Errorf("failed to load apply log: %w", err)
+	}
+
+	// The workspace overlay of the config file is only applied when the plan is given
+	var cfg *config.Config
+	var planned *plan.PlanSummary
+	switch {
+	case applyPlanFile == "":
+		cfg, err = loadConfig()
+	case plan.IsSummaryFile(applyPlanFile):
+		if planned, err = plan.LoadSummaryFile(applyPlanFile); err != nil {
+			return fmt.Errorf("failed to load plan summary: %w", err)
+		}
+		cfg, err = loadConfigForWorkspace(planned.Workspace)
+	default:
+		cfg, err = loadConfigForPlan(plan.NewParser(applyPlanFile))
+	}
+	if err != nil {
+		return err
+	}
+
+	summary := plan.NewAnalyzer(nil, cfg).AnalyzeApply(applyLog, logFile)
+
+	if applyPlanFile != "" {
+		if planned == nil {
+			parser := plan.NewParser(applyPlanFile)
+			tfPlan, err := parser.LoadPlan()
+			if err != nil {
+				return fmt.Errorf("failed to load plan: %w", err)
+			}
+			if err := parser.ValidateStructure(tfPlan); err != nil {
+				return fmt.Errorf("invalid plan structure: %w", err)
+			}
+			planned = plan.NewAnalyzer(tfPlan, cfg).GenerateSummary(applyPlanFile)
+		}
+		plan.ReconcileApply(summary, planned)
+	}
+
+	outputConfig := cfg.NewOutputConfiguration()
+	if outputConfig.OutputFile != "" {
+		validator := config.NewFileValidator(cfg)
+		if err := validator.ValidateFileOutput(outputConfig); err != nil {
+			return fmt.Errorf("file output validation failed: %w", err)
+		}
+	}
+
+	return plan.NewFormatter(cfg).OutputApplySummary(summary, outputConfig)
+}
+
+func init() {
+	applyCmd.AddCommand(applySummaryCmd)
+
+	applySummaryCmd.Flags().StringVar(&applyPlanFile, "plan", "",
+		"Plan file or saved plan summary to reconcile the apply against")
+}
@@ -0,0 +1,396 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the Strata configuration file",
+	Long: `Create, check, and inspect the Strata configuration file.
+
+Strata reads strata.yaml from the current directory or your home directory, or
+the file passed with --config.`,
+}
+
+// configInitCmd represents the config init command
+var configInitCmd = &cobra.Command{
+	Use:   "init [file]",
+	Short: "Write a commented configuration file",
+	Long: `Write a configuration file with every setting, its default value, and a
+comment explaining it. The file is written to strata.yaml in the current
+directory unless another file is given, and existing files are only replaced
+with --force.
+
+Examples:
+  # Create strata.yaml in the current directory
+  strata config init
+
+  # Create a configuration file in your home directory
+  strata config init ~/strata.yaml`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigInit,
+}
+
+// configValidateCmd represents the config validate command
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a configuration file",
+	Long: `Check a configuration file strictly. Unknown keys, values of the wrong type,
+values that aren't allowed, and invalid combinations of settings are reported
+with their line and column, and the command fails when any are found.
+
+The file that Strata would use is checked unless another file is given,
+together with the files it extends. The overlay of every workspace is checked
+against the rest of the configuration as well.
+
+Examples:
+  # Check the configuration file Strata uses
+  strata config validate
+
+  # Check a specific file, for example in CI
+  strata config validate ci/strata.yaml`,
+	Args:         cobra.MaximumNArgs(1),
+	SilenceUsage: true,
+	RunE:         runConfigValidate,
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration",
+	Long: `Print the configuration in effect after merging the defaults, the
+configuration file and the files it extends, environment variables, and flags.
+Every value is followed by its source: default, file, workspace, env, or flag.
+Workspace overlays are only applied when a workspace is given with --workspace.
+
+Flags of individual commands, such as --details of plan summary, are applied
+on top of this configuration when those commands run. The sensitive value hash
+salt is never shown.
+
+Examples:
+  strata config show
+  strata config show --config ci/strata.yaml --output markdown
+  strata config show --workspace prod`,
+	Args: cobra.NoArgs,
+	RunE: runConfigShow,
+}
+
+var (
+	forceConfigInit bool
+	showWorkspace   string
+)
+
+// settingFlags are the global flags that set configuration settings, by setting key
+var settingFlags = map[string]string{
+	"output":                 "output",
+	"output-file":            "file",
+	"output-file-format":     "file-format",
+	"template":               "template",
+	"table.style":            "table-style",
+	"table.max-column-width": "table-max-column-width",
+	"expand_all":             "expand-all",
+}
+
+// hashSaltKey is the setting of the salt for sensitive value hashes, which is never shown
+const hashSaltKey = "plan.sensitive_values.hash_salt"
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	path := "strata.yaml"
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if forceConfigInit {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use --force to replace it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create config file: %w", err)
+	}
+	if _, err := file.WriteString(config.InitTemplate()); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", path)
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := viper.ConfigFileUsed()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		return fmt.Errorf("no config file found, create one with strata config init")
+	}
+
+	// Every file the config file extends is checked as well
+	files := []string{path}
+	layers, layersErr := config.LoadLayers(path)
+	if layersErr == nil {
+		files = layers.Files
+	}
+	contents := map[string][]byte{}
+	problems := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		contents[file] = data
+		issues := config.ValidateYAML(data)
+		reportConfigIssues(cmd, file, issues)
+		problems += len(issues)
+	}
+	// Problems with extended files, such as missing files, are reported when the config file itself is valid
+	if layersErr != nil && problems == 0 {
+		reportConfigIssues(cmd, path, []config.Issue{{Message: layersErr.Error()}})
+		problems++
+	}
+
+	// Settings are only checked against each other once every value has the right type, for the merged
+	// files and for every workspace overlay
+	if problems == 0 {
+		if err := loadConfigFile(path); err != nil {
+			return err
+		}
+		if _, err := loadConfig(); err != nil {
+			file, issue := locateConfigIssue(files, contents, "", err)
+			reportConfigIssues(cmd, file, []config.Issue{issue})
+			problems++
+		}
+		for _, workspace := range configWorkspaces() {
+			if _, err := loadConfigForWorkspace(workspace); err != nil {
+				file, issue := locateConfigIssue(files, contents, "workspaces."+workspace, err)
+				issue.Message = fmt.Sprintf("workspace %s: %s", workspace, issue.Message)
+				reportConfigIssues(cmd, file, []config.Issue{issue})
+				problems++
+			}
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%s has %d problem(s)", path, problems)
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", path)
+	return nil
+}
+
+// reportConfigIssues prints the issues found in a config file
+func reportConfigIssues(cmd *cobra.Command, path string, issues []config.Issue) {
+	for _, issue := range issues {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "%s:%s\n", path, issue)
+	}
+}
+
+// configWorkspaces returns the names of the workspaces with overlays in the config file, sorted
+func configWorkspaces() []string {
+	if configLayers == nil {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(configLayers.Workspaces))
+}
+
+// loadConfigFile makes Viper read the given config file and the files it extends, unless it's the file
+// that was already read
+func loadConfigFile(path string) error {
+	if path == viper.ConfigFileUsed() {
+		return nil
+	}
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	configLayersErr = readConfigLayers(path)
+	return configLayersErr
+}
+
+// locateConfigIssue converts an error from loading the configuration into an issue in the last of the
+// files that sets the setting the error is about, or in the last file when none of them do
+func locateConfigIssue(files []string, contents map[string][]byte, prefix string, err error) (string, config.Issue) {
+	for _, file := range slices.Backward(files) {
+		if issue := configIssue(contents[file], prefix, err); issue.Line > 0 {
+			return file, issue
+		}
+	}
+	last := files[len(files)-1]
+	return last, configIssue(contents[last], prefix, err)
+}
+
+// configIssue converts an error from loading the configuration into an issue, on the line of the setting
+// the error is about when it starts with the setting's key. The key is looked up within prefix, such as
+// the overlay of a workspace.
+func configIssue(data []byte, prefix string, err error) config.Issue {
+	if inner := errors.Unwrap(err); inner != nil {
+		err = inner
+	}
+	key, _, _ := strings.Cut(err.Error(), " ")
+	key = strings.TrimSuffix(key, ":")
+	if prefix != "" {
+		key = prefix + "." + key
+	}
+	issue := config.Issue{Message: err.Error()}
+	issue.Line, issue.Column = config.KeyPosition(data, key)
+	return issue
+}
+
+func runConfigShow(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfigForWorkspace(showWorkspace)
+	if err != nil {
+		return err
+	}
+
+	settings := make([]config.Setting, 0)
+	for _, key := range config.CommandSettingKeys() {
+		settings = append(settings, config.Setting{Key: key, Value: viper.GetString(key)})
+	}
+	settings = append(settings, cfg.Settings()...)
+
+	direct := directSettings(cfg)
+	document := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range settings {
+		if setting.Key == hashSaltKey && cfg.GetHashSalt() != "" {
+			setting.Value = "<redacted>"
+		}
+		_, isDirect := direct[setting.Key]
+		source := settingSource(cmd, setting.Key, isDirect || settingFlags[setting.Key] != "" || isCommandSetting(setting.Key))
+		if err := addSetting(document, setting, source); err != nil {
+			return err
+		}
+	}
+
+	if file := viper.ConfigFileUsed(); file != "" {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "# Config file: %s\n", file)
+	} else {
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "# No config file found")
+	}
+	if showWorkspace != "" {
+		_, _ = fmt.Fprintf(cmd.OutOrStdout(), "# Workspace: %s\n", showWorkspace)
+	}
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to print configuration: %w", err)
+	}
+	return encoder.Close()
+}
+
+// isCommandSetting reports whether a key is one of the settings read directly by the commands
+func isCommandSetting(key string) bool {
+	for _, commandKey := range config.CommandSettingKeys() {
+		if key == commandKey {
+			return true
+		}
+	}
+	return false
+}
+
+// settingSource returns where the effective value of a setting comes from, following the precedence
+// of Viper. Environment variables only apply to settings that are read one by one, and to the hash salt.
+func settingSource(cmd *cobra.Command, key string, direct bool) string {
+	if flag, ok := settingFlags[key]; ok && cmd.Flags().Changed(flag) {
+		return "flag"
+	}
+	if _, ok := os.LookupEnv(strings.ToUpper(key)); ok && direct {
+		return "env"
+	}
+	if _, ok := os.LookupEnv("STRATA_HASH_SALT"); ok && key == hashSaltKey {
+		return "env"
+	}
+	if configLayers != nil && showWorkspace != "" && configLayers.InWorkspace(showWorkspace, key) {
+		return "workspace"
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	return "default"
+}
+
+// addSetting adds a setting to a YAML document at the position of its dotted key, with its source as comment
+func addSetting(document *yaml.Node, setting config.Setting, source string) error {
+	parts := strings.Split(setting.Key, ".")
+	parent := document
+	for _, part := range parts[:len(parts)-1] {
+		parent = mappingChild(parent, part)
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]}
+	value := &yaml.Node{}
+	if err := value.Encode(setting.Value); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", setting.Key, err)
+	}
+	// Lists with items are written as blocks, so their source is written above them
+	switch {
+	case value.Kind == yaml.SequenceNode && len(value.Content) > 0:
+		key.HeadComment = source
+	case value.Kind == yaml.SequenceNode:
+		value.Style = yaml.FlowStyle
+		value.LineComment = source
+	default:
+		value.LineComment = source
+	}
+	parent.Content = append(parent.Content, key, value)
+	return nil
+}
+
+// mappingChild returns the mapping at key within a mapping, adding it when it doesn't exist yet
+func mappingChild(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+	return child
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configShowCmd)
+
+	configInitCmd.Flags().BoolVar(&forceConfigInit, "force", false, "Replace the file if it already exists")
+	configShowCmd.Flags().StringVar(&showWorkspace, "workspace", "", "Apply the overlay of a Terraform workspace from the config file")
+}
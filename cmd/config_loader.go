@@ -0,0 +1,227 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// configLayers holds the settings of the config file merged with the files it extends
+	configLayers *config.Layers
+	// configLayersErr is the error from reading the files the config file extends, returned by loadConfig
+	configLayersErr error
+)
+
+// loadConfig builds the configuration from defaults and the settings and sections set in the config file,
+// then migrates and validates it. Command flags are applied by the caller.
+func loadConfig() (*config.Config, error) {
+	if configLayersErr != nil {
+		return nil, configLayersErr
+	}
+	cfg := config.GetDefaultConfig()
+
+	// Load the settings outside of sections, such as expand_all, if they are set in the config file,
+	// environment, or flags
+	for key, target := range directSettings(cfg) {
+		if !viper.IsSet(key) {
+			continue
+		}
+		switch target := target.(type) {
+		case *bool:
+			*target = viper.GetBool(key)
+		case *int:
+			*target = viper.GetInt(key)
+		case *string:
+			*target = viper.GetString(key)
+		}
+	}
+
+	// Load expandable sections configuration from config file if it exists
+	if viper.IsSet("plan.expandable_sections") {
+		if err := viper.UnmarshalKey("plan.expandable_sections", &cfg.Plan.ExpandableSections); err != nil {
+			return nil, fmt.Errorf("failed to parse expandable_sections config: %w", err)
+		}
+	}
+
+	// Load grouping configuration from config file if it exists
+	if viper.IsSet("plan.grouping") {
+		if err := viper.UnmarshalKey("plan.grouping", &cfg.Plan.Grouping); err != nil {
+			return nil, fmt.Errorf("failed to parse grouping config: %w", err)
+		}
+	}
+
+	// Load multiline diff configuration from config file if it exists
+	if viper.IsSet("plan.multiline_diff") {
+		if err := viper.UnmarshalKey("plan.multiline_diff", &cfg.Plan.MultilineDiff); err != nil {
+			return nil, fmt.Errorf("failed to parse multiline_diff config: %w", err)
+		}
+	}
+
+	// Load sensitive value display configuration from config file if it exists
+	if viper.IsSet("plan.sensitive_values") {
+		if err := viper.UnmarshalKey("plan.sensitive_values", &cfg.Plan.SensitiveValues); err != nil {
+			return nil, fmt.Errorf("failed to parse sensitive_values config: %w", err)
+		}
+	}
+
+	// Load perpetual diff detection configuration from config file if it exists
+	if viper.IsSet("plan.perpetual_diff") {
+		if err := viper.UnmarshalKey("plan.perpetual_diff", &cfg.Plan.PerpetualDiff); err != nil {
+			return nil, fmt.Errorf("failed to parse perpetual_diff config: %w", err)
+		}
+	}
+
+	// Load resource filter configuration from config file if it exists
+	if viper.IsSet("plan.filter") {
+		if err := viper.UnmarshalKey("plan.filter", &cfg.Plan.Filter); err != nil {
+			return nil, fmt.Errorf("failed to parse filter config: %w", err)
+		}
+	}
+
+	// Load performance limits configuration from config file if it exists
+	if viper.IsSet("plan.performance_limits") {
+		if err := viper.UnmarshalKey("plan.performance_limits", &cfg.Plan.PerformanceLimits); err != nil {
+			return nil, fmt.Errorf("failed to parse performance_limits config: %w", err)
+		}
+	}
+
+	// Load sensitive resources and properties from config file if they exist
+	if viper.IsSet("sensitive_resources") {
+		if err := viper.UnmarshalKey("sensitive_resources", &cfg.SensitiveResources); err != nil {
+			return nil, fmt.Errorf("failed to parse sensitive_resources config: %w", err)
+		}
+	}
+
+	if viper.IsSet("sensitive_properties") {
+		if err := viper.UnmarshalKey("sensitive_properties", &cfg.SensitiveProperties); err != nil {
+			return nil, fmt.Errorf("failed to parse sensitive_properties config: %w", err)
+		}
+	}
+
+	// Load secret scanning configuration from config file if it exists
+	if viper.IsSet("secret_scanning") {
+		if err := viper.UnmarshalKey("secret_scanning", &cfg.SecretScanning); err != nil {
+			return nil, fmt.Errorf("failed to parse secret_scanning config: %w", err)
+		}
+	}
+
+	// Load history store configuration from config file if it exists
+	if viper.IsSet("history") {
+		if err := viper.UnmarshalKey("history", &cfg.History); err != nil {
+			return nil, fmt.Errorf("failed to parse history config: %w", err)
+		}
+	}
+
+	// Handle configuration migration and show deprecation warnings
+	warnings := cfg.MigrateDeprecatedConfig()
+	config.PrintDeprecationWarnings(warnings)
+
+	// Validate configuration
+	if err := cfg.ValidateConfiguration(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// directSettings returns the settings that are read from Viper one by one instead of as part of a
+// section, by key. Environment variables only apply to these settings and to the command settings.
+func directSettings(cfg *config.Config) map[string]any {
+	return map[string]any{
+		"expand_all":                     &cfg.ExpandAll,
+		"use_emoji":                      &cfg.UseEmoji,
+		"table.style":                    &cfg.Table.Style,
+		"table.max-column-width":         &cfg.Table.MaxColumnWidth,
+		"plan.show-details":              &cfg.Plan.ShowDetails,
+		"plan.highlight-dangers":         &cfg.Plan.HighlightDangers,
+		"plan.show-statistics-summary":   &cfg.Plan.ShowStatisticsSummary,
+		"plan.statistics-summary-format": &cfg.Plan.StatisticsSummaryFormat,
+		"plan.always-show-sensitive":     &cfg.Plan.AlwaysShowSensitive,
+		"plan.show-no-ops":               &cfg.Plan.ShowNoOps,
+		"plan.group-by-provider":         &cfg.Plan.GroupByProvider,
+		"plan.grouping-threshold":        &cfg.Plan.GroupingThreshold,
+		"plan.show-context":              &cfg.Plan.ShowContext,
+	}
+}
+
+// readConfigLayers merges the config file that Viper read with the files it extends, and makes Viper use
+// the merged settings. Only YAML config files can extend other files.
+func readConfigLayers(path string) error {
+	configLayers = nil
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".yaml" && ext != ".yml" {
+		return nil
+	}
+	layers, err := config.LoadLayers(path)
+	if err != nil {
+		return fmt.Errorf("failed to load config file: %w", err)
+	}
+	configLayers = layers
+	return useConfigSettings(layers.Settings)
+}
+
+// useConfigSettings replaces the settings Viper read from the config file
+func useConfigSettings(settings map[string]any) error {
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to merge config files: %w", err)
+	}
+	viper.SetConfigType("yaml")
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to merge config files: %w", err)
+	}
+	return nil
+}
+
+// selectWorkspace applies the overlay of a Terraform workspace from the config file to the settings,
+// replacing the overlay of any workspace selected before
+func selectWorkspace(workspace string) error {
+	if configLayers == nil || configLayersErr != nil {
+		return nil
+	}
+	return useConfigSettings(configLayers.ForWorkspace(workspace))
+}
+
+// loadConfigForWorkspace loads the configuration with the overlay of a Terraform workspace applied
+func loadConfigForWorkspace(workspace string) (*config.Config, error) {
+	if err := selectWorkspace(workspace); err != nil {
+		return nil, err
+	}
+	return loadConfig()
+}
+
+// loadConfigForPlan loads the configuration with the overlay of the workspace the plan belongs to.
+// The workspace is only detected when the config file has workspace overlays.
+func loadConfigForPlan(parser *plan.Parser) (*config.Config, error) {
+	if configLayers == nil || len(configLayers.Workspaces) == 0 {
+		return loadConfig()
+	}
+	return loadConfigForWorkspace(parser.Workspace())
+}
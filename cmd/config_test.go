@@ -0,0 +1,92 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigIssue(t *testing.T) {
+	data := []byte("plan:\n  grouping:\n    threshold: 0\n")
+
+	issue := configIssue(data, "", fmt.Errorf("invalid configuration: %w", errors.New("plan.grouping.threshold must be at least 1, got 0")))
+	if got := issue.String(); got != "3:5: plan.grouping.threshold must be at least 1, got 0" {
+		t.Errorf("Unexpected issue %q", got)
+	}
+
+	workspaces := []byte("workspaces:\n  prod:\n    plan:\n      grouping:\n        threshold: 0\n")
+	issue = configIssue(workspaces, "workspaces.prod", errors.New("plan.grouping.threshold must be at least 1, got 0"))
+	if issue.Line != 5 || issue.Column != 9 {
+		t.Errorf("Expected the setting to be found in the workspace overlay, got %d:%d", issue.Line, issue.Column)
+	}
+
+	issue = configIssue(data, "", errors.New("something else went wrong"))
+	if got := issue.String(); got != "something else went wrong" {
+		t.Errorf("Expected an issue without position, got %q", got)
+	}
+}
+
+func TestAddSetting(t *testing.T) {
+	document := &yaml.Node{Kind: yaml.MappingNode}
+	settings := []struct {
+		setting config.Setting
+		source  string
+	}{
+		{config.Setting{Key: "output", Value: "json"}, "flag"},
+		{config.Setting{Key: "plan.grouping.enabled", Value: true}, "default"},
+		{config.Setting{Key: "plan.grouping.threshold", Value: 5}, "file"},
+		{config.Setting{Key: "plan.filter.actions", Value: []any{}}, "default"},
+		{config.Setting{Key: "sensitive_resources", Value: []any{map[string]any{"resource_type": "aws_instance"}}}, "file"},
+	}
+	for _, s := range settings {
+		if err := addSetting(document, s.setting, s.source); err != nil {
+			t.Fatalf("Failed to add %s: %v", s.setting.Key, err)
+		}
+	}
+
+	var output bytes.Buffer
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		t.Fatalf("Failed to encode document: %v", err)
+	}
+	expected := `output: json # flag
+plan:
+  grouping:
+    enabled: true # default
+    threshold: 5 # file
+  filter:
+    actions: [] # default
+# file
+sensitive_resources:
+  - resource_type: aws_instance
+`
+	if output.String() != expected {
+		t.Errorf("Unexpected document\ngot:\n%s\nwant:\n%s", output.String(), expected)
+	}
+}
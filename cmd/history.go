@@ -0,0 +1,174 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show plans recorded in the history store",
+	Long: `Show the plan summaries recorded in the local history store.
+
+Recording is opt-in. When history is enabled in the configuration file, every
+plan summary is stored together with its workspace, backend, root module, and
+git metadata:
+
+  history:
+    enabled: true
+    path: ~/.strata/history            # Directory of the history store
+
+The root module is the directory of the plan file, or for plan watch the
+directory strata was run from, relative to its git repository. Use the
+output format flags to export the history, for example
+as CSV or JSON.
+
+Examples:
+  # List all recorded plans
+  strata history
+
+  # List the last 10 plans for a single root module
+  strata history --root infrastructure/prod --limit 10
+
+  # Export the history as CSV
+  strata history --output csv --file history.csv
+
+  # Show change trends per root module
+  strata history trends`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+// historyTrendsCmd represents the history trends command
+var historyTrendsCmd = &cobra.Command{
+	Use:   "trends",
+	Short: "Show change trends per root module",
+	Long: `Show the trends of the plans recorded in the history store.
+
+For every root module, the counts of the latest plan are shown next to the
+averages across all recorded plans. The trend compares the total changes in
+the latest plan with the average of the earlier plans.
+
+Examples:
+  # Show trends for all root modules
+  strata history trends
+
+  # Export the trends as JSON
+  strata history trends --output json`,
+	Args: cobra.NoArgs,
+	RunE: runHistoryTrends,
+}
+
+var (
+	historyRoot  string
+	historyLimit int
+)
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	cfg, records, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	if historyLimit > 0 && len(records) > historyLimit {
+		records = records[len(records)-historyLimit:]
+	}
+
+	outputConfig, err := historyOutputConfiguration(cfg)
+	if err != nil {
+		return err
+	}
+	return plan.NewFormatter(cfg).OutputHistory(records, outputConfig)
+}
+
+func runHistoryTrends(cmd *cobra.Command, args []string) error {
+	cfg, records, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	outputConfig, err := historyOutputConfiguration(cfg)
+	if err != nil {
+		return err
+	}
+	return plan.NewFormatter(cfg).OutputHistoryTrends(plan.HistoryTrends(records), outputConfig)
+}
+
+// loadHistory loads the configuration and the recorded plans, filtered by the --root flag
+func loadHistory() (*config.Config, []plan.HistoryRecord, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	path, err := cfg.GetHistoryPath()
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := plan.NewHistoryStore(path).List(historyRoot)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	return cfg, records, nil
+}
+
+func historyOutputConfiguration(cfg *config.Config) (*config.OutputConfiguration, error) {
+	outputConfig := cfg.NewOutputConfiguration()
+	if outputConfig.OutputFile != "" {
+		validator := config.NewFileValidator(cfg)
+		if err := validator.ValidateFileOutput(outputConfig); err != nil {
+			return nil, fmt.Errorf("file output validation failed: %w", err)
+		}
+	}
+	return outputConfig, nil
+}
+
+// recordHistory stores a plan summary when history is enabled. The root module and git details are taken
+// from workDir, the directory the plan was made in. Failures are reported as warnings, as they shouldn't
+// stop the summary from being shown.
+func recordHistory(cfg *config.Config, summary *plan.PlanSummary, workDir string) {
+	if !cfg.History.Enabled || summary == nil {
+		return
+	}
+	path, err := cfg.GetHistoryPath()
+	if err == nil {
+		err = plan.NewHistoryStore(path).Record(plan.NewHistoryRecord(summary, workDir))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Failed to record plan history: %v\n", err)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyTrendsCmd)
+
+	historyCmd.PersistentFlags().StringVar(&historyRoot, "root", "",
+		"Only include plans of this root module")
+	historyCmd.Flags().IntVar(&historyLimit, "limit", 0,
+		"Only show the most recent plans (0 shows all)")
+}
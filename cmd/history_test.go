@@ -0,0 +1,48 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordHistory_UsesPlanDirectory(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.History.Enabled = true
+	cfg.History.Path = t.TempDir()
+
+	// The plan lives in a different root module than the working directory
+	rootModule := filepath.Join(t.TempDir(), "network")
+	planFile := filepath.Join(rootModule, "terraform.tfplan")
+	recordHistory(cfg, &plan.PlanSummary{PlanFile: planFile}, filepath.Dir(planFile))
+
+	records, err := plan.NewHistoryStore(cfg.History.Path).List("")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, rootModule, records[0].Root)
+}
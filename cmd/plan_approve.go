@@ -0,0 +1,148 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os/user"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// planApproveCmd represents the plan approve command
+var planApproveCmd = &cobra.Command{
+	Use:   "approve [plan-file]",
+	Short: "Record an approval of the changes in a Terraform plan",
+	Long: `Record that the changes in a Terraform plan were reviewed and approved.
+
+The approval record contains a canonical hash of every analysed resource
+change, the approver, and the time of approval. Run strata plan verify
+before applying to check that the plan being applied contains exactly the
+changes that were approved.
+
+With --key, the approval is signed with an ed25519 private key in PEM format,
+such as one created by:
+  openssl genpkey -algorithm ed25519 -out approval.key
+  openssl pkey -in approval.key -pubout -out approval.pub
+
+Sensitive values are masked in the approval record, but the hash of every
+resource includes an HMAC of its real sensitive values, keyed with the hash
+salt (plan.sensitive_values.hash_salt or STRATA_HASH_SALT). Any change to a
+sensitive value invalidates the approval, so approve and verify with the same
+salt. Plans with sensitive values can't be approved or verified without a
+salt, as an unkeyed digest of low-entropy values such as passwords could be
+guessed from a published approval record.
+
+Examples:
+  # Approve a plan, writing terraform.tfplan.approval.json
+  strata plan approve terraform.tfplan
+
+  # Approve and sign a plan as a named reviewer
+  strata plan approve --approver jane --key approval.key terraform.tfplan`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanApprove,
+}
+
+var (
+	approvalFile string
+	approverName string
+	approvalKey  string
+)
+
+func runPlanApprove(cmd *cobra.Command, args []string) error {
+	planFile := args[0]
+
+	approver := approverName
+	if approver == "" {
+		current, err := user.Current()
+		if err != nil {
+			return fmt.Errorf("failed to determine approver, use --approver: %w", err)
+		}
+		approver = current.Username
+	}
+
+	summary, err := approvalSummary(planFile)
+	if err != nil {
+		return err
+	}
+	approval, err := plan.NewApproval(summary, approver, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to create approval: %w", err)
+	}
+	if approvalKey != "" {
+		key, err := plan.LoadSigningKey(approvalKey)
+		if err != nil {
+			return err
+		}
+		approval.Sign(key)
+	}
+
+	path := approvalPath(planFile)
+	if err := plan.SaveApproval(approval, path); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Approved %d resource changes in %s as %s (%s)\nApproval written to %s\n",
+		len(approval.Changes), planFile, approver, approval.Hash, path)
+	return nil
+}
+
+// approvalSummary analyses a plan for approval and verification. Secret scanning is disabled,
+// so redaction can't hide changes to values, and the hash doesn't depend on the scanning rules.
+func approvalSummary(planFile string) (*plan.PlanSummary, error) {
+	parser := plan.NewParser(planFile)
+	tfPlan, err := parser.LoadPlan()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan: %w", err)
+	}
+	if err := parser.ValidateStructure(tfPlan); err != nil {
+		return nil, fmt.Errorf("invalid plan structure: %w", err)
+	}
+
+	cfg, err := loadConfigForPlan(parser)
+	if err != nil {
+		return nil, err
+	}
+	cfg.SecretScanning.Enabled = false
+
+	return plan.NewAnalyzer(tfPlan, cfg).GenerateSummary(planFile), nil
+}
+
+// approvalPath returns the approval record for a plan, which is stored next to the plan by default
+func approvalPath(planFile string) string {
+	if approvalFile != "" {
+		return approvalFile
+	}
+	return planFile + ".approval.json"
+}
+
+func init() {
+	planCmd.AddCommand(planApproveCmd)
+
+	planApproveCmd.Flags().StringVar(&approvalFile, "approval", "",
+		"Approval record to write (default: <plan-file>.approval.json)")
+	planApproveCmd.Flags().StringVar(&approverName, "approver", "",
+		"Name of the approver (default: the current user)")
+	planApproveCmd.Flags().StringVar(&approvalKey, "key", "",
+		"PEM encoded ed25519 private key used to sign the approval")
+}
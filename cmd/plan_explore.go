@@ -0,0 +1,105 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ArjenSchwarz/strata/lib/explore"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// planExploreCmd represents the plan explore command
+var planExploreCmd = &cobra.Command{
+	Use:   "explore [plan-file]",
+	Short: "Interactively explore the changes in a Terraform plan",
+	Long: `Explore the changes in a Terraform plan in a full-screen terminal UI.
+
+The resource list can be filtered by action, provider, module, and minimum
+risk level, and searched by address, resource type, or danger reason. The
+detail pane shows the property changes, replacement reasons, and danger
+reasons of the selected resource.
+
+Key bindings:
+  ↑/k ↓/j     Select the previous or next resource
+  /           Search
+  a p m r     Cycle the action, provider, module, and risk filters
+  c           Clear all filters
+  y           Copy the selected address to the clipboard
+  e           Export the filtered view as summary JSON
+  ?           Show all key bindings
+  q           Quit
+
+Copying uses the OSC 52 escape sequence, which most terminal emulators
+support. Exported summaries can be rendered like any other saved summary.
+
+Examples:
+  # Explore a plan
+  strata plan explore terraform.tfplan
+
+  # Include resources without changes and export to a custom file
+  strata plan explore --show-no-ops --export filtered.json terraform.tfplan`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanExplore,
+}
+
+var (
+	exploreExportFile string
+	exploreShowNoOps  bool
+)
+
+func runPlanExplore(cmd *cobra.Command, args []string) error {
+	planFile := args[0]
+
+	parser := plan.NewParser(planFile)
+	tfPlan, err := parser.LoadPlan()
+	if err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
+	}
+	if err := parser.ValidateStructure(tfPlan); err != nil {
+		return fmt.Errorf("invalid plan structure: %w", err)
+	}
+
+	cfg, err := loadConfigForPlan(parser)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("show-no-ops") {
+		cfg.Plan.ShowNoOps = exploreShowNoOps
+	}
+
+	summary := plan.NewAnalyzer(tfPlan, cfg).GenerateSummary(planFile)
+	return explore.Run(summary, explore.Options{
+		ExportPath: exploreExportFile,
+		ShowNoOps:  cfg.Plan.ShowNoOps,
+	})
+}
+
+func init() {
+	planCmd.AddCommand(planExploreCmd)
+
+	planExploreCmd.Flags().StringVar(&exploreExportFile, "export", "strata-explore.json",
+		"File the filtered view is exported to")
+	planExploreCmd.Flags().BoolVar(&exploreShowNoOps, "show-no-ops", false,
+		"List resources without changes")
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// planQueryCmd represents the plan query command
+var planQueryCmd = &cobra.Command{
+	Use:   "query [plan-file]",
+	Short: "Select resource changes in a Terraform plan with an expression",
+	Long: `Select the resource changes in a Terraform plan that match an expression.
+
+Expressions use the expr language (https://expr-lang.org) and are evaluated
+for every resource change. They can use the fields of the resource changes in
+the JSON output, such as address, type, change_type, provider, module_path,
+is_dangerous, danger_reason, replacement_hints, and property_changes.changes,
+as well as risk_level (low, medium, high, or critical). Unknown fields are
+reported as errors, so typos don't silently match nothing.
+
+By default the matches are shown as a table in the selected output format.
+Use --addresses to print one address per line, or --records to print the
+complete resource change records as JSON.
+
+Examples:
+  # Deletions of AWS resources
+  strata plan query --where 'change_type == "delete" && provider == "aws"' terraform.tfplan
+
+  # Resources where the tags change
+  strata plan query --where 'any(property_changes.changes, .name == "tags")' terraform.tfplan
+
+  # Addresses of high and critical risk changes in the app module
+  strata plan query --addresses \
+    --where 'risk_level in ["high", "critical"] && module_path startsWith "app"' terraform.tfplan
+
+  # Full records of replacements, for further processing
+  strata plan query --records --where 'len(replacement_hints) > 0' terraform.tfplan`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanQuery,
+}
+
+var (
+	queryWhere     string
+	queryAddresses bool
+	queryRecords   bool
+	queryShowNoOps bool
+)
+
+func runPlanQuery(cmd *cobra.Command, args []string) error {
+	planFile := args[0]
+
+	// The expression is checked first, so mistakes are reported without analysing the plan
+	query, err := plan.CompileQuery(queryWhere)
+	if err != nil {
+		return err
+	}
+
+	parser := plan.NewParser(planFile)
+	tfPlan, err := parser.LoadPlan()
+	if err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
+	}
+	if err := parser.ValidateStructure(tfPlan); err != nil {
+		return fmt.Errorf("invalid plan structure: %w", err)
+	}
+
+	cfg, err := loadConfigForPlan(parser)
+	if err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("show-no-ops") {
+		cfg.Plan.ShowNoOps = queryShowNoOps
+	}
+
+	summary := plan.NewAnalyzer(tfPlan, cfg).GenerateSummary(planFile)
+	changes := summary.ResourceChanges
+	if !cfg.Plan.ShowNoOps {
+		changes = plan.NewResourceFilter(config.ResourceFilterConfig{
+			Actions: []string{"create", "update", "delete", "replace"},
+		}).Apply(changes)
+	}
+	matches, err := query.Apply(changes)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case queryAddresses:
+		for _, change := range matches {
+			_, _ = fmt.Fprintln(out, change.Address)
+		}
+		return nil
+	case queryRecords:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(matches); err != nil {
+			return fmt.Errorf("failed to encode query results: %w", err)
+		}
+		return nil
+	}
+
+	outputConfig := cfg.NewOutputConfiguration()
+	if outputConfig.OutputFile != "" {
+		validator := config.NewFileValidator(cfg)
+		if err := validator.ValidateFileOutput(outputConfig); err != nil {
+			return fmt.Errorf("file output validation failed: %w", err)
+		}
+	}
+	return plan.NewFormatter(cfg).OutputQueryResults(matches, outputConfig)
+}
+
+func init() {
+	planCmd.AddCommand(planQueryCmd)
+
+	planQueryCmd.Flags().StringVar(&queryWhere, "where", "",
+		"Expression that resource changes have to match")
+	_ = planQueryCmd.MarkFlagRequired("where")
+	planQueryCmd.Flags().BoolVar(&queryAddresses, "addresses", false,
+		"Print the address of every match on its own line")
+	planQueryCmd.Flags().BoolVar(&queryRecords, "records", false,
+		"Print the complete resource change records of the matches as JSON")
+	planQueryCmd.MarkFlagsMutuallyExclusive("addresses", "records")
+	planQueryCmd.Flags().BoolVar(&queryShowNoOps, "show-no-ops", false,
+		"Include resources without changes")
+}
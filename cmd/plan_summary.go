@@ -23,6 +23,7 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/ArjenSchwarz/strata/config"
 	"github.com/ArjenSchwarz/strata/lib/plan"
@@ -94,6 +95,16 @@ Examples:
   # Include no-op resources in the summary
   strata plan summary --show-no-ops terraform.tfplan
 
+  # Only show high-risk deletions and replacements in the networking module
+  strata plan summary --action delete,replace --min-risk high --module networking terraform.tfplan
+
+  # Show all IAM resources except the ones in the sandbox module
+  strata plan summary --type 'aws_iam_*' --exclude 'module.sandbox.*' terraform.tfplan
+
+  # Save the summary for later runs and label changes seen in the last 3 plans
+  strata plan summary --save-summary summaries/$(date +%s).json \
+    --previous-summaries summaries terraform.tfplan
+
 Configuration:
 The summary behavior can be customized through the strata.yaml configuration file:
 
@@ -105,10 +116,35 @@ The summary behavior can be customized through the strata.yaml configuration fil
     expandable_sections:
       enabled: true                    # Enable collapsible sections
       auto_expand_dangerous: true      # Auto-expand high-risk sections
-      show_dependencies: true          # Show dependency information
+      max_detail_length: 10240         # Maximum characters for collapsible details
     grouping:
       enabled: true                    # Enable provider grouping
-      threshold: 10                    # Minimum resources to trigger grouping`,
+      threshold: 10                    # Minimum resources to trigger grouping
+    multiline_diff:
+      enabled: true                    # Show multiline strings as a unified diff
+      context_lines: 3                 # Unchanged lines shown around each change
+    sensitive_values:
+      show_hash: false                 # Show a salted short hash of sensitive values
+      hash_salt: ""                    # Salt for the hash (or set STRATA_HASH_SALT)
+    perpetual_diff:
+      summaries_dir: ""                # Directory of earlier summaries saved with --save-summary
+      threshold: 3                     # Consecutive plans with the same change, including this one
+      collapse: false                  # Hide perpetual diffs in the property changes
+    filter:                            # Resources shown, statistics still cover the full plan
+      include: []                      # Address globs, where * matches any characters
+      exclude: []                      # Address globs of resources to hide
+      actions: []                      # create, update, delete, replace, no-op
+      providers: []                    # Providers, such as aws
+      types: []                        # Resource type globs, such as aws_iam_*
+      modules: []                      # Module paths or addresses; root for resources outside modules
+      min_risk: ""                     # low, medium, high, or critical
+
+  secret_scanning:
+    enabled: true                      # Redact secrets found in non-sensitive values
+    entropy_threshold: 4.5             # Minimum entropy for the high-entropy heuristic
+    patterns:                          # Additional secret patterns
+      - name: internal_token
+        pattern: "itk_[a-z0-9]{32}"`,
 	Args: cobra.ExactArgs(1),
 	RunE: runPlanSummary,
 }
@@ -119,81 +155,57 @@ var (
 	showStatisticsSummary   bool
 	statisticsSummaryFormat string
 	showNoOps               bool
+	previousSummariesDir    string
+	saveSummaryFile         string
+	collapsePerpetualDiffs  bool
+	resourceFilter          config.ResourceFilterConfig
 )
 
 func runPlanSummary(cmd *cobra.Command, args []string) error {
 	planFile := args[0]
 
-	// Create parser and load plan
-	parser := plan.NewParser(planFile)
-	tfPlan, err := parser.LoadPlan()
+	// Load configuration, then apply command flags. The workspace comes from the environment,
+	// so the configuration is known before the plan is read.
+	cfg, err := loadConfigForPlan(plan.NewParser(planFile))
 	if err != nil {
-		return fmt.Errorf("failed to load plan: %w", err)
+		return err
 	}
-
-	// Validate plan structure
-	if err := parser.ValidateStructure(tfPlan); err != nil {
-		return fmt.Errorf("invalid plan structure: %w", err)
-	}
-
-	// Create config for analyzer with defaults
-	cfg := config.GetDefaultConfig()
 	cfg.Plan.ShowDetails = showDetails
 	cfg.Plan.HighlightDangers = highlightDangers
 	cfg.Plan.ShowStatisticsSummary = showStatisticsSummary
 	cfg.Plan.StatisticsSummaryFormat = statisticsSummaryFormat
 	cfg.Plan.ShowNoOps = showNoOps
-
-	// Read expand-all configuration from Viper (includes CLI flag override)
-	cfg.ExpandAll = viper.GetBool("expand_all")
-
-	// Load expandable sections configuration from config file if it exists
-	if viper.IsSet("plan.expandable_sections") {
-		if err := viper.UnmarshalKey("plan.expandable_sections", &cfg.Plan.ExpandableSections); err != nil {
-			return fmt.Errorf("failed to parse expandable_sections config: %w", err)
-		}
+	if cmd.Flags().Changed("previous-summaries") {
+		cfg.Plan.PerpetualDiff.SummariesDir = previousSummariesDir
 	}
-
-	// Load grouping configuration from config file if it exists
-	if viper.IsSet("plan.grouping") {
-		if err := viper.UnmarshalKey("plan.grouping", &cfg.Plan.Grouping); err != nil {
-			return fmt.Errorf("failed to parse grouping config: %w", err)
-		}
+	if cmd.Flags().Changed("collapse-perpetual-diffs") {
+		cfg.Plan.PerpetualDiff.Collapse = collapsePerpetualDiffs
 	}
-
-	// Load performance limits configuration from config file if it exists
-	if viper.IsSet("plan.performance_limits") {
-		if err := viper.UnmarshalKey("plan.performance_limits", &cfg.Plan.PerformanceLimits); err != nil {
-			return fmt.Errorf("failed to parse performance_limits config: %w", err)
-		}
+	if err := applyFilterFlags(cmd, &cfg.Plan.Filter); err != nil {
+		return err
 	}
 
-	// Load sensitive resources and properties from config file if they exist
-	if viper.IsSet("sensitive_resources") {
-		if err := viper.UnmarshalKey("sensitive_resources", &cfg.SensitiveResources); err != nil {
-			return fmt.Errorf("failed to parse sensitive_resources config: %w", err)
-		}
+	// Resource changes are analysed while the plan is read, so they aren't all held in memory
+	summary, err := plan.NewAnalyzer(nil, cfg).SummarizePlanFile(cmd.Context(), planFile)
+	if err != nil {
+		return fmt.Errorf("failed to load plan: %w", err)
 	}
+	recordHistory(cfg, summary, filepath.Dir(planFile))
 
-	if viper.IsSet("sensitive_properties") {
-		if err := viper.UnmarshalKey("sensitive_properties", &cfg.SensitiveProperties); err != nil {
-			return fmt.Errorf("failed to parse sensitive_properties config: %w", err)
+	// Compare with earlier summaries before saving this one, so it isn't compared with itself
+	if dir := cfg.Plan.PerpetualDiff.SummariesDir; dir != "" {
+		previous, err := plan.LoadPreviousSummaries(dir, summary)
+		if err != nil {
+			return fmt.Errorf("failed to load previous summaries: %w", err)
 		}
+		plan.DetectPerpetualDiffs(summary, previous, cfg.Plan.PerpetualDiff.Threshold)
 	}
-
-	// Handle configuration migration and show deprecation warnings
-	warnings := cfg.MigrateDeprecatedConfig()
-	config.PrintDeprecationWarnings(warnings)
-
-	// Validate configuration
-	if err := cfg.ValidateConfiguration(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+	if saveSummaryFile != "" {
+		if err := plan.SaveSummary(summary, saveSummaryFile); err != nil {
+			return err
+		}
 	}
 
-	// Create analyzer and generate summary
-	analyzer := plan.NewAnalyzer(tfPlan, cfg)
-	summary := analyzer.GenerateSummary(planFile)
-
 	// Create formatter and output summary
 	formatter := plan.NewFormatter(cfg)
 
@@ -248,4 +260,60 @@ func init() {
 	if err := viper.BindPFlag("plan.show-no-ops", planSummaryCmd.Flags().Lookup("show-no-ops")); err != nil {
 		panic(err)
 	}
+
+	// Perpetual diff flags
+	planSummaryCmd.Flags().StringVar(&previousSummariesDir, "previous-summaries", "",
+		"Directory of earlier summaries used to detect perpetual diffs")
+	planSummaryCmd.Flags().StringVar(&saveSummaryFile, "save-summary", "",
+		"Save the summary as JSON for perpetual diff detection in later runs")
+	planSummaryCmd.Flags().BoolVar(&collapsePerpetualDiffs, "collapse-perpetual-diffs", false,
+		"Hide perpetual diffs in the property changes")
+
+	addFilterFlags(planSummaryCmd)
+}
+
+// addFilterFlags adds the resource filter flags, which are applied with applyFilterFlags
+func addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&resourceFilter.Include, "include", nil,
+		"Only show resources whose address matches these globs")
+	cmd.Flags().StringSliceVar(&resourceFilter.Exclude, "exclude", nil,
+		"Hide resources whose address matches these globs")
+	cmd.Flags().StringSliceVar(&resourceFilter.Actions, "action", nil,
+		"Only show these actions (create, update, delete, replace, no-op)")
+	cmd.Flags().StringSliceVar(&resourceFilter.Providers, "provider", nil,
+		"Only show resources of these providers")
+	cmd.Flags().StringSliceVar(&resourceFilter.Types, "type", nil,
+		"Only show resources whose type matches these globs")
+	cmd.Flags().StringSliceVar(&resourceFilter.Modules, "module", nil,
+		"Only show resources in these modules and their child modules (root for the root module)")
+	cmd.Flags().StringVar(&resourceFilter.MinRisk, "min-risk", "",
+		"Only show resources with at least this risk level (low, medium, high, critical)")
+}
+
+// applyFilterFlags overrides the configured resource filter with the filter flags that were set
+func applyFilterFlags(cmd *cobra.Command, filter *config.ResourceFilterConfig) error {
+	overrides := map[string]*[]string{
+		"include":  &filter.Include,
+		"exclude":  &filter.Exclude,
+		"action":   &filter.Actions,
+		"provider": &filter.Providers,
+		"type":     &filter.Types,
+		"module":   &filter.Modules,
+	}
+	for name, target := range overrides {
+		if cmd.Flags().Changed(name) {
+			values, err := cmd.Flags().GetStringSlice(name)
+			if err != nil {
+				return err
+			}
+			*target = values
+		}
+	}
+	if cmd.Flags().Changed("min-risk") {
+		filter.MinRisk = resourceFilter.MinRisk
+	}
+	if err := filter.Validate(); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	return nil
 }
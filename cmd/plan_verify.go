@@ -0,0 +1,96 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// planVerifyCmd represents the plan verify command
+var planVerifyCmd = &cobra.Command{
+	Use:   "verify [plan-file]",
+	Short: "Verify that a Terraform plan matches its approval",
+	Long: `Verify that a Terraform plan contains exactly the changes that were approved.
+
+The resource changes of the plan are hashed the same way as by strata plan
+approve and compared with the approval record. The command fails and lists
+the differences when resources were added, removed, or changed since the
+plan was approved, so it can be used as a gate before terraform apply.
+
+Signed approvals are always checked for tampering. With --public-key, the
+approval must be signed with the matching private key.
+
+Examples:
+  # Verify a plan against terraform.tfplan.approval.json
+  strata plan verify terraform.tfplan && terraform apply terraform.tfplan
+
+  # Require an approval signed by a trusted key
+  strata plan verify --public-key approval.pub terraform.tfplan`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPlanVerify,
+}
+
+var approvalPublicKey string
+
+func runPlanVerify(cmd *cobra.Command, args []string) error {
+	planFile := args[0]
+
+	approval, err := plan.LoadApproval(approvalPath(planFile))
+	if err != nil {
+		return err
+	}
+
+	var trusted ed25519.PublicKey
+	if approvalPublicKey != "" {
+		if trusted, err = plan.LoadPublicKey(approvalPublicKey); err != nil {
+			return err
+		}
+	}
+	if err := approval.VerifySignature(trusted); err != nil {
+		return err
+	}
+
+	summary, err := approvalSummary(planFile)
+	if err != nil {
+		return err
+	}
+	if err := approval.Verify(summary); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(cmd.OutOrStdout(), "Plan %s matches the approval by %s at %s (%d resource changes)\n",
+		planFile, approval.Approver, approval.ApprovedAt.Format("2006-01-02 15:04:05 MST"), len(approval.Changes))
+	return nil
+}
+
+func init() {
+	planCmd.AddCommand(planVerifyCmd)
+
+	planVerifyCmd.Flags().StringVar(&approvalFile, "approval", "",
+		"Approval record to verify against (default: <plan-file>.approval.json)")
+	planVerifyCmd.Flags().StringVar(&approvalPublicKey, "public-key", "",
+		"PEM encoded ed25519 public key the approval must be signed with")
+}
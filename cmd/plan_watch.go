@@ -0,0 +1,172 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// planWatchCmd represents the plan watch command
+var planWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Summarise the streaming output of terraform plan -json",
+	Long: `Summarise a plan while Terraform is still creating it.
+
+This command reads the machine-readable output of terraform plan -json from
+stdin, analyzes every planned change as it arrives, and shows progress on
+stderr. Once Terraform reports its change summary, the normal plan summary is
+rendered, without needing a separate terraform show -json step.
+
+The plan stream doesn't contain attribute values, so property changes and
+property-based danger highlights aren't available. Resource types listed under
+sensitive_resources, deletions, and replacements are still highlighted.
+
+Examples:
+  # Watch a plan as it is created
+  terraform plan -json | strata plan watch
+
+  # Save the plan for apply and summarise it in a single run
+  terraform plan -json -out=terraform.tfplan | strata plan watch
+
+  # Save the summary as markdown
+  terraform plan -json | strata plan watch --file plan.md --file-format markdown`,
+	Args: cobra.NoArgs,
+	RunE: runPlanWatch,
+}
+
+var watchShowProgress bool
+
+func runPlanWatch(cmd *cobra.Command, args []string) error {
+	// Configuration is loaded first so invalid settings are reported before Terraform finishes. The plan is
+	// read from stdin, so its workspace is the one selected in the current directory.
+	cfg, err := loadConfigForPlan(plan.NewParser("(stdin)"))
+	if err != nil {
+		return err
+	}
+	cfg.Plan.ShowDetails = viper.GetBool("plan.show-details")
+	cfg.Plan.HighlightDangers = viper.GetBool("plan.highlight-dangers")
+	cfg.Plan.ShowStatisticsSummary = viper.GetBool("plan.show-statistics-summary")
+	cfg.Plan.StatisticsSummaryFormat = viper.GetString("plan.statistics-summary-format")
+	cfg.Plan.ShowNoOps = viper.GetBool("plan.show-no-ops")
+
+	outputConfig := cfg.NewOutputConfiguration()
+	if outputConfig.OutputFile != "" {
+		validator := config.NewFileValidator(cfg)
+		if err := validator.ValidateFileOutput(outputConfig); err != nil {
+			return fmt.Errorf("file output validation failed: %w", err)
+		}
+	}
+
+	stream := plan.NewPlanStream(plan.NewAnalyzer(nil, cfg), "(stdin)")
+	var progress *watchProgress
+	if watchShowProgress {
+		progress = newWatchProgress(os.Stderr)
+	}
+	err = stream.Read(cmd.InOrStdin(), progress.update)
+	progress.finish()
+	if err != nil {
+		return err
+	}
+
+	// Streamed plans don't have a plan file, so they belong to the root module terraform runs in
+	summary := stream.Summary()
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to determine the working directory: %w", err)
+	}
+	recordHistory(cfg, summary, workDir)
+
+	return plan.NewFormatter(cfg).OutputSummary(summary, outputConfig, cfg.Plan.ShowDetails)
+}
+
+// watchProgress reports plan stream progress. On a terminal a single status line is
+// updated in place, otherwise every change is written on its own line.
+type watchProgress struct {
+	out      io.Writer
+	terminal bool
+	counts   map[plan.ChangeType]int
+	drift    int
+}
+
+func newWatchProgress(out *os.File) *watchProgress {
+	terminal := false
+	if info, err := out.Stat(); err == nil {
+		terminal = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &watchProgress{out: out, terminal: terminal, counts: map[plan.ChangeType]int{}}
+}
+
+func (p *watchProgress) update(event plan.PlanStreamEvent) {
+	if p == nil {
+		return
+	}
+	if event.Type == plan.PlanStreamResourceDrift {
+		p.drift++
+		if !p.terminal {
+			fmt.Fprintf(p.out, "drift: %s (%s outside of Terraform)\n", event.Address, event.ChangeType)
+		}
+	} else {
+		p.counts[event.ChangeType]++
+		if !p.terminal {
+			fmt.Fprintf(p.out, "planned: %s (%s)\n", event.Address, event.ChangeType)
+		}
+	}
+	if p.terminal {
+		fmt.Fprintf(p.out, "\r\033[KPlanning... %s", p.status())
+	}
+}
+
+func (p *watchProgress) finish() {
+	if p == nil {
+		return
+	}
+	if p.terminal {
+		fmt.Fprint(p.out, "\r\033[K")
+	}
+	if p.drift > 0 {
+		fmt.Fprintf(p.out, "Resources changed outside of Terraform: %d\n", p.drift)
+	}
+}
+
+func (p *watchProgress) status() string {
+	status := fmt.Sprintf("%d to add, %d to change, %d to replace, %d to destroy",
+		p.counts[plan.ChangeTypeCreate], p.counts[plan.ChangeTypeUpdate],
+		p.counts[plan.ChangeTypeReplace], p.counts[plan.ChangeTypeDelete])
+	if p.drift > 0 {
+		status += fmt.Sprintf(", %d drifted", p.drift)
+	}
+	return status
+}
+
+func init() {
+	planCmd.AddCommand(planWatchCmd)
+
+	planWatchCmd.Flags().BoolVar(&watchShowProgress, "progress", true,
+		"Show progress on stderr while the plan is created")
+}
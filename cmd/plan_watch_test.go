@@ -0,0 +1,58 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchProgress(t *testing.T) {
+	t.Run("writes a line per change when not on a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		progress := &watchProgress{out: &out, counts: map[plan.ChangeType]int{}}
+		progress.update(plan.PlanStreamEvent{Type: plan.PlanStreamResourceDrift, Address: "aws_security_group.web", ChangeType: plan.ChangeTypeUpdate})
+		progress.update(plan.PlanStreamEvent{Type: plan.PlanStreamPlannedChange, Address: "aws_instance.web", ChangeType: plan.ChangeTypeCreate})
+		progress.finish()
+
+		assert.Equal(t, "drift: aws_security_group.web (update outside of Terraform)\n"+
+			"planned: aws_instance.web (create)\n"+
+			"Resources changed outside of Terraform: 1\n", out.String())
+	})
+
+	t.Run("updates a status line on a terminal", func(t *testing.T) {
+		var out bytes.Buffer
+		progress := &watchProgress{out: &out, terminal: true, counts: map[plan.ChangeType]int{}}
+		progress.update(plan.PlanStreamEvent{Type: plan.PlanStreamPlannedChange, Address: "aws_instance.web", ChangeType: plan.ChangeTypeReplace})
+
+		assert.Equal(t, "\r\033[KPlanning... 0 to add, 0 to change, 1 to replace, 0 to destroy", out.String())
+	})
+
+	t.Run("disabled progress is a no-op", func(t *testing.T) {
+		var progress *watchProgress
+		progress.update(plan.PlanStreamEvent{Type: plan.PlanStreamPlannedChange})
+		progress.finish()
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// renderCmd represents the render command
+var renderCmd = &cobra.Command{
+	Use:   "render [summary-file]",
+	Short: "Render a saved plan summary",
+	Long: `Render a plan summary that was saved as JSON, without the plan file or
+Terraform.
+
+Summaries are saved with the --save-summary flag of plan summary, or written by
+the Go library. This makes it possible to analyse a plan once and then render
+it in as many formats as needed, for example markdown for a pull request, an
+HTML report as a build artifact, and JSON for a bot. The summary is rendered in
+the same way as by plan summary, and the display settings and resource filter
+can be changed for every render.
+
+Examples:
+  # Analyse the plan once
+  strata plan summary --save-summary summary.json terraform.tfplan
+
+  # Render the saved summary in other formats
+  strata render summary.json --output markdown
+  strata render summary.json --output report --file plan-report.html
+  strata render summary.json --output json
+
+  # Only show the deletions and replacements
+  strata render summary.json --action delete,replace`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRender,
+}
+
+func runRender(cmd *cobra.Command, args []string) error {
+	summary, err := plan.LoadSummaryFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load summary: %w", err)
+	}
+
+	// Load configuration for the workspace of the summary, then apply command flags
+	cfg, err := loadConfigForWorkspace(summary.Workspace)
+	if err != nil {
+		return err
+	}
+	cfg.Plan.ShowDetails = showDetails
+	cfg.Plan.ShowStatisticsSummary = showStatisticsSummary
+	cfg.Plan.StatisticsSummaryFormat = statisticsSummaryFormat
+	cfg.Plan.ShowNoOps = showNoOps
+	if err := applyFilterFlags(cmd, &cfg.Plan.Filter); err != nil {
+		return err
+	}
+
+	outputConfig := cfg.NewOutputConfiguration()
+	if outputConfig.OutputFile != "" {
+		validator := config.NewFileValidator(cfg)
+		if err := validator.ValidateFileOutput(outputConfig); err != nil {
+			return fmt.Errorf("file output validation failed: %w", err)
+		}
+	}
+
+	return plan.NewFormatter(cfg).OutputSummary(summary, outputConfig, showDetails)
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+
+	renderCmd.Flags().BoolVar(&showDetails, "details", true,
+		"Show detailed change information")
+	renderCmd.Flags().BoolVar(&showStatisticsSummary, "show-statistics", true,
+		"Show statistics summary table")
+	renderCmd.Flags().StringVar(&statisticsSummaryFormat, "stats-format", "horizontal",
+		"Statistics summary format (horizontal, vertical)")
+	renderCmd.Flags().BoolVar(&showNoOps, "show-no-ops", false,
+		"Show no-op resources in the summary")
+	addFilterFlags(renderCmd)
+}
@@ -78,9 +78,10 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.strata.yaml)")
 
 	// Output format flags
-	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table, json, csv, html, markdown)")
+	rootCmd.PersistentFlags().StringP("output", "o", "table", "Output format (table, json, csv, html, markdown, template, report)")
 	rootCmd.PersistentFlags().String("file", "", "Optional file to save the output to, in addition to stdout")
 	rootCmd.PersistentFlags().String("file-format", "", "Optional format for the file, defaults to the same as output")
+	rootCmd.PersistentFlags().String("template", "", "Go template file used by the template output format")
 
 	// Table format flags
 	rootCmd.PersistentFlags().String("table-style", "", "Table style for table output")
@@ -96,6 +97,8 @@ func init() {
 	cobra.CheckErr(err)
 	err = viper.BindPFlag("output-file-format", rootCmd.PersistentFlags().Lookup("file-format"))
 	cobra.CheckErr(err)
+	err = viper.BindPFlag("template", rootCmd.PersistentFlags().Lookup("template"))
+	cobra.CheckErr(err)
 
 	// Bind table flags to Viper
 	err = viper.BindPFlag("table.style", rootCmd.PersistentFlags().Lookup("table-style"))
@@ -132,5 +135,6 @@ func initConfig() {
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
+		configLayersErr = readConfigLayers(viper.ConfigFileUsed())
 	}
 }
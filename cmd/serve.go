@@ -0,0 +1,170 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/lib/server"
+	"github.com/spf13/cobra"
+)
+
+// runTaskHMACKeyEnv is the environment variable holding the run task HMAC key, so it doesn't show up in process lists
+const runTaskHMACKeyEnv = "STRATA_RUN_TASK_HMAC_KEY"
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run Strata as an HTTP service",
+	Long: `Run Strata as an HTTP service.
+
+The API summarises plans in-process. POST the output of terraform show -json
+to /v1/summaries and the summary is returned in the format selected by the
+Accept header (application/json, text/markdown, text/html, text/csv, or
+text/plain for a table), or by the format query parameter.
+
+Display settings can be overridden per request with the details, show_no_ops,
+expand_all, group_by_provider, and use_emoji query parameters. Everything else
+comes from the configuration file the server was started with.
+
+The size of uploaded plans and the number of summaries generated at the same
+time are limited. Requests beyond the concurrency limit receive a 503 response
+with a Retry-After header. Health and Prometheus metrics are served at
+/healthz and /metrics.
+
+With --run-task, Strata also acts as a Terraform Cloud or Terraform Enterprise
+run task at /run-task. For each run it downloads the plan JSON, analyses it,
+and sends back a result with a markdown summary and an outcome for every
+changed resource. Runs with high-risk changes fail unless
+--fail-on-danger=false is set, which blocks mandatory run tasks. The run task
+must have an HMAC key, set in the ` + runTaskHMACKeyEnv + ` environment variable
+(or --hmac-key), and unsigned requests are rejected. Runs share the concurrency
+limit of the API, and runs beyond it receive a 503 response.
+
+Examples:
+  # Serve the API on port 8080
+  strata serve --addr :8080
+
+  # Summarise a plan as markdown
+  terraform show -json terraform.tfplan | curl -s --data-binary @- \
+    -H "Accept: text/markdown" http://localhost:8080/v1/summaries
+
+  # Also serve the run task endpoint
+  STRATA_RUN_TASK_HMAC_KEY=secret strata serve --run-task`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+var (
+	serveAddr           string
+	serveMaxRequestSize int64
+	serveMaxConcurrent  int
+	serveRunTask        bool
+	runTaskHMACKey      string
+	runTaskFailDanger   bool
+)
+
+func runServe(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	logger := log.New(cmd.ErrOrStderr(), "", log.LstdFlags)
+	api := server.New(cfg, server.Options{
+		MaxRequestSize: serveMaxRequestSize,
+		MaxConcurrent:  serveMaxConcurrent,
+	}, logger)
+
+	var runTask *server.RunTaskHandler
+	if serveRunTask {
+		hmacKey := runTaskHMACKey
+		if hmacKey == "" {
+			hmacKey = os.Getenv(runTaskHMACKeyEnv)
+		}
+		runTask, err = server.NewRunTaskHandler(cfg, hmacKey, runTaskFailDanger, logger)
+		if err != nil {
+			return fmt.Errorf("%w: set %s or --hmac-key", err, runTaskHMACKeyEnv)
+		}
+		api.HandleRunTask("/run-task", runTask)
+	}
+
+	srv := &http.Server{
+		Addr:              serveAddr,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		logger.Printf("listening on %s", serveAddr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+	case <-ctx.Done():
+		logger.Printf("shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shut down: %w", err)
+		}
+	}
+
+	// Runs that were acknowledged still need their results
+	if runTask != nil {
+		runTask.Wait()
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080",
+		"Address to listen on")
+	serveCmd.Flags().Int64Var(&serveMaxRequestSize, "max-request-size", server.DefaultMaxRequestSize,
+		"Maximum size of an uploaded plan in bytes")
+	serveCmd.Flags().IntVar(&serveMaxConcurrent, "max-concurrent", server.DefaultMaxConcurrent,
+		"Maximum number of summaries generated at the same time")
+	serveCmd.Flags().BoolVar(&serveRunTask, "run-task", false,
+		"Serve the Terraform Cloud run task endpoint at /run-task")
+	serveCmd.Flags().StringVar(&runTaskHMACKey, "hmac-key", "",
+		"HMAC key of the run task (default: $"+runTaskHMACKeyEnv+")")
+	serveCmd.Flags().BoolVar(&runTaskFailDanger, "fail-on-danger", true,
+		"Fail runs that contain high-risk changes")
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2025 Arjen Schwarz <developer@arjen.eu>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/spf13/cobra"
+)
+
+// stateSummaryCmd represents the state summary command
+var stateSummaryCmd = &cobra.Command{
+	Use:   "summary [state-file]",
+	Short: "Generate an inventory of the resources in a Terraform state",
+	Long: `Generate an inventory of the resources managed by a Terraform state.
+
+This command reads either a local terraform.tfstate file or the output of
+terraform show -json, and summarises the managed resources with counts per
+provider, resource type, and module. Resource types listed under
+sensitive_resources in the configuration file are flagged.
+
+Data sources are counted but not listed, and deposed objects are ignored.
+
+Examples:
+  # Summarise a local state file
+  strata state summary terraform.tfstate
+
+  # Summarise the state of a remote backend
+  terraform show -json > state.json
+  strata state summary state.json
+
+  # Only list sensitive resources alongside the counts
+  strata state summary --details=false terraform.tfstate
+
+  # Save the inventory as markdown
+  strata state summary --file inventory.md --file-format markdown terraform.tfstate`,
+	Args: cobra.ExactArgs(1),
+	RunE: runStateSummary,
+}
+
+var stateShowDetails bool
+
+func runStateSummary(cmd *cobra.Command, args []string) error {
+	stateFile := args[0]
+
+	state, err := plan.NewStateParser(stateFile).LoadState()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	summary := plan.NewAnalyzer(nil, cfg).AnalyzeState(state, stateFile)
+
+	outputConfig := cfg.NewOutputConfiguration()
+	if outputConfig.OutputFile != "" {
+		validator := config.NewFileValidator(cfg)
+		if err := validator.ValidateFileOutput(outputConfig); err != nil {
+			return fmt.Errorf("file output validation failed: %w", err)
+		}
+	}
+
+	return plan.NewFormatter(cfg).OutputStateSummary(summary, outputConfig, stateShowDetails)
+}
+
+func init() {
+	stateCmd.AddCommand(stateSummaryCmd)
+
+	stateSummaryCmd.Flags().BoolVar(&stateShowDetails, "details", true,
+		"List every managed resource, not only sensitive ones")
+}
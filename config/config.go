@@ -3,14 +3,21 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
 const (
 	markdownFormat = "markdown"
+
+	// defaultHistoryPath is where plan summaries are recorded when history is enabled
+	defaultHistoryPath = "~/.strata/history"
 )
 
 // SensitiveResource defines a resource type that should be flagged as sensitive
@@ -47,6 +54,45 @@ type Config struct {
 	// Sensitive resources and properties configuration
 	SensitiveResources  []SensitiveResource `mapstructure:"sensitive_resources"`
 	SensitiveProperties []SensitiveProperty `mapstructure:"sensitive_properties"`
+
+	// Secret detection for values that aren't marked as sensitive
+	SecretScanning SecretScanningConfig `mapstructure:"secret_scanning"`
+
+	// Local store of past plan summaries for trend reports
+	History HistoryConfig `mapstructure:"history"`
+}
+
+// HistoryConfig controls the opt-in store of past plan summaries
+type HistoryConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // Record every plan summary in the history store
+	Path    string `mapstructure:"path"`    // Directory of the history store (default: ~/.strata/history)
+}
+
+// GetHistoryPath returns the directory of the history store, with a leading ~ expanded
+func (config *Config) GetHistoryPath() (string, error) {
+	path := config.History.Path
+	if path == "" {
+		path = defaultHistoryPath
+	}
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand history path %q: %w", path, err)
+	}
+	return expanded, nil
+}
+
+// SecretScanningConfig controls detection and redaction of secrets in non-sensitive values
+type SecretScanningConfig struct {
+	Enabled          bool            `mapstructure:"enabled"`            // Scan and redact values for secrets
+	EntropyThreshold float64         `mapstructure:"entropy_threshold"`  // Minimum Shannon entropy (bits per character) for the entropy heuristic (default: 4.5)
+	MinEntropyLength int             `mapstructure:"min_entropy_length"` // Minimum token length for the entropy heuristic (default: 20)
+	Patterns         []SecretPattern `mapstructure:"patterns"`           // Additional patterns to detect
+}
+
+// SecretPattern defines a custom regular expression that identifies a secret
+type SecretPattern struct {
+	Name    string `mapstructure:"name"`
+	Pattern string `mapstructure:"pattern"`
 }
 
 // PlanConfig holds configuration specific to plan operations
@@ -64,6 +110,10 @@ type PlanConfig struct {
 	ExpandableSections ExpandableSectionsConfig `mapstructure:"expandable_sections"` // Collapsible sections configuration
 	Grouping           GroupingConfig           `mapstructure:"grouping"`            // Enhanced grouping configuration
 	PerformanceLimits  PerformanceLimitsConfig  `mapstructure:"performance_limits"`  // Performance and memory limits
+	MultilineDiff      MultilineDiffConfig      `mapstructure:"multiline_diff"`      // Unified diff rendering for multiline strings
+	SensitiveValues    SensitiveValuesConfig    `mapstructure:"sensitive_values"`    // Display of sensitive value changes
+	PerpetualDiff      PerpetualDiffConfig      `mapstructure:"perpetual_diff"`      // Detection of changes that repeat in every plan
+	Filter             ResourceFilterConfig     `mapstructure:"filter"`              // Resources shown in the summary
 }
 
 // GetLCString returns a lowercase string value for the given setting
@@ -99,6 +149,7 @@ type OutputConfiguration struct {
 	UseColors        bool
 	TableStyle       string
 	MaxColumnWidth   int
+	Template         string // Go template file used by the template output format
 }
 
 // NewOutputConfiguration creates a new output configuration from the global config
@@ -128,6 +179,20 @@ func (config *Config) NewOutputConfiguration() *OutputConfiguration {
 		UseColors:        useColors,
 		TableStyle:       config.GetString("table.style"),
 		MaxColumnWidth:   config.GetInt("table.max-column-width"),
+		Template:         config.GetString("template"),
+	}
+}
+
+// NewOutputConfigurationForFormat creates an output configuration for the given format from the configuration
+// alone, without command line settings or file output. It is used when rendering outside of a command, such
+// as in the API server, where the global settings don't apply to the request being handled.
+func (config *Config) NewOutputConfigurationForFormat(format string) *OutputConfiguration {
+	return &OutputConfiguration{
+		Format:           strings.ToLower(format),
+		OutputFileFormat: strings.ToLower(format),
+		UseEmoji:         config.UseEmoji,
+		TableStyle:       config.Table.Style,
+		MaxColumnWidth:   config.Table.MaxColumnWidth,
 	}
 }
 
@@ -183,6 +248,94 @@ type ExpandableSectionsConfig struct {
 	MaxDetailLength     int  `mapstructure:"max_detail_length"`     // Maximum characters for collapsible details (default: 10240)
 }
 
+// MultilineDiffConfig controls unified diff rendering for multiline string attributes
+type MultilineDiffConfig struct {
+	Enabled      bool `mapstructure:"enabled"`       // Show multiline string changes as a unified diff
+	ContextLines int  `mapstructure:"context_lines"` // Unchanged lines shown around each change (default: 3)
+}
+
+// PerpetualDiffConfig controls detection of property changes that appear identically in consecutive plans
+type PerpetualDiffConfig struct {
+	SummariesDir string `mapstructure:"summaries_dir"` // Directory of previously saved summary JSON files; detection is disabled when empty
+	Threshold    int    `mapstructure:"threshold"`     // Consecutive plans, including the current one, a change must appear in (default: 3, 0 uses the default)
+	Collapse     bool   `mapstructure:"collapse"`      // Hide perpetual diffs from the property change details
+}
+
+// ResourceFilterConfig limits the resources shown in plan summaries. The summary statistics always cover the
+// full plan. Each setting matches any of its values, and a resource has to match every setting that is set.
+type ResourceFilterConfig struct {
+	Include   []string `mapstructure:"include"`   // Address globs of resources to show, where * matches any characters
+	Exclude   []string `mapstructure:"exclude"`   // Address globs of resources to hide
+	Actions   []string `mapstructure:"actions"`   // Actions to show: create, update, delete, replace, or no-op
+	Providers []string `mapstructure:"providers"` // Providers to show, such as aws
+	Types     []string `mapstructure:"types"`     // Resource type globs to show, such as aws_iam_*
+	Modules   []string `mapstructure:"modules"`   // Modules to show, including their child modules; root for resources outside modules
+	MinRisk   string   `mapstructure:"min_risk"`  // Lowest risk level to show: low, medium, high, or critical
+}
+
+// filterActions are the actions a resource filter accepts
+var filterActions = []string{"create", "update", "delete", "replace", "no-op"}
+
+// filterRiskLevels are the risk levels a resource filter accepts, from low to critical
+var filterRiskLevels = []string{"low", "medium", "high", "critical"}
+
+// IsActive reports whether the filter hides any resources
+func (filter ResourceFilterConfig) IsActive() bool {
+	return len(filter.Include) > 0 || len(filter.Exclude) > 0 || len(filter.Actions) > 0 ||
+		len(filter.Providers) > 0 || len(filter.Types) > 0 || len(filter.Modules) > 0 || filter.MinRisk != ""
+}
+
+// Validate checks the actions and risk level of the filter
+func (filter ResourceFilterConfig) Validate() error {
+	for _, action := range filter.Actions {
+		if !slices.Contains(filterActions, strings.ToLower(action)) {
+			return fmt.Errorf("invalid filter action %q, must be one of: %s", action, strings.Join(filterActions, ", "))
+		}
+	}
+	if filter.MinRisk != "" && !slices.Contains(filterRiskLevels, strings.ToLower(filter.MinRisk)) {
+		return fmt.Errorf("invalid filter risk level %q, must be one of: %s", filter.MinRisk, strings.Join(filterRiskLevels, ", "))
+	}
+	return nil
+}
+
+// String describes the settings of the filter that are set
+func (filter ResourceFilterConfig) String() string {
+	var parts []string
+	add := func(name string, values []string) {
+		if len(values) > 0 {
+			parts = append(parts, name+"="+strings.Join(values, ","))
+		}
+	}
+	add("include", filter.Include)
+	add("exclude", filter.Exclude)
+	add("action", filter.Actions)
+	add("provider", filter.Providers)
+	add("type", filter.Types)
+	add("module", filter.Modules)
+	if filter.MinRisk != "" {
+		parts = append(parts, "min-risk="+filter.MinRisk)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// SensitiveValuesConfig controls how changes to sensitive values are displayed
+type SensitiveValuesConfig struct {
+	ShowHash bool   `mapstructure:"show_hash"` // Show a salted short hash of sensitive values so plans can be compared
+	HashSalt string `mapstructure:"hash_salt"` // Salt for the hash; falls back to the STRATA_HASH_SALT environment variable
+}
+
+// minHashSaltLength is the shortest salt accepted for sensitive value hashes
+const minHashSaltLength = 16
+
+// GetHashSalt returns the salt for sensitive value hashes from config or the environment
+func (config *Config) GetHashSalt() string {
+	// Prefer the environment so the salt doesn't need to be committed with the config file
+	if salt := os.Getenv("STRATA_HASH_SALT"); salt != "" {
+		return salt
+	}
+	return config.Plan.SensitiveValues.HashSalt
+}
+
 // GroupingConfig controls enhanced grouping behavior
 type GroupingConfig struct {
 	Enabled   bool `mapstructure:"enabled"`   // Enable provider grouping
@@ -196,6 +349,7 @@ type PerformanceLimitsConfig struct {
 	MaxTotalMemory           int64 `mapstructure:"max_total_memory"`            // Default: 100MB (104857600 bytes)
 	MaxDependencyDepth       int   `mapstructure:"max_dependency_depth"`        // Default: 10
 	MaxResourcesPerGroup     int   `mapstructure:"max_resources_per_group"`     // Default: 1000
+	Workers                  int   `mapstructure:"workers"`                     // Default: number of CPUs
 }
 
 // GetPerformanceLimitsWithDefaults returns performance limits with default values applied
@@ -218,6 +372,9 @@ func (config *Config) GetPerformanceLimitsWithDefaults() PerformanceLimitsConfig
 	if limits.MaxResourcesPerGroup == 0 {
 		limits.MaxResourcesPerGroup = 1000
 	}
+	if limits.Workers == 0 {
+		limits.Workers = runtime.GOMAXPROCS(0)
+	}
 
 	return limits
 }
@@ -240,6 +397,13 @@ func (config *Config) MigrateDeprecatedConfig() []string {
 		config.Plan.ExpandableSections.MaxDetailLength = 10240 // 10KB default
 	}
 
+	if !viper.IsSet("plan.multiline_diff") {
+		config.Plan.MultilineDiff = MultilineDiffConfig{
+			Enabled:      true,
+			ContextLines: 3,
+		}
+	}
+
 	if !viper.IsSet("plan.grouping") {
 		// Use existing threshold value if it was migrated, otherwise default to 10
 		threshold := 10
@@ -266,6 +430,35 @@ func (config *Config) ValidateConfiguration() error {
 		return fmt.Errorf("plan.grouping.threshold must be at least 1, got %d", config.Plan.Grouping.Threshold)
 	}
 
+	// Validate multiline diff context
+	if config.Plan.MultilineDiff.ContextLines < 0 {
+		return fmt.Errorf("plan.multiline_diff.context_lines must not be negative, got %d", config.Plan.MultilineDiff.ContextLines)
+	}
+
+	// A perpetual diff needs at least one earlier plan to compare with
+	if config.Plan.PerpetualDiff.Threshold < 2 && config.Plan.PerpetualDiff.Threshold != 0 {
+		return fmt.Errorf("plan.perpetual_diff.threshold must be at least 2, got %d", config.Plan.PerpetualDiff.Threshold)
+	}
+
+	if err := config.Plan.Filter.Validate(); err != nil {
+		return fmt.Errorf("plan.filter: %w", err)
+	}
+
+	// Hashes of sensitive values without a secret salt can be reversed by brute force
+	if config.Plan.SensitiveValues.ShowHash && len(config.GetHashSalt()) < minHashSaltLength {
+		return fmt.Errorf("plan.sensitive_values.show_hash requires a hash_salt (or STRATA_HASH_SALT) of at least %d characters", minHashSaltLength)
+	}
+
+	// Validate custom secret patterns
+	for _, pattern := range config.SecretScanning.Patterns {
+		if pattern.Name == "" {
+			return fmt.Errorf("secret_scanning.patterns entries must have a name")
+		}
+		if _, err := regexp.Compile(pattern.Pattern); err != nil {
+			return fmt.Errorf("secret_scanning.patterns %q is not a valid regular expression: %w", pattern.Name, err)
+		}
+	}
+
 	// Validate performance limits
 	limits := config.Plan.PerformanceLimits
 	if limits.MaxPropertiesPerResource < 1 && limits.MaxPropertiesPerResource != 0 {
@@ -277,6 +470,9 @@ func (config *Config) ValidateConfiguration() error {
 	if limits.MaxTotalMemory < 1048576 && limits.MaxTotalMemory != 0 {
 		return fmt.Errorf("plan.performance_limits.max_total_memory must be at least 1MB, got %d", limits.MaxTotalMemory)
 	}
+	if limits.Workers < 0 {
+		return fmt.Errorf("plan.performance_limits.workers must not be negative, got %d", limits.Workers)
+	}
 
 	return nil
 }
@@ -313,6 +509,13 @@ func GetDefaultConfig() *Config {
 				Enabled:   true,
 				Threshold: 10,
 			},
+			MultilineDiff: MultilineDiffConfig{
+				Enabled:      true,
+				ContextLines: 3,
+			},
+			PerpetualDiff: PerpetualDiffConfig{
+				Threshold: 3,
+			},
 			PerformanceLimits: PerformanceLimitsConfig{
 				MaxPropertiesPerResource: 100,
 				MaxPropertySize:          1048576,   // 1MB
@@ -325,5 +528,14 @@ func GetDefaultConfig() *Config {
 			Style:          "default",
 			MaxColumnWidth: 50,
 		},
+		SecretScanning: SecretScanningConfig{
+			Enabled:          true,
+			EntropyThreshold: 4.5,
+			MinEntropyLength: 20,
+		},
+		History: HistoryConfig{
+			Enabled: false,
+			Path:    defaultHistoryPath,
+		},
 	}
 }
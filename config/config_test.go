@@ -1,9 +1,12 @@
 package config
 
 import (
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
+	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
@@ -422,6 +425,38 @@ func TestValidateConfiguration(t *testing.T) {
 			expectError: true,
 			errorMsg:    "plan.grouping.threshold must be at least 1",
 		},
+		{
+			name: "invalid perpetual diff threshold",
+			config: Config{
+				Plan: PlanConfig{
+					Grouping: GroupingConfig{
+						Enabled:   true,
+						Threshold: 10,
+					},
+					PerpetualDiff: PerpetualDiffConfig{
+						Threshold: 1,
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "plan.perpetual_diff.threshold must be at least 2",
+		},
+		{
+			name: "invalid filter risk level",
+			config: Config{
+				Plan: PlanConfig{
+					Grouping: GroupingConfig{
+						Enabled:   true,
+						Threshold: 10,
+					},
+					Filter: ResourceFilterConfig{
+						MinRisk: "severe",
+					},
+				},
+			},
+			expectError: true,
+			errorMsg:    "plan.filter: invalid filter risk level",
+		},
 		{
 			name: "invalid max properties per resource",
 			config: Config{
@@ -539,3 +574,52 @@ func TestGetDefaultConfig(t *testing.T) {
 		t.Errorf("Default config should be valid, got error: %v", err)
 	}
 }
+
+func TestGetHistoryPath(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Fatalf("failed to get home directory: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{name: "default path", path: "", expected: filepath.Join(home, ".strata", "history")},
+		{name: "home directory is expanded", path: "~/history", expected: filepath.Join(home, "history")},
+		{name: "absolute path", path: "/var/lib/strata", expected: "/var/lib/strata"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := GetDefaultConfig()
+			config.History.Path = tt.path
+			path, err := config.GetHistoryPath()
+			if err != nil {
+				t.Fatalf("GetHistoryPath() returned error: %v", err)
+			}
+			if path != tt.expected {
+				t.Errorf("GetHistoryPath() = %q, want %q", path, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetPerformanceLimitsWithDefaults_Workers(t *testing.T) {
+	config := &Config{}
+	if workers := config.GetPerformanceLimitsWithDefaults().Workers; workers != runtime.GOMAXPROCS(0) {
+		t.Errorf("Workers = %v, expected the number of CPUs (%v)", workers, runtime.GOMAXPROCS(0))
+	}
+
+	config.Plan.PerformanceLimits.Workers = 3
+	if workers := config.GetPerformanceLimitsWithDefaults().Workers; workers != 3 {
+		t.Errorf("Workers = %v, expected 3", workers)
+	}
+
+	config = GetDefaultConfig()
+	config.Plan.PerformanceLimits.Workers = -1
+	if err := config.ValidateConfiguration(); err == nil || !strings.Contains(err.Error(), "workers must not be negative") {
+		t.Errorf("Expected negative workers to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings that combine configuration files instead of configuring Strata
+const (
+	extendsKey    = "extends"    // Files whose settings this file builds on
+	mergeKey      = "merge"      // How the lists of this file are merged, by setting key
+	workspacesKey = "workspaces" // Settings that only apply to a Terraform workspace, by workspace name
+)
+
+// Ways lists are merged with the lists of the files they extend
+const (
+	MergeAppend  = "append"  // Items are added after the items of the extended file, skipping duplicates
+	MergeReplace = "replace" // The list replaces the list of the extended file
+)
+
+// appendedLists are the lists that are appended by default, so rules can be added to a shared baseline.
+// All other lists replace the lists of the files they extend.
+var appendedLists = []string{"sensitive_resources", "sensitive_properties", "secret_scanning.patterns"}
+
+// Layers holds the settings of a configuration file merged with the files it extends
+type Layers struct {
+	Files      []string                  // Files that were read, with extended files before the files extending them
+	Settings   map[string]any            // Merged settings of all files
+	Workspaces map[string]map[string]any // Merged workspace overlays, by workspace name
+	merge      map[string]string         // How lists are merged, by setting key
+}
+
+// LoadLayers reads a configuration file in YAML format together with the files it extends.
+//
+// Files listed in extends are read first, in order, and relative paths are resolved from the directory of
+// the file that extends them. The settings of a file are then merged on top of them: mappings are merged
+// key by key, other values replace the values they override, and lists are appended or replaced as set in
+// merge, which by default appends the sensitive resources, sensitive properties, and secret patterns.
+// Workspace overlays are merged in the same way, and are applied with ForWorkspace.
+func LoadLayers(path string) (*Layers, error) {
+	layers := &Layers{
+		Settings:   map[string]any{},
+		Workspaces: map[string]map[string]any{},
+		merge:      map[string]string{},
+	}
+	for _, key := range appendedLists {
+		layers.merge[key] = MergeAppend
+	}
+	if err := layers.load(path, nil); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// load reads a configuration file and the files it extends, and merges them into the layers.
+// chain holds the files that are being loaded, to detect files that extend themselves.
+func (layers *Layers) load(path string, chain []string) error {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config file %s: %w", path, err)
+	}
+	if slices.Contains(chain, absolute) {
+		return fmt.Errorf("config file %s extends itself", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	settings := map[string]any{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	extends, err := extendedFiles(settings[extendsKey])
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, base := range extends {
+		base, err := homedir.Expand(base)
+		if err != nil {
+			return fmt.Errorf("%s: failed to expand %s: %w", path, base, err)
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+		if err := layers.load(base, append(chain, absolute)); err != nil {
+			return err
+		}
+	}
+
+	if err := layers.addMergeStrategies(settings[mergeKey]); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if workspaces, ok := settings[workspacesKey].(map[string]any); ok {
+		for name, overlay := range workspaces {
+			overlay, _ := overlay.(map[string]any)
+			layers.Workspaces[name] = layers.mergeSettings(layers.Workspaces[name], overlay, "")
+		}
+	}
+	delete(settings, extendsKey)
+	delete(settings, mergeKey)
+	delete(settings, workspacesKey)
+
+	layers.Settings = layers.mergeSettings(layers.Settings, settings, "")
+	layers.Files = append(layers.Files, path)
+	return nil
+}
+
+// extendedFiles returns the files in an extends setting, which is a single file or a list of files
+func extendedFiles(value any) ([]string, error) {
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{value}, nil
+	case []any:
+		files := make([]string, 0, len(value))
+		for _, file := range value {
+			name, ok := file.(string)
+			if !ok {
+				return nil, fmt.Errorf("extends must only contain file paths, got %v", file)
+			}
+			files = append(files, name)
+		}
+		return files, nil
+	default:
+		return nil, fmt.Errorf("extends must be a file path or a list of file paths")
+	}
+}
+
+// addMergeStrategies sets how lists are merged from a merge setting
+func (layers *Layers) addMergeStrategies(value any) error {
+	if value == nil {
+		return nil
+	}
+	strategies, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("merge must be a mapping of setting keys to %s or %s", MergeAppend, MergeReplace)
+	}
+	for key, strategy := range strategies {
+		if strategy != MergeAppend && strategy != MergeReplace {
+			return fmt.Errorf("merge for %s must be %s or %s, got %v", key, MergeAppend, MergeReplace, strategy)
+		}
+		layers.merge[key] = strategy.(string)
+	}
+	return nil
+}
+
+// ForWorkspace returns the settings with the overlay of a workspace applied. The settings are returned
+// unchanged when there is no overlay for the workspace.
+func (layers *Layers) ForWorkspace(workspace string) map[string]any {
+	overlay, ok := layers.Workspaces[workspace]
+	if !ok {
+		return layers.Settings
+	}
+	return layers.mergeSettings(layers.Settings, overlay, "")
+}
+
+// InWorkspace reports whether the overlay of a workspace sets a dotted setting key
+func (layers *Layers) InWorkspace(workspace, key string) bool {
+	var value any = layers.Workspaces[workspace]
+	for part := range strings.SplitSeq(key, ".") {
+		mapping, ok := value.(map[string]any)
+		if !ok {
+			return false
+		}
+		if value, ok = mapping[part]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeSettings returns the settings of overlay merged on top of base, without changing either.
+// prefix is the dotted key of the settings, which decides how their lists are merged.
+func (layers *Layers) mergeSettings(base, overlay map[string]any, prefix string) map[string]any {
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = map[string]any{}
+	}
+	for name, value := range overlay {
+		key := joinKey(prefix, name)
+		switch value := value.(type) {
+		case nil:
+			// An empty value leaves the setting as it is
+			continue
+		case map[string]any:
+			existing, _ := merged[name].(map[string]any)
+			merged[name] = layers.mergeSettings(existing, value, key)
+		case []any:
+			existing, _ := merged[name].([]any)
+			if layers.merge[key] == MergeAppend {
+				merged[name] = appendUnique(existing, value)
+			} else {
+				merged[name] = value
+			}
+		default:
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// appendUnique returns the items of base followed by the items of extra that aren't in base yet
+func appendUnique(base, extra []any) []any {
+	merged := slices.Clone(base)
+	for _, item := range extra {
+		if !slices.ContainsFunc(merged, func(existing any) bool { return reflect.DeepEqual(existing, item) }) {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
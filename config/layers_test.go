@@ -0,0 +1,170 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles writes configuration files to a temporary directory and returns the directory
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadLayers_MergesExtendedFiles(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"org/base.yaml": "sensitive_resources:\n  - resource_type: aws_db_instance\n" +
+			"plan:\n  filter:\n    actions: [create, delete]\n  grouping:\n    enabled: true\n    threshold: 5\n",
+		"strata.yaml": "extends: org/base.yaml\n" +
+			"sensitive_resources:\n  - resource_type: aws_db_instance\n  - resource_type: aws_rds_cluster\n" +
+			"plan:\n  filter:\n    actions: [delete]\n  grouping:\n    threshold: 3\n",
+	})
+
+	layers, err := LoadLayers(filepath.Join(dir, "strata.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to load layers: %v", err)
+	}
+
+	expectedResources := []any{
+		map[string]any{"resource_type": "aws_db_instance"},
+		map[string]any{"resource_type": "aws_rds_cluster"},
+	}
+	if got := layers.Settings["sensitive_resources"]; !reflect.DeepEqual(got, expectedResources) {
+		t.Errorf("Expected sensitive resources to be appended without duplicates, got %v", got)
+	}
+	plan := layers.Settings["plan"].(map[string]any)
+	if got := plan["filter"].(map[string]any)["actions"]; !reflect.DeepEqual(got, []any{"delete"}) {
+		t.Errorf("Expected other lists to be replaced, got %v", got)
+	}
+	grouping := plan["grouping"].(map[string]any)
+	if grouping["threshold"] != 3 || grouping["enabled"] != true {
+		t.Errorf("Expected mappings to be merged key by key, got %v", grouping)
+	}
+	if len(layers.Files) != 2 || !strings.HasSuffix(layers.Files[0], "base.yaml") {
+		t.Errorf("Expected the extended file to be read first, got %v", layers.Files)
+	}
+	if _, ok := layers.Settings["extends"]; ok {
+		t.Error("Expected extends to be removed from the settings")
+	}
+}
+
+func TestLoadLayers_MergeStrategies(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml": "sensitive_properties:\n  - resource_type: aws_instance\n    property: user_data\n" +
+			"plan:\n  filter:\n    types: [aws_instance]\n",
+		"strata.yaml": "extends: [base.yaml]\n" +
+			"merge:\n  sensitive_properties: replace\n  plan.filter.types: append\n" +
+			"sensitive_properties:\n  - resource_type: aws_lambda_function\n    property: environment\n" +
+			"plan:\n  filter:\n    types: [aws_s3_bucket]\n",
+	})
+
+	layers, err := LoadLayers(filepath.Join(dir, "strata.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to load layers: %v", err)
+	}
+
+	expectedProperties := []any{map[string]any{"resource_type": "aws_lambda_function", "property": "environment"}}
+	if got := layers.Settings["sensitive_properties"]; !reflect.DeepEqual(got, expectedProperties) {
+		t.Errorf("Expected sensitive properties to be replaced, got %v", got)
+	}
+	types := layers.Settings["plan"].(map[string]any)["filter"].(map[string]any)["types"]
+	if !reflect.DeepEqual(types, []any{"aws_instance", "aws_s3_bucket"}) {
+		t.Errorf("Expected filter types to be appended, got %v", types)
+	}
+}
+
+func TestLoadLayers_Errors(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yaml":       "extends: b.yaml\n",
+		"b.yaml":       "extends: a.yaml\n",
+		"missing.yaml": "extends: nowhere.yaml\n",
+		"merge.yaml":   "merge:\n  sensitive_resources: keep\n",
+		"list.yaml":    "extends:\n  - [base.yaml]\n",
+	})
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"a.yaml", "extends itself"},
+		{"missing.yaml", "nowhere.yaml"},
+		{"merge.yaml", "merge for sensitive_resources must be append or replace"},
+		{"list.yaml", "extends must only contain file paths"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			_, err := LoadLayers(filepath.Join(dir, tt.file))
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("Expected an error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestLayers_ForWorkspace(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		"base.yaml": "workspaces:\n  prod:\n    plan:\n      grouping:\n        threshold: 2\n",
+		"strata.yaml": "extends: base.yaml\n" +
+			"sensitive_resources:\n  - resource_type: aws_db_instance\n" +
+			"plan:\n  grouping:\n    threshold: 10\n" +
+			"workspaces:\n  prod:\n    sensitive_resources:\n      - resource_type: aws_s3_bucket\n",
+	})
+
+	layers, err := LoadLayers(filepath.Join(dir, "strata.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to load layers: %v", err)
+	}
+
+	prod := layers.ForWorkspace("prod")
+	if threshold := prod["plan"].(map[string]any)["grouping"].(map[string]any)["threshold"]; threshold != 2 {
+		t.Errorf("Expected the overlay of the extended file to apply, got threshold %v", threshold)
+	}
+	expectedResources := []any{
+		map[string]any{"resource_type": "aws_db_instance"},
+		map[string]any{"resource_type": "aws_s3_bucket"},
+	}
+	if got := prod["sensitive_resources"]; !reflect.DeepEqual(got, expectedResources) {
+		t.Errorf("Expected the overlay to append sensitive resources, got %v", got)
+	}
+	if !layers.InWorkspace("prod", "plan.grouping.threshold") || layers.InWorkspace("prod", "plan.grouping.enabled") {
+		t.Error("Expected InWorkspace to report only the settings of the overlay")
+	}
+
+	if dev := layers.ForWorkspace("dev"); !reflect.DeepEqual(dev, layers.Settings) {
+		t.Errorf("Expected workspaces without an overlay to use the settings, got %v", dev)
+	}
+	if threshold := layers.Settings["plan"].(map[string]any)["grouping"].(map[string]any)["threshold"]; threshold != 10 {
+		t.Errorf("Expected applying an overlay to leave the settings unchanged, got threshold %v", threshold)
+	}
+}
+
+func TestValidateYAML_Layers(t *testing.T) {
+	data := []byte("extends: org/base.yaml\n" +
+		"merge:\n  sensitive_resources: replace\n  plan.grouping: append\n" +
+		"workspaces:\n  prod:\n    plan:\n      grouping:\n        treshold: 2\n")
+
+	var got []string
+	for _, issue := range ValidateYAML(data) {
+		got = append(got, issue.String())
+	}
+	expected := []string{
+		`9:9: unknown key "workspaces.prod.plan.grouping.treshold", did you mean "threshold"?`,
+		`4:3: merge key "plan.grouping" is not a list setting`,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected issues %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,416 @@
+package config
+
+import (
+	_ "embed"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// initTemplate is the commented configuration file written by strata config init
+//
+//go:embed template.yaml
+var initTemplate string
+
+// InitTemplate returns a commented configuration file with every setting and its default value
+func InitTemplate() string {
+	return initTemplate
+}
+
+// outputFormats are the formats accepted by the output settings
+var outputFormats = []string{"table", "json", "csv", "markdown", "html", "template", "report"}
+
+// commandSettings are the settings in the configuration file that aren't part of Config, because they
+// are read directly by the commands. Their values can also be set with flags and environment variables.
+var commandSettings = []struct {
+	Key    string
+	Values []string
+}{
+	{Key: "output", Values: outputFormats},
+	{Key: "output-file"},
+	{Key: "output-file-format", Values: outputFormats},
+	{Key: "template"},
+	{Key: "region"},
+	{Key: "account-id"},
+}
+
+// settingValues limits the values of the settings that only accept a fixed set of values
+var settingValues = map[string][]string{
+	"plan.statistics-summary-format": {"horizontal", "vertical"},
+	"plan.filter.actions":            filterActions,
+	"plan.filter.min_risk":           filterRiskLevels,
+}
+
+// settingKind is the type of value a setting accepts, as used in messages
+type settingKind string
+
+const (
+	kindBool   settingKind = "a boolean"
+	kindInt    settingKind = "an integer"
+	kindFloat  settingKind = "a number"
+	kindString settingKind = "a string"
+	kindList   settingKind = "a list"
+	kindObject settingKind = "a mapping"
+)
+
+// settingSchema describes the values a setting accepts
+type settingSchema struct {
+	kind   settingKind
+	fields map[string]*settingSchema // Settings within a mapping, by key
+	order  []string                  // Keys of the fields in the order they are defined
+	items  *settingSchema            // Values in a list
+	values []string                  // Allowed values of a string, when limited
+	anyKey *settingSchema            // Settings within a mapping whose keys are chosen by the user, such as workspace names
+	single bool                      // Whether a list also accepts a single item instead of a list
+}
+
+// configSchema returns the schema of the configuration file, generated from Config and the command settings,
+// together with the settings that combine configuration files
+func configSchema() *settingSchema {
+	schema := settingsSchema()
+	layers := map[string]*settingSchema{
+		extendsKey:    {kind: kindList, items: &settingSchema{kind: kindString}, single: true},
+		mergeKey:      {kind: kindObject, fields: map[string]*settingSchema{}, anyKey: &settingSchema{kind: kindString, values: []string{MergeAppend, MergeReplace}}},
+		workspacesKey: {kind: kindObject, fields: map[string]*settingSchema{}, anyKey: settingsSchema()},
+	}
+	for _, key := range []string{extendsKey, mergeKey, workspacesKey} {
+		schema.fields[key] = layers[key]
+		schema.order = append(schema.order, key)
+	}
+	return schema
+}
+
+// settingsSchema returns the schema of the settings that configure Strata, which can be set at the top of
+// the configuration file and in workspace overlays
+func settingsSchema() *settingSchema {
+	schema := schemaForType(reflect.TypeFor[Config](), "")
+	for _, setting := range commandSettings {
+		schema.fields[setting.Key] = &settingSchema{kind: kindString, values: setting.Values}
+		schema.order = append(schema.order, setting.Key)
+	}
+	return schema
+}
+
+// listKeys returns the dotted keys of the lists in a schema
+func listKeys(schema *settingSchema, key string) []string {
+	if schema.kind == kindList {
+		return []string{key}
+	}
+	var keys []string
+	for _, name := range schema.order {
+		keys = append(keys, listKeys(schema.fields[name], joinKey(key, name))...)
+	}
+	return keys
+}
+
+// schemaForType returns the schema of a configuration type, using the mapstructure names of struct fields
+func schemaForType(t reflect.Type, key string) *settingSchema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &settingSchema{kind: kindBool}
+	case reflect.Int, reflect.Int64:
+		return &settingSchema{kind: kindInt}
+	case reflect.Float64:
+		return &settingSchema{kind: kindFloat}
+	case reflect.Slice:
+		return &settingSchema{kind: kindList, items: schemaForType(t.Elem(), key)}
+	case reflect.Struct:
+		schema := &settingSchema{kind: kindObject, fields: map[string]*settingSchema{}}
+		for i := range t.NumField() {
+			name := t.Field(i).Tag.Get("mapstructure")
+			if name == "" {
+				continue
+			}
+			schema.fields[name] = schemaForType(t.Field(i).Type, joinKey(key, name))
+			schema.order = append(schema.order, name)
+		}
+		return schema
+	default:
+		return &settingSchema{kind: kindString, values: settingValues[key]}
+	}
+}
+
+// joinKey returns the dotted key of a setting within a parent setting
+func joinKey(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// Issue is a problem found in a configuration file
+type Issue struct {
+	Line    int    // Line of the problem, or zero when it isn't tied to a line
+	Column  int    // Column of the problem, or zero when it isn't tied to a line
+	Message string // Description of the problem
+}
+
+// String returns the issue with its position
+func (issue Issue) String() string {
+	if issue.Line == 0 {
+		return issue.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", issue.Line, issue.Column, issue.Message)
+}
+
+// yamlLinePattern finds the line number in YAML syntax errors
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// ValidateYAML checks configuration in YAML format strictly against the configuration schema. Unknown keys,
+// values of the wrong type, and values that aren't allowed are reported with their line and column.
+// The settings are not checked against each other; that is done by ValidateConfiguration.
+func ValidateYAML(data []byte) []Issue {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		issue := Issue{Message: strings.TrimPrefix(err.Error(), "yaml: ")}
+		if match := yamlLinePattern.FindStringSubmatch(issue.Message); match != nil {
+			issue.Line, _ = strconv.Atoi(match[1])
+			issue.Column = 1
+			issue.Message = strings.TrimPrefix(issue.Message, match[0]+": ")
+		}
+		return []Issue{issue}
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+	var issues []Issue
+	validateNode(configSchema(), document.Content[0], "", &issues)
+	validateMergeKeys(document.Content[0], &issues)
+	return issues
+}
+
+// validateMergeKeys checks that the keys in the merge setting are lists that can be merged
+func validateMergeKeys(root *yaml.Node, issues *[]Issue) {
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	lists := listKeys(settingsSchema(), "")
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		merge := root.Content[i+1]
+		if root.Content[i].Value != mergeKey || merge.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j < len(merge.Content); j += 2 {
+			keyNode := merge.Content[j]
+			if slices.Contains(lists, keyNode.Value) {
+				continue
+			}
+			issue := Issue{Line: keyNode.Line, Column: keyNode.Column, Message: fmt.Sprintf("merge key %q is not a list setting", keyNode.Value)}
+			if suggestion := closestKey(keyNode.Value, lists); suggestion != "" {
+				issue.Message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			*issues = append(*issues, issue)
+		}
+	}
+}
+
+// validateNode checks a YAML node against the schema of the setting at key
+func validateNode(schema *settingSchema, node *yaml.Node, key string, issues *[]Issue) {
+	report := func(format string, args ...any) {
+		*issues = append(*issues, Issue{Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...)})
+	}
+	name := key
+	if name == "" {
+		name = "the configuration"
+	}
+
+	// An empty value leaves the default in place
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch schema.kind {
+	case kindObject:
+		if node.Kind != yaml.MappingNode {
+			report("%s must be %s", name, kindObject)
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			field, ok := schema.fields[keyNode.Value]
+			if !ok && schema.anyKey != nil {
+				field, ok = schema.anyKey, true
+			}
+			if !ok {
+				issue := Issue{Line: keyNode.Line, Column: keyNode.Column, Message: fmt.Sprintf("unknown key %q", joinKey(key, keyNode.Value))}
+				if suggestion := closestKey(keyNode.Value, schema.order); suggestion != "" {
+					issue.Message += fmt.Sprintf(", did you mean %q?", suggestion)
+				}
+				*issues = append(*issues, issue)
+				continue
+			}
+			validateNode(field, valueNode, joinKey(key, keyNode.Value), issues)
+		}
+	case kindList:
+		if schema.single && node.Kind == yaml.ScalarNode {
+			validateNode(schema.items, node, key, issues)
+			return
+		}
+		if node.Kind != yaml.SequenceNode {
+			report("%s must be %s", name, kindList)
+			return
+		}
+		for _, item := range node.Content {
+			validateNode(schema.items, item, key, issues)
+		}
+	default:
+		if node.Kind != yaml.ScalarNode {
+			report("%s must be %s", name, schema.kind)
+			return
+		}
+		if !scalarMatches(schema.kind, node.Tag) {
+			report("%s must be %s, got %q", name, schema.kind, node.Value)
+			return
+		}
+		if len(schema.values) > 0 && !slices.Contains(schema.values, strings.ToLower(node.Value)) {
+			report("%s has invalid value %q, must be one of: %s", name, node.Value, strings.Join(schema.values, ", "))
+		}
+	}
+}
+
+// scalarMatches reports whether a YAML scalar with the given tag is accepted for a kind of setting.
+// Strings accept any scalar, as numbers and booleans are converted to strings when they are loaded.
+func scalarMatches(kind settingKind, tag string) bool {
+	switch kind {
+	case kindBool:
+		return tag == "!!bool"
+	case kindInt:
+		return tag == "!!int"
+	case kindFloat:
+		return tag == "!!int" || tag == "!!float"
+	default:
+		return true
+	}
+}
+
+// closestKey returns the known key that an unknown key was most likely meant to be, if any is close enough
+func closestKey(unknown string, known []string) string {
+	normalise := func(key string) string { return strings.ReplaceAll(strings.ToLower(key), "-", "_") }
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		distance := editDistance(normalise(unknown), normalise(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// KeyPosition returns the line and column of a dotted key in configuration in YAML format, or zeros when
+// the key isn't set
+func KeyPosition(data []byte, key string) (line, column int) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		return 0, 0
+	}
+	node := document.Content[0]
+	for part := range strings.SplitSeq(key, ".") {
+		if node.Kind != yaml.MappingNode {
+			return 0, 0
+		}
+		found := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				line, column = node.Content[i].Line, node.Content[i].Column
+				node = node.Content[i+1]
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, 0
+		}
+	}
+	return line, column
+}
+
+// Setting is the effective value of a single configuration setting
+type Setting struct {
+	Key   string // Dotted key, such as plan.grouping.threshold
+	Value any    // Value of the setting; lists of mappings are kept together as a single setting
+}
+
+// Settings returns the settings of the configuration in the order they are defined, with their values.
+// Nested mappings are flattened into dotted keys, and lists are returned as a single setting.
+func (config *Config) Settings() []Setting {
+	var settings []Setting
+	collectSettings(reflect.ValueOf(config).Elem(), "", &settings)
+	return settings
+}
+
+// collectSettings adds the settings of a configuration struct to settings
+func collectSettings(value reflect.Value, key string, settings *[]Setting) {
+	for i := range value.NumField() {
+		name := value.Type().Field(i).Tag.Get("mapstructure")
+		if name == "" {
+			continue
+		}
+		field := value.Field(i)
+		if field.Kind() == reflect.Struct {
+			collectSettings(field, joinKey(key, name), settings)
+			continue
+		}
+		*settings = append(*settings, Setting{Key: joinKey(key, name), Value: settingValue(field)})
+	}
+}
+
+// settingValue converts a configuration value to plain values keyed by their mapstructure names
+func settingValue(value reflect.Value) any {
+	switch value.Kind() {
+	case reflect.Slice:
+		items := make([]any, value.Len())
+		for i := range items {
+			items[i] = settingValue(value.Index(i))
+		}
+		return items
+	case reflect.Struct:
+		fields := map[string]any{}
+		for i := range value.NumField() {
+			if name := value.Type().Field(i).Tag.Get("mapstructure"); name != "" {
+				fields[name] = settingValue(value.Field(i))
+			}
+		}
+		return fields
+	default:
+		return value.Interface()
+	}
+}
+
+// CommandSettingKeys returns the keys of the settings in the configuration file that are read directly by
+// the commands instead of being part of Config, such as the output format
+func CommandSettingKeys() []string {
+	keys := make([]string, 0, len(commandSettings))
+	for _, setting := range commandSettings {
+		keys = append(keys, setting.Key)
+	}
+	return keys
+}
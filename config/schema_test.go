@@ -0,0 +1,134 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestInitTemplate_IsValidAndMatchesDefaults(t *testing.T) {
+	if issues := ValidateYAML([]byte(InitTemplate())); len(issues) > 0 {
+		t.Fatalf("Expected the template to be valid, got %v", issues)
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(strings.NewReader(InitTemplate())); err != nil {
+		t.Fatalf("Failed to read template: %v", err)
+	}
+	var loaded Config
+	if err := v.Unmarshal(&loaded); err != nil {
+		t.Fatalf("Failed to unmarshal template: %v", err)
+	}
+	if defaults := GetDefaultConfig(); !reflect.DeepEqual(&loaded, defaults) {
+		t.Errorf("Expected the template to contain the default values\ngot:  %+v\nwant: %+v", loaded, *defaults)
+	}
+}
+
+func TestValidateYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []string
+	}{
+		{
+			name:     "valid",
+			yaml:     "output: markdown\nplan:\n  show-no-ops: true\n  grouping:\n    threshold: 5\nsensitive_resources:\n  - resource_type: aws_instance\n",
+			expected: nil,
+		},
+		{
+			name:     "empty values keep the defaults",
+			yaml:     "plan:\n  filter:\n",
+			expected: nil,
+		},
+		{
+			name:     "unknown key with suggestion",
+			yaml:     "plan:\n  grouping:\n    treshold: 5\n",
+			expected: []string{`3:5: unknown key "plan.grouping.treshold", did you mean "threshold"?`},
+		},
+		{
+			name:     "unknown key with underscores instead of dashes",
+			yaml:     "plan:\n  show_no_ops: true\n",
+			expected: []string{`2:3: unknown key "plan.show_no_ops", did you mean "show-no-ops"?`},
+		},
+		{
+			name:     "unknown key without suggestion",
+			yaml:     "servers: []\n",
+			expected: []string{`1:1: unknown key "servers"`},
+		},
+		{
+			name:     "wrong scalar type",
+			yaml:     "plan:\n  show-details: sometimes\n  grouping:\n    threshold: ten\n",
+			expected: []string{`2:17: plan.show-details must be a boolean, got "sometimes"`, `4:16: plan.grouping.threshold must be an integer, got "ten"`},
+		},
+		{
+			name:     "numbers are accepted for floats",
+			yaml:     "secret_scanning:\n  entropy_threshold: 4\n",
+			expected: nil,
+		},
+		{
+			name:     "mapping instead of list",
+			yaml:     "sensitive_properties:\n  resource_type: aws_instance\n",
+			expected: []string{`2:3: sensitive_properties must be a list`},
+		},
+		{
+			name:     "invalid value",
+			yaml:     "output: yaml\nplan:\n  filter:\n    actions: [create, destroy]\n",
+			expected: []string{`1:9: output has invalid value "yaml", must be one of: table, json, csv, markdown, html, template, report`, `4:23: plan.filter.actions has invalid value "destroy", must be one of: create, update, delete, replace, no-op`},
+		},
+		{
+			name:     "syntax error",
+			yaml:     "plan:\n  grouping: [\n",
+			expected: []string{`2:1: did not find expected node content`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range ValidateYAML([]byte(tt.yaml)) {
+				got = append(got, issue.String())
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected issues %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestKeyPosition(t *testing.T) {
+	data := []byte("output: table\nplan:\n  grouping:\n    threshold: 0\n")
+
+	if line, column := KeyPosition(data, "plan.grouping.threshold"); line != 4 || column != 5 {
+		t.Errorf("Expected 4:5, got %d:%d", line, column)
+	}
+	if line, column := KeyPosition(data, "plan.filter"); line != 0 || column != 0 {
+		t.Errorf("Expected no position for a missing key, got %d:%d", line, column)
+	}
+}
+
+func TestConfig_Settings(t *testing.T) {
+	cfg := GetDefaultConfig()
+	cfg.SensitiveResources = []SensitiveResource{{ResourceType: "aws_instance"}}
+
+	settings := map[string]any{}
+	for _, setting := range cfg.Settings() {
+		settings[setting.Key] = setting.Value
+	}
+
+	if settings["plan.grouping.threshold"] != 10 {
+		t.Errorf("Expected plan.grouping.threshold to be 10, got %v", settings["plan.grouping.threshold"])
+	}
+	if settings["table.max-column-width"] != 50 {
+		t.Errorf("Expected table.max-column-width to be 50, got %v", settings["table.max-column-width"])
+	}
+	expected := []any{map[string]any{"resource_type": "aws_instance"}}
+	if !reflect.DeepEqual(settings["sensitive_resources"], expected) {
+		t.Errorf("Expected sensitive_resources to be %v, got %v", expected, settings["sensitive_resources"])
+	}
+	if _, ok := settings["plan.filter"]; ok {
+		t.Error("Expected mappings to be flattened into their settings")
+	}
+}
@@ -116,24 +116,16 @@ func (fv *FileValidator) validateDirectoryPermissions(filePath string) error {
 }
 
 // validateFormatSupport checks if the specified output format is supported.
-// Supported formats include: table, json, csv, markdown, html
+// Supported formats include: table, json, csv, markdown, html, template, report
 func (fv *FileValidator) validateFormatSupport(formatName string) error {
-	supportedFormats := []string{
-		"table",
-		"json",
-		"csv",
-		"markdown",
-		"html",
-	}
-
 	formatLower := strings.ToLower(formatName)
-	if !slices.Contains(supportedFormats, formatLower) {
+	if !slices.Contains(outputFormats, formatLower) {
 		return &FileOutputError{
 			Type:    "format",
 			Code:    "UNSUPPORTED_FORMAT",
 			Path:    "",
 			Format:  formatName,
-			Message: fmt.Sprintf("unsupported output format: %s, supported formats: %v", formatName, supportedFormats),
+			Message: fmt.Sprintf("unsupported output format: %s, supported formats: %v", formatName, outputFormats),
 		}
 	}
 
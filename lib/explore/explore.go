@@ -0,0 +1,322 @@
+// Package explore implements the interactive terminal UI for browsing plan summaries.
+package explore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+)
+
+// rootModule is shown as the module of resources that aren't in a module
+const rootModule = "(root)"
+
+// actionFilters are the actions the action filter cycles through, after showing all actions
+var actionFilters = []plan.ChangeType{
+	plan.ChangeTypeCreate,
+	plan.ChangeTypeUpdate,
+	plan.ChangeTypeDelete,
+	plan.ChangeTypeReplace,
+}
+
+// riskFilters are the minimum risk levels the risk filter cycles through, after showing all levels
+var riskFilters = []string{"medium", "high", "critical"}
+
+// Options configures the explorer
+type Options struct {
+	ExportPath string // File the filtered view is exported to
+	ShowNoOps  bool   // Whether resources without changes are listed
+}
+
+// explorer holds the state of the terminal UI. Key handling and rendering don't touch the
+// terminal, so the state can be tested without one.
+type explorer struct {
+	summary   *plan.PlanSummary
+	resources []plan.ResourceChange
+	providers []string
+	modules   []string
+	options   Options
+
+	// Filters, where empty values show everything
+	action   plan.ChangeType
+	provider string
+	module   string
+	minRisk  string
+	query    string
+
+	visible      []int // Indexes of the resources that match the filters
+	cursor       int   // Index in visible of the selected resource
+	offset       int   // Index in visible of the first listed resource
+	detailOffset int   // First line shown in the detail pane
+	searching    bool
+	showHelp     bool
+	status       string
+
+	width, height int
+	clipboard     io.Writer // Receives the OSC 52 sequence that copies to the terminal clipboard
+}
+
+func newExplorer(summary *plan.PlanSummary, options Options) *explorer {
+	e := &explorer{summary: summary, options: options, width: 80, height: 24}
+	providers := map[string]bool{}
+	modules := map[string]bool{}
+	for _, change := range summary.ResourceChanges {
+		if change.ChangeType == plan.ChangeTypeNoOp && !options.ShowNoOps {
+			continue
+		}
+		e.resources = append(e.resources, change)
+		providers[change.Provider] = true
+		modules[moduleName(change)] = true
+	}
+	for provider := range providers {
+		if provider != "" {
+			e.providers = append(e.providers, provider)
+		}
+	}
+	for module := range modules {
+		e.modules = append(e.modules, module)
+	}
+	slices.Sort(e.providers)
+	slices.Sort(e.modules)
+	e.applyFilters()
+	return e
+}
+
+// moduleName returns the module of a resource, or rootModule for resources in the root module
+func moduleName(change plan.ResourceChange) string {
+	// The analyzer uses "-" for resources outside of modules
+	if change.ModulePath == "" || change.ModulePath == "-" {
+		return rootModule
+	}
+	return change.ModulePath
+}
+
+// matches reports whether a resource matches the current filters and search query
+func (e *explorer) matches(change plan.ResourceChange) bool {
+	switch {
+	case e.action != "" && change.ChangeType != e.action:
+		return false
+	case e.provider != "" && change.Provider != e.provider:
+		return false
+	case e.module != "" && moduleName(change) != e.module:
+		return false
+	case e.minRisk != "" && plan.RiskLevelRank(change.RiskLevel()) < plan.RiskLevelRank(e.minRisk):
+		return false
+	}
+	if e.query == "" {
+		return true
+	}
+	query := strings.ToLower(e.query)
+	for _, field := range []string{change.Address, change.Type, change.DangerReason} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyFilters recalculates the visible resources, keeping the selected resource selected when it still matches
+func (e *explorer) applyFilters() {
+	selected := e.selectedIndex()
+	e.visible = e.visible[:0]
+	e.cursor = 0
+	for i, change := range e.resources {
+		if e.matches(change) {
+			if i == selected {
+				e.cursor = len(e.visible)
+			}
+			e.visible = append(e.visible, i)
+		}
+	}
+	e.detailOffset = 0
+	e.scrollToCursor()
+}
+
+// selectedIndex returns the index in resources of the selected resource, or -1 when nothing is selected
+func (e *explorer) selectedIndex() int {
+	if e.cursor < 0 || e.cursor >= len(e.visible) {
+		return -1
+	}
+	return e.visible[e.cursor]
+}
+
+// selected returns the selected resource, or nil when no resources match the filters
+func (e *explorer) selected() *plan.ResourceChange {
+	index := e.selectedIndex()
+	if index < 0 {
+		return nil
+	}
+	return &e.resources[index]
+}
+
+// listHeight returns the number of resources that fit in the list pane
+func (e *explorer) listHeight() int {
+	// The header, filter line, and footer take three lines
+	return max(e.height-3, 1)
+}
+
+// move moves the cursor by delta resources, staying within the list
+func (e *explorer) move(delta int) {
+	e.cursor = max(min(e.cursor+delta, len(e.visible)-1), 0)
+	e.detailOffset = 0
+	e.scrollToCursor()
+}
+
+// scrollToCursor scrolls the list so the selected resource is visible
+func (e *explorer) scrollToCursor() {
+	height := e.listHeight()
+	if e.cursor < e.offset {
+		e.offset = e.cursor
+	}
+	if e.cursor >= e.offset+height {
+		e.offset = e.cursor - height + 1
+	}
+	e.offset = max(min(e.offset, len(e.visible)-height), 0)
+}
+
+// resize updates the terminal size used for rendering
+func (e *explorer) resize(width, height int) {
+	e.width, e.height = width, height
+	e.scrollToCursor()
+}
+
+// handleKey updates the state for a key press. It returns false when the explorer should quit.
+func (e *explorer) handleKey(k key) bool {
+	e.status = ""
+	if e.searching {
+		e.handleSearchKey(k)
+		return true
+	}
+	if e.showHelp {
+		// Any key closes the help, without quitting unless it's the quit key
+		e.showHelp = false
+		return k.code != keyCtrlC && !(k.code == keyRune && k.r == 'q')
+	}
+
+	switch k.code {
+	case keyCtrlC:
+		return false
+	case keyUp:
+		e.move(-1)
+	case keyDown:
+		e.move(1)
+	case keyPageUp:
+		e.move(-e.listHeight())
+	case keyPageDown:
+		e.move(e.listHeight())
+	case keyHome:
+		e.move(-len(e.visible))
+	case keyEnd:
+		e.move(len(e.visible))
+	case keyRune:
+		return e.handleRune(k.r)
+	}
+	return true
+}
+
+// handleRune handles the single character commands
+func (e *explorer) handleRune(r rune) bool {
+	switch r {
+	case 'q':
+		return false
+	case 'k':
+		e.move(-1)
+	case 'j':
+		e.move(1)
+	case 'g':
+		e.move(-len(e.visible))
+	case 'G':
+		e.move(len(e.visible))
+	case 'K':
+		e.detailOffset = max(e.detailOffset-1, 0)
+	case 'J':
+		e.detailOffset++
+	case '/':
+		e.searching = true
+	case 'a':
+		e.action = cycle(actionFilters, e.action)
+		e.applyFilters()
+	case 'p':
+		e.provider = cycle(e.providers, e.provider)
+		e.applyFilters()
+	case 'm':
+		e.module = cycle(e.modules, e.module)
+		e.applyFilters()
+	case 'r':
+		e.minRisk = cycle(riskFilters, e.minRisk)
+		e.applyFilters()
+	case 'c':
+		e.action, e.provider, e.module, e.minRisk, e.query = "", "", "", "", ""
+		e.applyFilters()
+	case 'y':
+		e.copyAddress()
+	case 'e':
+		e.export()
+	case '?':
+		e.showHelp = true
+	}
+	return true
+}
+
+// handleSearchKey edits the search query, which filters the list while typing
+func (e *explorer) handleSearchKey(k key) {
+	switch k.code {
+	case keyEnter:
+		e.searching = false
+	case keyEsc, keyCtrlC:
+		e.searching = false
+		e.query = ""
+	case keyBackspace:
+		if runes := []rune(e.query); len(runes) > 0 {
+			e.query = string(runes[:len(runes)-1])
+		}
+	case keyRune:
+		e.query += string(k.r)
+	default:
+		return
+	}
+	e.applyFilters()
+}
+
+// cycle returns the option after current, going back to the empty value (no filter) after the last option
+func cycle[T comparable](options []T, current T) T {
+	var none T
+	if current == none {
+		if len(options) == 0 {
+			return none
+		}
+		return options[0]
+	}
+	index := slices.Index(options, current)
+	if index < 0 || index == len(options)-1 {
+		return none
+	}
+	return options[index+1]
+}
+
+// copyAddress copies the address of the selected resource to the clipboard with an OSC 52 escape
+// sequence, which is supported by most terminal emulators, including over SSH
+func (e *explorer) copyAddress() {
+	change := e.selected()
+	if change == nil || e.clipboard == nil {
+		return
+	}
+	fmt.Fprintf(e.clipboard, "\033]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(change.Address)))
+	e.status = "Copied " + change.Address
+}
+
+// export writes the resources in the current filtered view as a summary JSON file
+func (e *explorer) export() {
+	changes := make([]plan.ResourceChange, 0, len(e.visible))
+	for _, index := range e.visible {
+		changes = append(changes, e.resources[index])
+	}
+	if err := plan.SaveSummary(e.summary.WithResourceChanges(changes), e.options.ExportPath); err != nil {
+		e.status = err.Error()
+		return
+	}
+	e.status = fmt.Sprintf("Exported %d resources to %s", len(changes), e.options.ExportPath)
+}
@@ -0,0 +1,208 @@
+package explore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSummary() *plan.PlanSummary {
+	return &plan.PlanSummary{
+		PlanFile: "test.tfplan",
+		ResourceChanges: []plan.ResourceChange{
+			{Address: "aws_instance.web", Type: "aws_instance", Provider: "aws", ChangeType: plan.ChangeTypeUpdate, ModulePath: "-",
+				PropertyChanges: plan.PropertyChangeAnalysis{Changes: []plan.PropertyChange{
+					{Name: "instance_type", Action: "update", Before: "t3.micro", After: "t3.large"},
+					{Name: "password", Action: "update", Sensitive: true, SensitiveStatus: "unchanged"},
+				}}},
+			{Address: "module.db.aws_db_instance.main", Type: "aws_db_instance", Provider: "aws", ChangeType: plan.ChangeTypeReplace,
+				ModulePath: "db", IsDangerous: true, DangerReason: "Sensitive resource replacement", ReplacementHints: []string{"engine"}},
+			{Address: "azurerm_resource_group.main", Type: "azurerm_resource_group", Provider: "azurerm", ChangeType: plan.ChangeTypeCreate},
+			{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Provider: "aws", ChangeType: plan.ChangeTypeDelete, IsDangerous: true},
+			{Address: "aws_vpc.main", Type: "aws_vpc", Provider: "aws", ChangeType: plan.ChangeTypeNoOp},
+		},
+	}
+}
+
+func visibleAddresses(e *explorer) []string {
+	addresses := []string{}
+	for _, index := range e.visible {
+		addresses = append(addresses, e.resources[index].Address)
+	}
+	return addresses
+}
+
+func runes(text string) []key {
+	keys := []key{}
+	for _, r := range text {
+		keys = append(keys, key{code: keyRune, r: r})
+	}
+	return keys
+}
+
+func TestExplorer_NoOps(t *testing.T) {
+	assert.Len(t, newExplorer(testSummary(), Options{}).visible, 4)
+	assert.Len(t, newExplorer(testSummary(), Options{ShowNoOps: true}).visible, 5)
+}
+
+func TestExplorer_Filters(t *testing.T) {
+	e := newExplorer(testSummary(), Options{})
+	assert.Equal(t, []string{"aws", "azurerm"}, e.providers)
+	assert.Equal(t, []string{"(root)", "db"}, e.modules)
+
+	e.handleKey(key{code: keyRune, r: 'a'}) // create
+	assert.Equal(t, []string{"azurerm_resource_group.main"}, visibleAddresses(e))
+	e.handleKey(key{code: keyRune, r: 'a'}) // update
+	assert.Equal(t, []string{"aws_instance.web"}, visibleAddresses(e))
+
+	e.handleKey(key{code: keyRune, r: 'c'})
+	e.handleKey(key{code: keyRune, r: 'p'}) // aws
+	e.handleKey(key{code: keyRune, r: 'm'}) // root
+	assert.Equal(t, []string{"aws_instance.web", "aws_s3_bucket.logs"}, visibleAddresses(e))
+
+	e.handleKey(key{code: keyRune, r: 'c'})
+	e.handleKey(key{code: keyRune, r: 'r'}) // medium+
+	e.handleKey(key{code: keyRune, r: 'r'}) // high+
+	assert.Equal(t, []string{"module.db.aws_db_instance.main", "aws_s3_bucket.logs"}, visibleAddresses(e))
+	e.handleKey(key{code: keyRune, r: 'r'}) // critical
+	assert.Equal(t, []string{"aws_s3_bucket.logs"}, visibleAddresses(e))
+	e.handleKey(key{code: keyRune, r: 'r'}) // any
+	assert.Len(t, e.visible, 4)
+}
+
+func TestExplorer_Search(t *testing.T) {
+	e := newExplorer(testSummary(), Options{})
+	e.handleKey(key{code: keyRune, r: '/'})
+	for _, k := range runes("SENSITIVE") {
+		assert.True(t, e.handleKey(k), "typing q in a search must not quit")
+	}
+	assert.True(t, e.searching)
+	assert.Equal(t, []string{"module.db.aws_db_instance.main"}, visibleAddresses(e))
+
+	e.handleKey(key{code: keyEnter})
+	assert.False(t, e.searching)
+	assert.Equal(t, "SENSITIVE", e.query)
+
+	e.handleKey(key{code: keyRune, r: '/'})
+	e.handleKey(key{code: keyEsc})
+	assert.Empty(t, e.query)
+	assert.Len(t, e.visible, 4)
+}
+
+func TestExplorer_Navigation(t *testing.T) {
+	e := newExplorer(testSummary(), Options{})
+	e.resize(80, 5) // Two list rows
+
+	e.handleKey(key{code: keyEnd})
+	assert.Equal(t, 3, e.cursor)
+	assert.Equal(t, 2, e.offset)
+	e.handleKey(key{code: keyDown})
+	assert.Equal(t, 3, e.cursor)
+	e.handleKey(key{code: keyRune, r: 'g'})
+	assert.Equal(t, 0, e.cursor)
+	assert.Equal(t, 0, e.offset)
+
+	// The selection is kept when it still matches a new filter
+	e.handleKey(key{code: keyRune, r: 'j'})
+	e.handleKey(key{code: keyRune, r: 'p'})
+	assert.Equal(t, "module.db.aws_db_instance.main", e.selected().Address)
+
+	assert.False(t, e.handleKey(key{code: keyRune, r: 'q'}))
+	assert.False(t, e.handleKey(key{code: keyCtrlC}))
+}
+
+func TestExplorer_Render(t *testing.T) {
+	e := newExplorer(testSummary(), Options{})
+	e.resize(100, 20)
+	e.handleKey(key{code: keyRune, r: 'j'})
+
+	lines := e.render()
+	require.Len(t, lines, 20)
+	screen := stripStyles(strings.Join(lines, "\n"))
+	assert.Contains(t, screen, "4 of 4 resources")
+	assert.Contains(t, screen, "-/+ module.db.aws_db_instance.main")
+	assert.Contains(t, screen, "Danger:   Sensitive resource replacement")
+	assert.Contains(t, screen, "Replacement caused by:")
+	assert.Contains(t, screen, "Risk:     high")
+
+	e.handleKey(key{code: keyRune, r: 'k'})
+	screen = stripStyles(strings.Join(e.render(), "\n"))
+	assert.Contains(t, screen, `~ instance_type: "t3.micro" → "t3.large"`)
+	assert.Contains(t, screen, "~ password: (sensitive value unchanged)")
+	assert.Contains(t, screen, "Module:   (root)")
+
+	e.handleKey(key{code: keyRune, r: '?'})
+	assert.Contains(t, strings.Join(e.render(), "\n"), "Press any key to close the help")
+	assert.True(t, e.handleKey(key{code: keyRune, r: 'x'}))
+	assert.False(t, e.showHelp)
+
+	e.handleKey(key{code: keyRune, r: '/'})
+	for _, k := range runes("nothing") {
+		e.handleKey(k)
+	}
+	screen = stripStyles(strings.Join(e.render(), "\n"))
+	assert.Contains(t, screen, "No resources match the filters")
+	assert.Contains(t, screen, "/nothing█")
+}
+
+func TestExplorer_CopyAndExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	e := newExplorer(testSummary(), Options{ExportPath: path})
+	var clipboard bytes.Buffer
+	e.clipboard = &clipboard
+
+	e.handleKey(key{code: keyRune, r: 'y'})
+	assert.Equal(t, "\033]52;c;"+base64.StdEncoding.EncodeToString([]byte("aws_instance.web"))+"\a", clipboard.String())
+	assert.Equal(t, "Copied aws_instance.web", e.status)
+
+	e.handleKey(key{code: keyRune, r: 'r'})
+	e.handleKey(key{code: keyRune, r: 'e'})
+	assert.Contains(t, e.status, "Exported 2 resources")
+
+	exported, err := plan.LoadPreviousSummaries(filepath.Dir(path), nil)
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+	assert.Len(t, exported[0].ResourceChanges, 2)
+	assert.Equal(t, 2, exported[0].Statistics.HighRisk)
+	assert.Equal(t, 1, exported[0].Statistics.ToDestroy)
+	assert.Equal(t, "test.tfplan", exported[0].PlanFile)
+}
+
+func TestReadKey(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("j\033[B\033OA\033[6~\rä\x7f\x03"))
+	expected := []key{
+		{code: keyRune, r: 'j'},
+		{code: keyDown},
+		{code: keyUp},
+		{code: keyPageDown},
+		{code: keyEnter},
+		{code: keyRune, r: 'ä'},
+		{code: keyBackspace},
+		{code: keyCtrlC},
+	}
+	for _, want := range expected {
+		got, err := readKey(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	// A lone escape isn't followed by the rest of a sequence
+	got, err := readKey(bufio.NewReader(strings.NewReader("\033")))
+	require.NoError(t, err)
+	assert.Equal(t, key{code: keyEsc}, got)
+}
+
+func TestCycle(t *testing.T) {
+	options := []string{"a", "b"}
+	assert.Equal(t, "a", cycle(options, ""))
+	assert.Equal(t, "b", cycle(options, "a"))
+	assert.Empty(t, cycle(options, "b"))
+	assert.Empty(t, cycle(nil, ""))
+}
@@ -0,0 +1,158 @@
+package explore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"golang.org/x/term"
+)
+
+// keyCode identifies a key press
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyHome
+	keyEnd
+	keyEnter
+	keyEsc
+	keyBackspace
+	keyCtrlC
+	keyUnknown
+)
+
+// key is a decoded key press. r is set for keyRune.
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// escapeKeys maps the escape sequences of special keys to key codes. Both the normal and
+// application cursor key sequences are included.
+var escapeKeys = map[string]keyCode{
+	"\033[A":  keyUp,
+	"\033OA":  keyUp,
+	"\033[B":  keyDown,
+	"\033OB":  keyDown,
+	"\033[5~": keyPageUp,
+	"\033[6~": keyPageDown,
+	"\033[H":  keyHome,
+	"\033OH":  keyHome,
+	"\033[1~": keyHome,
+	"\033[F":  keyEnd,
+	"\033OF":  keyEnd,
+	"\033[4~": keyEnd,
+}
+
+// ErrNotTerminal is returned when the explorer isn't run in an interactive terminal
+var ErrNotTerminal = errors.New("plan explore requires an interactive terminal")
+
+// Run shows the explorer for a plan summary in the terminal until the user quits
+func Run(summary *plan.PlanSummary, options Options) error {
+	in, out := os.Stdin, os.Stdout
+	if !term.IsTerminal(int(in.Fd())) || !term.IsTerminal(int(out.Fd())) {
+		return ErrNotTerminal
+	}
+
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to configure terminal: %w", err)
+	}
+	defer func() { _ = term.Restore(int(in.Fd()), state) }()
+
+	// Use the alternate screen, so the shell output is restored on exit
+	fmt.Fprint(out, "\033[?1049h\033[?25l")
+	defer fmt.Fprint(out, "\033[?25h\033[?1049l")
+
+	e := newExplorer(summary, options)
+	e.clipboard = out
+	return e.loop(bufio.NewReader(in), out, func() (int, int, error) {
+		return term.GetSize(int(out.Fd()))
+	})
+}
+
+// loop renders the explorer and handles key presses until the user quits. The terminal
+// size is checked before every render, so resizes are picked up with the next key press.
+func (e *explorer) loop(in *bufio.Reader, out io.Writer, size func() (int, int, error)) error {
+	for {
+		if width, height, err := size(); err == nil {
+			e.resize(width, height)
+		}
+		frame := "\033[H" + strings.Join(e.render(), "\r\n")
+		if _, err := io.WriteString(out, frame); err != nil {
+			return fmt.Errorf("failed to render: %w", err)
+		}
+
+		k, err := readKey(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		if !e.handleKey(k) {
+			return nil
+		}
+	}
+}
+
+// readKey reads and decodes a single key press from a terminal in raw mode
+func readKey(in *bufio.Reader) (key, error) {
+	b, err := in.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+
+	switch b {
+	case 3:
+		return key{code: keyCtrlC}, nil
+	case '\r', '\n':
+		return key{code: keyEnter}, nil
+	case 8, 127:
+		return key{code: keyBackspace}, nil
+	case 27:
+		// An escape sequence arrives in a single read, while a lone escape key doesn't
+		if in.Buffered() == 0 {
+			return key{code: keyEsc}, nil
+		}
+		sequence := []byte{b}
+		for in.Buffered() > 0 {
+			next, _ := in.ReadByte()
+			sequence = append(sequence, next)
+			// Sequences end with a letter or a tilde
+			if len(sequence) > 2 && (next == '~' || (next >= 'A' && next <= 'Z') || (next >= 'a' && next <= 'z')) {
+				break
+			}
+		}
+		if code, ok := escapeKeys[string(sequence)]; ok {
+			return key{code: code}, nil
+		}
+		return key{code: keyUnknown}, nil
+	}
+
+	if b < utf8.RuneSelf {
+		if b < 32 {
+			return key{code: keyUnknown}, nil
+		}
+		return key{code: keyRune, r: rune(b)}, nil
+	}
+	// Multi-byte characters, which can be used in searches
+	if err := in.UnreadByte(); err != nil {
+		return key{}, err
+	}
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return key{}, err
+	}
+	return key{code: keyRune, r: r}, nil
+}
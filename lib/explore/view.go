@@ -0,0 +1,296 @@
+package explore
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ArjenSchwarz/strata/lib/plan"
+	"github.com/mattn/go-runewidth"
+)
+
+// ANSI escape sequences used for rendering
+const (
+	styleReset   = "\033[0m"
+	styleBold    = "\033[1m"
+	styleReverse = "\033[7m"
+	styleRed     = "\033[31m"
+	styleGreen   = "\033[32m"
+	styleYellow  = "\033[33m"
+	styleMagenta = "\033[35m"
+)
+
+// helpText describes the key bindings
+var helpText = []string{
+	"Navigation",
+	"  ↑/k ↓/j        Select the previous or next resource",
+	"  PgUp PgDn      Move a page up or down",
+	"  g/Home G/End   Select the first or last resource",
+	"  K J            Scroll the detail pane",
+	"",
+	"Filters",
+	"  /              Search addresses, types, and danger reasons",
+	"  a              Cycle the action filter",
+	"  p              Cycle the provider filter",
+	"  m              Cycle the module filter",
+	"  r              Cycle the minimum risk level",
+	"  c              Clear all filters and the search",
+	"",
+	"Actions",
+	"  y              Copy the address of the selected resource",
+	"  e              Export the filtered view as summary JSON",
+	"  q/Ctrl+C       Quit",
+}
+
+// render returns the full screen for the current state, one string per terminal line
+func (e *explorer) render() []string {
+	lines := make([]string, 0, e.height)
+	lines = append(lines, styleReverse+pad(e.header(), e.width)+styleReset)
+	lines = append(lines, pad(e.filterLine(), e.width))
+
+	height := e.listHeight()
+	if e.showHelp {
+		for i := range height {
+			line := ""
+			if i < len(helpText) {
+				line = helpText[i]
+			}
+			lines = append(lines, pad(line, e.width))
+		}
+	} else {
+		listWidth := max(e.width*2/5, 20)
+		detailWidth := max(e.width-listWidth-3, 10)
+		detail := e.detailLines(detailWidth)
+		e.detailOffset = max(min(e.detailOffset, len(detail)-height), 0)
+		for i := range height {
+			row := e.listRow(e.offset+i, listWidth)
+			detailLine := ""
+			if e.detailOffset+i < len(detail) {
+				detailLine = detail[e.detailOffset+i]
+			}
+			lines = append(lines, row+" │ "+pad(detailLine, detailWidth))
+		}
+	}
+
+	lines = append(lines, pad(e.footer(), e.width))
+	return lines[:min(len(lines), max(e.height, 1))]
+}
+
+func (e *explorer) header() string {
+	return fmt.Sprintf(" Strata plan explorer  %s  %d of %d resources", e.summary.PlanFile, len(e.visible), len(e.resources))
+}
+
+func (e *explorer) filterLine() string {
+	orAll := func(value string) string {
+		if value == "" {
+			return "all"
+		}
+		return value
+	}
+	risk := "any"
+	if e.minRisk != "" {
+		risk = e.minRisk + "+"
+	}
+	line := fmt.Sprintf(" Action: %s  Provider: %s  Module: %s  Risk: %s", orAll(string(e.action)), orAll(e.provider), orAll(e.module), risk)
+	if e.query != "" && !e.searching {
+		line += "  Search: " + e.query
+	}
+	return line
+}
+
+func (e *explorer) footer() string {
+	switch {
+	case e.showHelp:
+		return " Press any key to close the help"
+	case e.searching:
+		return " /" + e.query + "█"
+	case e.status != "":
+		return " " + e.status
+	default:
+		return " ↑↓ move  / search  a action  p provider  m module  r risk  c clear  y copy  e export  ? help  q quit"
+	}
+}
+
+// listRow renders a resource in the list pane, padded to width. The selected resource is highlighted.
+func (e *explorer) listRow(index, width int) string {
+	if index >= len(e.visible) {
+		if index == 0 {
+			return pad(" No resources match the filters", width)
+		}
+		return pad("", width)
+	}
+	change := e.resources[e.visible[index]]
+	symbol, color := actionSymbol(change.ChangeType)
+	marker := " "
+	if change.IsDangerous {
+		marker = "!"
+	}
+	text := fmt.Sprintf("%s %3s %s", marker, symbol, change.Address)
+	text = pad(truncate(text, width), width)
+	if index == e.cursor {
+		return styleReverse + text + styleReset
+	}
+	return color + text + styleReset
+}
+
+// actionSymbol returns the Terraform symbol and color of a change type
+func actionSymbol(changeType plan.ChangeType) (string, string) {
+	switch changeType {
+	case plan.ChangeTypeCreate:
+		return "+", styleGreen
+	case plan.ChangeTypeUpdate:
+		return "~", styleYellow
+	case plan.ChangeTypeDelete:
+		return "-", styleRed
+	case plan.ChangeTypeReplace:
+		return "-/+", styleMagenta
+	default:
+		return "", ""
+	}
+}
+
+// detailLines describes the selected resource, wrapped to width
+func (e *explorer) detailLines(width int) []string {
+	change := e.selected()
+	if change == nil {
+		return nil
+	}
+
+	lines := []string{
+		styleBold + truncate(change.Address, width) + styleReset,
+		"",
+		"Action:   " + string(change.ChangeType),
+		"Type:     " + change.Type,
+		"Provider: " + change.Provider,
+		"Module:   " + moduleName(*change),
+		"Risk:     " + change.RiskLevel(),
+	}
+	if change.PhysicalID != "" && change.PhysicalID != "-" {
+		lines = append(lines, "ID:       "+change.PhysicalID)
+	}
+	if change.DangerReason != "" {
+		lines = append(lines, "", "Danger:   "+change.DangerReason)
+	}
+	if len(change.ReplacementHints) > 0 {
+		lines = append(lines, "", "Replacement caused by:")
+		for _, hint := range change.ReplacementHints {
+			lines = append(lines, "  "+hint)
+		}
+	}
+	if len(change.UnknownProperties) > 0 {
+		lines = append(lines, "", "Known after apply: "+strings.Join(change.UnknownProperties, ", "))
+	}
+
+	properties := change.PropertyChanges
+	if len(properties.Changes) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Property changes (%d):", len(properties.Changes)))
+		for _, property := range properties.Changes {
+			lines = append(lines, "  "+propertyLine(property))
+		}
+		if properties.Truncated {
+			lines = append(lines, "  … more changes not shown")
+		}
+	}
+
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		wrapped = append(wrapped, wrap(line, width)...)
+	}
+	return wrapped
+}
+
+// propertyLine describes a single property change in Terraform's diff notation
+func propertyLine(property plan.PropertyChange) string {
+	name := property.Name
+	if len(property.Path) > 0 {
+		name = strings.Join(property.Path, ".")
+	}
+
+	var line string
+	switch {
+	case property.Sensitive:
+		status := property.SensitiveStatus
+		if status == "" {
+			status = "changed"
+		}
+		line = fmt.Sprintf("~ %s: (sensitive value %s)", name, status)
+	case property.Action == "add":
+		line = fmt.Sprintf("+ %s = %s", name, afterValue(property))
+	case property.Action == "remove":
+		line = fmt.Sprintf("- %s = %s", name, formatValue(property.Before))
+	default:
+		line = fmt.Sprintf("~ %s: %s → %s", name, formatValue(property.Before), afterValue(property))
+	}
+
+	if property.TriggersReplacement {
+		line += "  # forces replacement"
+	}
+	if property.PerpetualDiff {
+		line += "  # perpetual diff"
+	}
+	return line
+}
+
+// afterValue formats the after value of a property, which may not be known until apply
+func afterValue(property plan.PropertyChange) string {
+	if property.IsUnknown && property.UnknownType != "before" {
+		return "(known after apply)"
+	}
+	return formatValue(property.After)
+}
+
+// formatValue formats a value on a single line, quoting strings
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// pad truncates or pads a line with spaces to exactly width columns
+func pad(line string, width int) string {
+	line = truncate(line, width)
+	return line + strings.Repeat(" ", max(width-runewidth.StringWidth(stripStyles(line)), 0))
+}
+
+// truncate shortens a line to width columns, ending it with an ellipsis when it was cut
+func truncate(line string, width int) string {
+	if strings.Contains(line, "\033") {
+		// Styled lines are only ever short labels, so they aren't cut
+		return line
+	}
+	return runewidth.Truncate(line, width, "…")
+}
+
+// wrap splits a line into lines of at most width columns, indenting continuation lines
+func wrap(line string, width int) []string {
+	if strings.Contains(line, "\033") || runewidth.StringWidth(line) <= width {
+		return []string{line}
+	}
+	var lines []string
+	current := ""
+	for _, r := range line {
+		if runewidth.StringWidth(current)+runewidth.RuneWidth(r) > width {
+			lines = append(lines, current)
+			current = "    "
+		}
+		current += string(r)
+	}
+	return append(lines, current)
+}
+
+// stripStyles removes the ANSI escape sequences this package uses from a line
+func stripStyles(line string) string {
+	for _, style := range []string{styleReset, styleBold, styleReverse, styleRed, styleGreen, styleYellow, styleMagenta} {
+		line = strings.ReplaceAll(line, style, "")
+	}
+	return line
+}
@@ -1,7 +1,10 @@
 package plan
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"sort"
 	"strconv"
@@ -13,8 +16,10 @@ import (
 )
 
 const (
-	riskLevelHigh   = "high"
-	riskLevelMedium = "medium"
+	riskLevelCritical = "critical"
+	riskLevelHigh     = "high"
+	riskLevelMedium   = "medium"
+	riskLevelLow      = "low"
 
 	// Action constants for property change analysis
 	actionAdd    = "add"
@@ -40,6 +45,9 @@ type Analyzer struct {
 	plan          *tfjson.Plan
 	config        *config.Config
 	providerCache sync.Map // Cache for provider extraction results
+
+	secretScanner     *secretScanner // Lazily created from the secret scanning configuration
+	secretScannerOnce sync.Once
 }
 
 // NewAnalyzer creates a new plan analyzer
@@ -208,8 +216,15 @@ func (a *Analyzer) compareObjects(path string, before, after, beforeSensitive, a
 				triggersReplacement = a.pathMatchesReplacePathString(propertyPath, replacePathStrings)
 			}
 
+			// Mask sensitive values within the object, or the whole object when it's sensitive
+			beforeSens, afterSens := beforeSensitive, afterSensitive
+			if isSensitive {
+				beforeSens, afterSens = true, true
+			}
+			maskedBefore, maskedAfter := a.maskSensitivePair(processedBefore, processedAfter, beforeSens, afterSens)
+
 			// Handle unknown values override logic (requirement 1.6)
-			displayAfter := processedAfter
+			displayAfter := maskedAfter
 			unknownType := ""
 			if isUnknown {
 				displayAfter = a.getUnknownValueDisplay()
@@ -217,20 +232,24 @@ func (a *Analyzer) compareObjects(path string, before, after, beforeSensitive, a
 
 				// When a nested object is unknown, collect all nested property paths
 				// and add them as individual unknown properties for tracking
-				a.collectNestedUnknownProperties(path, processedBefore, processedAfter, analysis)
+				a.collectNestedUnknownProperties(path, maskedBefore, processedAfter, analysis)
 			}
 
-			analysis.Changes = append(analysis.Changes, PropertyChange{
+			change := PropertyChange{
 				Name:                a.extractPropertyName(path),
 				Path:                propertyPath,
-				Before:              processedBefore,
+				Before:              maskedBefore,
 				After:               displayAfter,
 				Action:              action,
 				TriggersReplacement: triggersReplacement,
 				Sensitive:           isSensitive, // Already computed above
 				IsUnknown:           isUnknown,
 				UnknownType:         unknownType,
-			})
+			}
+			if isSensitive {
+				a.setSensitiveStatus(&change, before, after)
+			}
+			analysis.Changes = append(analysis.Changes, change)
 		}
 		// Don't recurse further for nested objects we're treating as single changes
 		return
@@ -263,7 +282,7 @@ func (a *Analyzer) compareObjects(path string, before, after, beforeSensitive, a
 			unknownType = "after"
 		}
 
-		analysis.Changes = append(analysis.Changes, PropertyChange{
+		change := PropertyChange{
 			Name:                a.extractPropertyName(path),
 			Path:                propertyPath,
 			Before:              processedBefore,
@@ -273,7 +292,11 @@ func (a *Analyzer) compareObjects(path string, before, after, beforeSensitive, a
 			Sensitive:           isSensitive, // Already computed above
 			IsUnknown:           isUnknown,
 			UnknownType:         unknownType,
-		})
+		}
+		if isSensitive {
+			a.setSensitiveStatus(&change, before, after)
+		}
+		analysis.Changes = append(analysis.Changes, change)
 
 		// For leaf values, don't recurse further
 		if !isComplexType(processedBefore) && !isComplexType(processedAfter) {
@@ -317,17 +340,9 @@ func (a *Analyzer) compareObjects(path string, before, after, beforeSensitive, a
 				afterChild = afterMap[key]
 			}
 
-			var beforeSensChild, afterSensChild any
-			if beforeSensitive != nil {
-				if beforeSensMap, ok := beforeSensitive.(map[string]any); ok {
-					beforeSensChild = beforeSensMap[key]
-				}
-			}
-			if afterSensitive != nil {
-				if afterSensMap, ok := afterSensitive.(map[string]any); ok {
-					afterSensChild = afterSensMap[key]
-				}
-			}
+			// Children of a sensitive object are sensitive too
+			beforeSensChild := sensitiveChild(beforeSensitive, key)
+			afterSensChild := sensitiveChild(afterSensitive, key)
 
 			// Extract afterUnknown for the child property
 			var afterUnknownChild any
@@ -449,12 +464,7 @@ func (a *Analyzer) compareObjects(path string, before, after, beforeSensitive, a
 					newPath = key
 				}
 
-				var afterSensChild any
-				if afterSensitive != nil {
-					if afterSensMap, ok := afterSensitive.(map[string]any); ok {
-						afterSensChild = afterSensMap[key]
-					}
-				}
+				afterSensChild := sensitiveChild(afterSensitive, key)
 
 				// Extract afterUnknown for the new property
 				var afterUnknownChild any
@@ -825,6 +835,11 @@ func (a *Analyzer) extractSensitiveIndex(sensitiveValues any, index int) any {
 		return nil
 	}
 
+	// Elements of a sensitive list are sensitive too
+	if marked, ok := sensitiveValues.(bool); ok && marked {
+		return true
+	}
+
 	if sensitiveSlice, ok := sensitiveValues.([]any); ok {
 		if index >= 0 && index < len(sensitiveSlice) {
 			return sensitiveSlice[index]
@@ -847,91 +862,186 @@ func (a *Analyzer) GenerateSummary(planFile string) *PlanSummary {
 		a.plan = plan
 	}
 
-	summary := &PlanSummary{
-		FormatVersion:    a.plan.FormatVersion,
-		TerraformVersion: a.plan.TerraformVersion,
-		PlanFile:         planFile,
-		Workspace:        parser.extractWorkspaceInfo(a.plan),
-		Backend:          parser.extractBackendInfo(a.plan),
-		ResourceChanges:  a.analyzeResourceChanges(),
-		OutputChanges:    a.analyzeOutputChanges(),
+	summary := a.SummarizePlan(planFile)
+	a.describePlanFile(summary, parser)
+	return summary
+}
+
+// SummarizePlanFile generates the summary of a plan file like GenerateSummary, but analyses the resource
+// changes while the plan is read instead of loading the whole plan first, see Parser.StreamPlan.
+// The analyzer keeps the rest of the plan, without its resource changes.
+func (a *Analyzer) SummarizePlanFile(ctx context.Context, planFile string) (*PlanSummary, error) {
+	parser := NewParser(planFile)
+	summary, err := a.summarizeStream(ctx, planFile, parser.StreamPlan)
+	if err != nil {
+		return nil, err
 	}
+	a.describePlanFile(summary, parser)
+	return summary, nil
+}
+
+// SummarizeReader generates the summary of a plan in JSON format like SummarizePlanContext, analysing the
+// resource changes while the plan is decoded from r, see DecodePlanStream. The analyzer keeps the rest of
+// the plan, without its resource changes.
+func (a *Analyzer) SummarizeReader(ctx context.Context, r io.Reader, name string) (*PlanSummary, error) {
+	return a.summarizeStream(ctx, name, func(handle func(*tfjson.ResourceChange) error) (*tfjson.Plan, error) {
+		return DecodePlanStream(r, handle)
+	})
+}
+
+// summarizeStream summarises a plan that decode hands the resource changes of to the analysis as they're
+// decoded. Decoding stops when the context is cancelled.
+func (a *Analyzer) summarizeStream(ctx context.Context, name string, decode func(handle func(*tfjson.ResourceChange) error) (*tfjson.Plan, error)) (*PlanSummary, error) {
+	pool := a.newResourcePool(ctx)
+	plan, decodeErr := decode(pool.add)
+	resourceChanges, err := pool.wait()
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.plan = plan
+	return a.summarize(name, resourceChanges), nil
+}
+
+// describePlanFile fills in the workspace, root module, backend, and creation time of a summary of a local plan file
+func (a *Analyzer) describePlanFile(summary *PlanSummary, parser *Parser) {
+	summary.Workspace = parser.extractWorkspaceInfo(a.plan)
+	summary.RootModule = planRoot(parser.planFile)
+	summary.Backend = parser.extractBackendInfo(a.plan)
 
 	// Get file creation time
-	if createdAt, err := parser.getPlanFileInfo(planFile); err == nil {
+	if createdAt, err := parser.getPlanFileInfo(parser.planFile); err == nil {
 		summary.CreatedAt = createdAt
 	}
+}
+
+// SummarizePlan generates a summary of the loaded plan without inspecting the plan file or the local
+// Terraform workspace, for plans received from elsewhere such as uploads to the API server.
+// The workspace, backend, and creation time are left for the caller to fill in.
+func (a *Analyzer) SummarizePlan(name string) *PlanSummary {
+	// Analysis can only fail when the context is cancelled
+	summary, _ := a.SummarizePlanContext(context.Background(), name)
+	return summary
+}
 
+// SummarizePlanContext is SummarizePlan with cancellation. Resource changes are analysed concurrently,
+// and analysis stops with the context's error when the context is cancelled.
+func (a *Analyzer) SummarizePlanContext(ctx context.Context, name string) (*PlanSummary, error) {
+	if a.plan == nil {
+		return nil, nil
+	}
+
+	resourceChanges, err := a.analyzeResourceChangesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return a.summarize(name, resourceChanges), nil
+}
+
+// summarize builds the summary of the plan from its analysed resource changes
+func (a *Analyzer) summarize(name string, resourceChanges []ResourceChange) *PlanSummary {
+	summary := &PlanSummary{
+		SchemaVersion:    SummarySchemaVersion,
+		FormatVersion:    a.plan.FormatVersion,
+		TerraformVersion: a.plan.TerraformVersion,
+		PlanFile:         name,
+		ResourceChanges:  resourceChanges,
+		OutputChanges:    a.analyzeOutputChanges(),
+	}
+	a.addDependencies(summary.ResourceChanges)
 	summary.Statistics = a.calculateStatistics(summary.ResourceChanges, summary.OutputChanges)
 	return summary
 }
 
 // analyzeResourceChanges processes all resource changes in the plan
 func (a *Analyzer) analyzeResourceChanges() []ResourceChange {
-	if a.plan.ResourceChanges == nil {
-		return []ResourceChange{}
-	}
-
-	changes := make([]ResourceChange, 0, len(a.plan.ResourceChanges))
+	// Analysis can only fail when the context is cancelled
+	changes, _ := a.analyzeResourceChangesContext(context.Background())
+	return changes
+}
 
-	for _, rc := range a.plan.ResourceChanges {
-		changeType := FromTerraformAction(rc.Change.Actions)
-		replacementType := a.analyzeReplacementNecessity(rc)
+// analyzeResourceChange analyzes a single resource change, including its property changes and danger evaluation
+func (a *Analyzer) analyzeResourceChange(rc *tfjson.ResourceChange) ResourceChange {
+	changeType := FromTerraformAction(rc.Change.Actions)
+	replacementType := a.analyzeReplacementNecessity(rc)
 
-		// Analyze property changes
-		propertyChanges := a.analyzePropertyChanges(rc)
+	// Analyze property changes
+	propertyChanges := a.analyzePropertyChanges(rc)
 
-		// Extract unknown values information (requirement 1.5, 1.2)
-		hasUnknownValues := false
-		unknownProperties := []string{}
+	// Extract unknown values information (requirement 1.5, 1.2)
+	hasUnknownValues := false
+	unknownProperties := []string{}
 
-		for _, pc := range propertyChanges.Changes {
-			if pc.IsUnknown {
-				hasUnknownValues = true
-				if len(pc.Path) > 0 {
-					unknownProperties = append(unknownProperties, strings.Join(pc.Path, "."))
-				} else {
-					unknownProperties = append(unknownProperties, pc.Name)
-				}
+	for _, pc := range propertyChanges.Changes {
+		if pc.IsUnknown {
+			hasUnknownValues = true
+			if len(pc.Path) > 0 {
+				unknownProperties = append(unknownProperties, strings.Join(pc.Path, "."))
+			} else {
+				unknownProperties = append(unknownProperties, pc.Name)
 			}
 		}
-
-		change := ResourceChange{
-			Address:          rc.Address,
-			Type:             rc.Type,
-			Name:             rc.Name,
-			ChangeType:       changeType,
-			IsDestructive:    changeType.IsDestructive(),
-			ReplacementType:  replacementType,
-			PhysicalID:       a.extractPhysicalID(rc),
-			PlannedID:        a.extractPlannedID(rc),
-			ModulePath:       a.extractModulePath(rc.Address),
-			ChangeAttributes: a.getChangingAttributes(rc),
-			Before:           rc.Change.Before,
-			After:            rc.Change.After,
-			// Check for sensitive resources and properties
-			IsDangerous:      false, // Will be updated below
-			DangerReason:     "",
-			DangerProperties: []string{},
-			// Enhanced summary visualization fields
-			Provider:         a.extractProvider(rc.Type),
-			ReplacementHints: a.extractReplacementHints(rc),
-			TopChanges:       a.getTopChangedProperties(rc, 3),
-			PropertyChanges:  propertyChanges,
-			// Unknown values fields (requirement 1.2, 1.5)
-			HasUnknownValues:  hasUnknownValues,
-			UnknownProperties: unknownProperties,
-			// Mark no-op resources for filtering (Output Refinements feature)
-			IsNoOp: changeType == ChangeTypeNoOp,
-		}
-
-		// Enhanced danger reason logic
-		change.IsDangerous, change.DangerReason = a.evaluateResourceDanger(rc, changeType)
-
-		changes = append(changes, change)
 	}
 
-	return changes
+	maskedBefore, maskedAfter := a.maskSensitivePair(rc.Change.Before, rc.Change.After, rc.Change.BeforeSensitive, rc.Change.AfterSensitive)
+	sensitiveDigest, hasSensitiveValues := a.sensitiveDigest(rc.Change)
+
+	change := ResourceChange{
+		Address:          rc.Address,
+		Type:             rc.Type,
+		Name:             rc.Name,
+		ChangeType:       changeType,
+		IsDestructive:    changeType.IsDestructive(),
+		ReplacementType:  replacementType,
+		PhysicalID:       a.extractPhysicalID(rc),
+		PlannedID:        a.extractPlannedID(rc),
+		ModulePath:       a.extractModulePath(rc.Address),
+		ChangeAttributes: a.getChangingAttributes(rc),
+		// Raw values are kept for JSON output, with sensitive values masked
+		Before: maskedBefore,
+		After:  maskedAfter,
+		// Check for sensitive resources and properties
+		IsDangerous:      false, // Will be updated below
+		DangerReason:     "",
+		DangerProperties: []string{},
+		// Enhanced summary visualization fields
+		Provider:         a.extractProvider(rc.Type),
+		ReplacementHints: a.extractReplacementHints(rc),
+		TopChanges:       a.getTopChangedProperties(rc, 3),
+		PropertyChanges:  propertyChanges,
+		// Unknown values fields (requirement 1.2, 1.5)
+		HasUnknownValues:  hasUnknownValues,
+		UnknownProperties: unknownProperties,
+		// Mark no-op resources for filtering (Output Refinements feature)
+		IsNoOp: changeType == ChangeTypeNoOp,
+		// Approvals cover the real sensitive values, which are masked everywhere else
+		SensitiveDigest:    sensitiveDigest,
+		HasSensitiveValues: hasSensitiveValues,
+	}
+
+	// Redact secrets in values that Terraform doesn't know are sensitive
+	a.redactResourceSecrets(rc, &change)
+
+	// Enhanced danger reason logic
+	change.IsDangerous, change.DangerReason = a.evaluateResourceDanger(rc, changeType)
+
+	// Secrets in non-sensitive values end up in plan files, logs, and PR comments
+	if len(change.SecretFindings) > 0 {
+		change.IsDangerous = true
+		change.DangerReason = joinDangerReasons(change.DangerReason, "Possible secret exposure")
+		change.DangerProperties = append(change.DangerProperties, secretFindingProperties(change.SecretFindings)...)
+	}
+
+	// Policy changes that grant wildcard actions or principals are always dangerous
+	if escalated := policyEscalationProperties(propertyChanges); len(escalated) > 0 {
+		change.IsDangerous = true
+		change.DangerReason = joinDangerReasons(change.DangerReason, "Privilege escalation")
+		change.DangerProperties = append(change.DangerProperties, escalated...)
+	}
+
+	return change
 }
 
 // analyzeReplacementNecessity determines the replacement necessity for a resource change
@@ -1021,6 +1131,15 @@ func (a *Analyzer) analyzeOutputChange(name string, change *tfjson.Change) (*Out
 
 	// Handle sensitive output detection with "(sensitive value)" display (requirement 2.4)
 	if isSensitive {
+		// Compare the real values before masking so reviewers can tell whether the value changed
+		outputChange.BeforeHash = a.sensitiveHash(change.Before)
+		if !isUnknown {
+			outputChange.AfterHash = a.sensitiveHash(change.After)
+		}
+		if change.Before != nil && change.After != nil && !isUnknown {
+			outputChange.SensitiveStatus, _, _ = a.sensitiveStatus(change.Before, change.After)
+		}
+
 		if outputChange.Before != nil {
 			outputChange.Before = sensitiveValue
 		}
@@ -1029,6 +1148,8 @@ func (a *Analyzer) analyzeOutputChange(name string, change *tfjson.Change) (*Out
 		}
 	}
 
+	a.redactOutputSecrets(outputChange)
+
 	return outputChange, nil
 }
 
@@ -1432,6 +1553,14 @@ func (a *Analyzer) evaluateResourceDanger(change *tfjson.ResourceChange, changeT
 	return isDangerous, reason
 }
 
+// joinDangerReasons appends an additional reason to an existing danger reason
+func joinDangerReasons(existing, reason string) string {
+	if existing == "" {
+		return reason
+	}
+	return existing + " and " + reason
+}
+
 // getSensitiveResourceReason returns a descriptive reason for sensitive resource changes
 func (a *Analyzer) getSensitiveResourceReason(resourceType string) string {
 	// Provide specific reasons based on common resource types
@@ -1566,6 +1695,15 @@ func (a *Analyzer) analyzePropertyChanges(change *tfjson.ResourceChange) Propert
 
 	// Note: Deduplication removed - improved comparison logic prevents duplicates at source
 
+	// Redact secrets before any further analysis sees the values
+	a.redactPropertySecrets(&analysis)
+
+	// Replace whole-string policy diffs with statement-level diffs
+	a.analyzePolicyChanges(&analysis)
+
+	// Diff JSON and YAML encoded strings structurally
+	a.analyzeEncodedDocuments(&analysis)
+
 	// Sort properties alphabetically within the resource (requirement 1.1, 1.2, 1.3, 1.4)
 	a.sortPropertiesAlphabetically(&analysis)
 
@@ -1620,7 +1758,11 @@ func (a *Analyzer) pathMatchesReplacePathString(propertyPath []string, replacePa
 	return false
 }
 
-// compareValues recursively compares two values and calls the callback for each difference
+// errStopComparison is returned by compareValues when the callback stops the comparison
+var errStopComparison = errors.New("comparison stopped")
+
+// compareValues recursively compares two values and calls the callback for each difference.
+// Returning false from the callback stops the comparison, which then returns errStopComparison.
 func (a *Analyzer) compareValues(before, after any, path []string, depth, maxDepth int, callback func(PropertyChange) bool) error {
 	// Prevent infinite recursion
 	if depth > maxDepth {
@@ -1642,7 +1784,7 @@ func (a *Analyzer) compareValues(before, after any, path []string, depth, maxDep
 	afterMap, afterIsMap := after.(map[string]any)
 
 	if beforeIsMap && afterIsMap {
-		// Compare map keys
+		// Compare map keys in sorted order, so the changes and any limit applied by the callback are stable
 		allKeys := make(map[string]bool)
 		for k := range beforeMap {
 			allKeys[k] = true
@@ -1650,8 +1792,13 @@ func (a *Analyzer) compareValues(before, after any, path []string, depth, maxDep
 		for k := range afterMap {
 			allKeys[k] = true
 		}
+		keys := make([]string, 0, len(allKeys))
+		for k := range allKeys {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 
-		for key := range allKeys {
+		for _, key := range keys {
 			beforeVal, beforeExists := beforeMap[key]
 			afterVal, afterExists := afterMap[key]
 
@@ -1668,9 +1815,10 @@ func (a *Analyzer) compareValues(before, after any, path []string, depth, maxDep
 					Before:    nil,
 					After:     afterVal,
 					Sensitive: false, // Will be updated if needed
+					Action:    actionAdd,
 				}
 				if !callback(pc) {
-					return nil // Stop processing
+					return errStopComparison
 				}
 			case !afterExists:
 				// Removed property
@@ -1680,9 +1828,10 @@ func (a *Analyzer) compareValues(before, after any, path []string, depth, maxDep
 					Before:    beforeVal,
 					After:     nil,
 					Sensitive: false,
+					Action:    actionRemove,
 				}
 				if !callback(pc) {
-					return nil
+					return errStopComparison
 				}
 			default:
 				// Compare nested values
@@ -1726,19 +1875,29 @@ func (a *Analyzer) compareValues(before, after any, path []string, depth, maxDep
 	}
 
 	// For primitive values or different types, record the change
+	action := actionUpdate
+	switch {
+	case before == nil:
+		action = actionAdd
+	case after == nil:
+		action = actionRemove
+	}
 	pc := PropertyChange{
 		Name:      strings.Join(path, "."),
 		Path:      path,
 		Before:    before,
 		After:     after,
 		Sensitive: false,
+		Action:    action,
 	}
 
 	// Check if this property is sensitive
 	// For now, we'll skip sensitive property detection in this function
 	// and handle it at a higher level as we need more context
 
-	callback(pc)
+	if !callback(pc) {
+		return errStopComparison
+	}
 	return nil
 }
 
@@ -1787,7 +1946,7 @@ func (a *Analyzer) assessRiskLevel(change *tfjson.ResourceChange) string {
 
 	if changeType == ChangeTypeDelete {
 		if a.IsSensitiveResource(change.Type) {
-			return "critical"
+			return riskLevelCritical
 		}
 		return riskLevelHigh
 	}
@@ -1803,7 +1962,7 @@ func (a *Analyzer) assessRiskLevel(change *tfjson.ResourceChange) string {
 		return riskLevelMedium
 	}
 
-	return "low"
+	return riskLevelLow
 }
 
 // AnalyzeResource performs comprehensive analysis with performance limits
@@ -0,0 +1,330 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Apply operation statuses
+const (
+	applyStatusApplied    = "applied"
+	applyStatusFailed     = "failed"
+	applyStatusIncomplete = "incomplete"
+	applyStatusNotStarted = "not started"
+
+	// applyActionRead is the hook action for data sources read during apply
+	applyActionRead = "read"
+
+	// maxApplyLogLineSize limits a single log line, which can contain long diagnostic details
+	maxApplyLogLineSize = 10 * 1024 * 1024
+)
+
+// ApplyLog holds the machine-readable UI messages from a `terraform apply -json` run
+type ApplyLog struct {
+	TerraformVersion string
+	messages         []applyLogMessage
+}
+
+// applyLogMessage is the subset of a machine-readable UI message needed for apply summaries
+type applyLogMessage struct {
+	Type       string              `json:"type"`
+	Timestamp  string              `json:"@timestamp"`
+	Terraform  string              `json:"terraform"`
+	Hook       *applyLogHook       `json:"hook"`
+	Diagnostic *applyLogDiagnostic `json:"diagnostic"`
+	Changes    *applyLogChanges    `json:"changes"`
+}
+
+type applyLogHook struct {
+	Resource struct {
+		Addr         string `json:"addr"`
+		ResourceType string `json:"resource_type"`
+	} `json:"resource"`
+	Action         string  `json:"action"`
+	IDValue        string  `json:"id_value"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+type applyLogDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Address  string `json:"address"`
+}
+
+type applyLogChanges struct {
+	Add       int    `json:"add"`
+	Change    int    `json:"change"`
+	Import    int    `json:"import"`
+	Remove    int    `json:"remove"`
+	Operation string `json:"operation"`
+}
+
+// ApplyLogParser handles parsing of `terraform apply -json` output
+type ApplyLogParser struct {
+	logFile string
+}
+
+// NewApplyLogParser creates a new apply log parser instance. A log file of "-" reads from stdin.
+func NewApplyLogParser(logFile string) *ApplyLogParser {
+	return &ApplyLogParser{
+		logFile: logFile,
+	}
+}
+
+// LoadApplyLog loads and parses the apply log
+func (p *ApplyLogParser) LoadApplyLog() (*ApplyLog, error) {
+	if p.logFile == "-" {
+		return ParseApplyLog(os.Stdin)
+	}
+
+	file, err := os.Open(p.logFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("apply log does not exist: %s", p.logFile)
+		}
+		return nil, fmt.Errorf("failed to open apply log: %w", err)
+	}
+	defer file.Close()
+
+	return ParseApplyLog(file)
+}
+
+// ParseApplyLog parses the JSON lines written by `terraform apply -json`.
+// Lines that aren't JSON, such as output from wrapper scripts, are skipped.
+func ParseApplyLog(r io.Reader) (*ApplyLog, error) {
+	log := &ApplyLog{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxApplyLogLineSize)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		var message applyLogMessage
+		if err := json.Unmarshal(line, &message); err != nil {
+			return nil, fmt.Errorf("failed to parse apply log line %d: %w", lineNumber, err)
+		}
+		if message.Type == "version" {
+			log.TerraformVersion = message.Terraform
+		}
+		log.messages = append(log.messages, message)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read apply log: %w", err)
+	}
+	if len(log.messages) == 0 {
+		return nil, fmt.Errorf("no machine-readable messages found: expected the output of terraform apply -json")
+	}
+
+	return log, nil
+}
+
+// AnalyzeApply summarises an apply log with the result and duration of every resource operation
+func (a *Analyzer) AnalyzeApply(log *ApplyLog, logFile string) *ApplySummary {
+	summary := &ApplySummary{
+		LogFile:     logFile,
+		Resources:   []ApplyResourceResult{},
+		Diagnostics: []ApplyDiagnostic{},
+	}
+	if log == nil {
+		return summary
+	}
+	summary.TerraformVersion = log.TerraformVersion
+
+	// Replacements report a delete and a create for the same address, so operations are keyed by both
+	type operationKey struct{ address, action string }
+	operations := map[operationKey]int{}
+	operation := func(hook *applyLogHook) *ApplyResourceResult {
+		key := operationKey{hook.Resource.Addr, hook.Action}
+		if idx, ok := operations[key]; ok {
+			return &summary.Resources[idx]
+		}
+		operations[key] = len(summary.Resources)
+		summary.Resources = append(summary.Resources, ApplyResourceResult{
+			Address: hook.Resource.Addr,
+			Type:    hook.Resource.ResourceType,
+			Action:  hook.Action,
+			Status:  applyStatusIncomplete,
+		})
+		return &summary.Resources[len(summary.Resources)-1]
+	}
+
+	var first, last time.Time
+	for _, message := range log.messages {
+		if timestamp, err := time.Parse(time.RFC3339Nano, message.Timestamp); err == nil {
+			if first.IsZero() || timestamp.Before(first) {
+				first = timestamp
+			}
+			if timestamp.After(last) {
+				last = timestamp
+			}
+		}
+
+		switch message.Type {
+		case "apply_start", "apply_progress":
+			if message.Hook != nil {
+				result := operation(message.Hook)
+				result.DurationSeconds = max(result.DurationSeconds, message.Hook.ElapsedSeconds)
+			}
+		case "apply_complete":
+			if message.Hook != nil {
+				result := operation(message.Hook)
+				result.Status = applyStatusApplied
+				result.DurationSeconds = message.Hook.ElapsedSeconds
+				result.ID = message.Hook.IDValue
+			}
+		case "apply_errored":
+			if message.Hook != nil {
+				result := operation(message.Hook)
+				result.Status = applyStatusFailed
+				result.DurationSeconds = message.Hook.ElapsedSeconds
+			}
+		case "diagnostic":
+			if message.Diagnostic != nil {
+				summary.Diagnostics = append(summary.Diagnostics, ApplyDiagnostic{
+					Severity: message.Diagnostic.Severity,
+					Summary:  message.Diagnostic.Summary,
+					Detail:   message.Diagnostic.Detail,
+					Address:  message.Diagnostic.Address,
+				})
+			}
+		case "change_summary":
+			if message.Changes != nil {
+				summary.Completed = true
+				summary.Operation = message.Changes.Operation
+				summary.Statistics.Added = message.Changes.Add
+				summary.Statistics.Changed = message.Changes.Change
+				summary.Statistics.Removed = message.Changes.Remove
+				summary.Statistics.Imported = message.Changes.Import
+			}
+		}
+	}
+
+	// Diagnostics are usually reported after the operations have finished, so attach them at the end.
+	// A failed operation is preferred when an address has several.
+	for _, diagnostic := range summary.Diagnostics {
+		if diagnostic.Address == "" {
+			continue
+		}
+		target := -1
+		for i, result := range summary.Resources {
+			if result.Address == diagnostic.Address && (target == -1 || result.Status == applyStatusFailed) {
+				target = i
+			}
+		}
+		if target != -1 {
+			summary.Resources[target].Diagnostics = append(summary.Resources[target].Diagnostics, diagnostic)
+		}
+	}
+
+	for _, result := range summary.Resources {
+		switch result.Status {
+		case applyStatusApplied:
+			summary.Statistics.Succeeded++
+			// Terraform only reports the change summary when the apply succeeds,
+			// so otherwise the totals are counted the same way from the completed operations
+			if !summary.Completed {
+				switch result.Action {
+				case string(ChangeTypeCreate):
+					summary.Statistics.Added++
+				case string(ChangeTypeUpdate):
+					summary.Statistics.Changed++
+				case string(ChangeTypeDelete):
+					summary.Statistics.Removed++
+				}
+			}
+		case applyStatusFailed:
+			summary.Statistics.Failed++
+		default:
+			summary.Statistics.Incomplete++
+		}
+	}
+	for _, diagnostic := range summary.Diagnostics {
+		switch diagnostic.Severity {
+		case "error":
+			summary.Statistics.Errors++
+		case "warning":
+			summary.Statistics.Warnings++
+		}
+	}
+	if !first.IsZero() {
+		summary.Statistics.DurationSeconds = last.Sub(first).Seconds()
+	}
+
+	return summary
+}
+
+// ReconcileApply compares an apply summary with the plan it was expected to carry out,
+// recording planned changes that weren't applied and changes that weren't planned
+func ReconcileApply(summary *ApplySummary, planned *PlanSummary) {
+	if summary == nil || planned == nil {
+		return
+	}
+
+	statuses := map[string][]string{}
+	for _, result := range summary.Resources {
+		statuses[result.Address] = append(statuses[result.Address], result.Status)
+	}
+
+	reconciliation := &ApplyReconciliation{
+		PlanFile:   planned.PlanFile,
+		NotApplied: []ApplyPlannedChange{},
+		Unplanned:  []string{},
+	}
+	plannedAddresses := map[string]bool{}
+	for _, change := range planned.ResourceChanges {
+		if change.ChangeType == ChangeTypeNoOp {
+			continue
+		}
+		plannedAddresses[change.Address] = true
+		reconciliation.Planned++
+
+		status := plannedChangeStatus(statuses[change.Address])
+		if status == applyStatusApplied {
+			reconciliation.Applied++
+			continue
+		}
+		reconciliation.NotApplied = append(reconciliation.NotApplied, ApplyPlannedChange{
+			Address:    change.Address,
+			ChangeType: change.ChangeType,
+			Status:     status,
+		})
+	}
+
+	seen := map[string]bool{}
+	for _, result := range summary.Resources {
+		// Data sources can be read during apply without a planned change
+		if result.Action == applyActionRead || plannedAddresses[result.Address] || seen[result.Address] {
+			continue
+		}
+		seen[result.Address] = true
+		reconciliation.Unplanned = append(reconciliation.Unplanned, result.Address)
+	}
+
+	summary.Reconciliation = reconciliation
+}
+
+// plannedChangeStatus combines the statuses of the operations for a single address,
+// as a replacement is only applied when both its delete and create completed
+func plannedChangeStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return applyStatusNotStarted
+	}
+	status := applyStatusApplied
+	for _, s := range statuses {
+		switch s {
+		case applyStatusFailed:
+			return applyStatusFailed
+		case applyStatusIncomplete:
+			status = applyStatusIncomplete
+		}
+	}
+	return status
+}
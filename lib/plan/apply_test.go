@@ -0,0 +1,182 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testApplyLogFile = "testdata/apply/apply-errored.jsonl"
+
+const testSuccessfulApplyLog = `Running terraform apply...
+{"@level":"info","@timestamp":"2025-06-01T09:00:00.000000Z","terraform":"1.9.5","type":"version","ui":"1.2"}
+{"@level":"info","@timestamp":"2025-06-01T09:00:01.000000Z","hook":{"resource":{"addr":"local_file.test_files[0]","resource_type":"local_file"},"action":"create"},"type":"apply_start"}
+{"@level":"info","@timestamp":"2025-06-01T09:00:01.500000Z","hook":{"resource":{"addr":"local_file.test_files[0]","resource_type":"local_file"},"action":"create","id_key":"id","id_value":"abc123","elapsed_seconds":0},"type":"apply_complete"}
+{"@level":"info","@timestamp":"2025-06-01T09:00:02.000000Z","changes":{"add":1,"change":0,"import":0,"remove":0,"operation":"apply"},"type":"change_summary"}
+`
+
+func loadTestApplySummary(t *testing.T) *ApplySummary {
+	t.Helper()
+	log, err := NewApplyLogParser(testApplyLogFile).LoadApplyLog()
+	require.NoError(t, err)
+	return NewAnalyzer(nil, config.GetDefaultConfig()).AnalyzeApply(log, testApplyLogFile)
+}
+
+func TestParseApplyLog(t *testing.T) {
+	t.Run("skips lines that aren't JSON", func(t *testing.T) {
+		log, err := ParseApplyLog(strings.NewReader(testSuccessfulApplyLog))
+		require.NoError(t, err)
+		assert.Equal(t, "1.9.5", log.TerraformVersion)
+		assert.Len(t, log.messages, 4)
+	})
+
+	t.Run("malformed JSON", func(t *testing.T) {
+		_, err := ParseApplyLog(strings.NewReader("{\"type\": \"version\"}\n{\"type\": "))
+		assert.ErrorContains(t, err, "failed to parse apply log line 2")
+	})
+
+	t.Run("no messages", func(t *testing.T) {
+		_, err := ParseApplyLog(strings.NewReader("Apply complete!\n"))
+		assert.ErrorContains(t, err, "no machine-readable messages found")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewApplyLogParser(filepath.Join(t.TempDir(), "apply.log")).LoadApplyLog()
+		assert.ErrorContains(t, err, "apply log does not exist")
+	})
+}
+
+func TestAnalyzer_AnalyzeSuccessfulApply(t *testing.T) {
+	log, err := ParseApplyLog(strings.NewReader(testSuccessfulApplyLog))
+	require.NoError(t, err)
+
+	summary := NewAnalyzer(nil, config.GetDefaultConfig()).AnalyzeApply(log, "apply.log")
+
+	assert.True(t, summary.Completed)
+	assert.Equal(t, "apply", summary.Operation)
+	assert.Equal(t, ApplyStatistics{Added: 1, Succeeded: 1, DurationSeconds: 2}, summary.Statistics)
+	assert.Equal(t, []ApplyResourceResult{{
+		Address: "local_file.test_files[0]",
+		Type:    "local_file",
+		Action:  "create",
+		Status:  applyStatusApplied,
+		ID:      "abc123",
+	}}, summary.Resources)
+}
+
+func TestAnalyzer_AnalyzeErroredApply(t *testing.T) {
+	summary := loadTestApplySummary(t)
+
+	assert.False(t, summary.Completed)
+	assert.Equal(t, ApplyStatistics{
+		Added:           2,
+		Removed:         1,
+		Succeeded:       4,
+		Failed:          1,
+		Errors:          1,
+		Warnings:        1,
+		DurationSeconds: 240,
+	}, summary.Statistics)
+
+	require.Len(t, summary.Resources, 5)
+	replacementDelete := summary.Resources[1]
+	assert.Equal(t, "aws_db_instance.main", replacementDelete.Address)
+	assert.Equal(t, "delete", replacementDelete.Action)
+	assert.Equal(t, float64(45), replacementDelete.DurationSeconds)
+	replacementCreate := summary.Resources[4]
+	assert.Equal(t, "create", replacementCreate.Action)
+	assert.Equal(t, float64(192), replacementCreate.DurationSeconds)
+
+	failed := summary.Resources[2]
+	assert.Equal(t, "aws_instance.web_server", failed.Address)
+	assert.Equal(t, applyStatusFailed, failed.Status)
+	require.Len(t, failed.Diagnostics, 1)
+	assert.Equal(t, "The instance type cannot be changed while the instance is running.", failed.Diagnostics[0].Detail)
+
+	require.Len(t, summary.Diagnostics, 2)
+	assert.Empty(t, summary.Diagnostics[0].Address)
+}
+
+func TestAnalyzer_AnalyzeInterruptedApply(t *testing.T) {
+	log, err := ParseApplyLog(strings.NewReader(
+		`{"@timestamp":"2025-06-01T09:00:00Z","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"update"},"type":"apply_start"}` + "\n" +
+			`{"@timestamp":"2025-06-01T09:00:10Z","hook":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"update","elapsed_seconds":10},"type":"apply_progress"}`))
+	require.NoError(t, err)
+
+	summary := NewAnalyzer(nil, config.GetDefaultConfig()).AnalyzeApply(log, "apply.log")
+	require.Len(t, summary.Resources, 1)
+	assert.Equal(t, applyStatusIncomplete, summary.Resources[0].Status)
+	assert.Equal(t, float64(10), summary.Resources[0].DurationSeconds)
+	assert.Equal(t, 1, summary.Statistics.Incomplete)
+}
+
+func TestReconcileApply(t *testing.T) {
+	summary := loadTestApplySummary(t)
+	// A change that happened during apply but wasn't part of the plan
+	summary.Resources = append(summary.Resources, ApplyResourceResult{
+		Address: "aws_s3_bucket.extra", Action: "create", Status: applyStatusApplied,
+	})
+
+	ReconcileApply(summary, &PlanSummary{
+		PlanFile: "danger.tfplan",
+		ResourceChanges: []ResourceChange{
+			{Address: "aws_db_instance.main", ChangeType: ChangeTypeReplace},
+			{Address: "aws_iam_instance_profile.app_profile", ChangeType: ChangeTypeCreate},
+			{Address: "aws_instance.web_server", ChangeType: ChangeTypeUpdate},
+			{Address: "aws_security_group.web_sg", ChangeType: ChangeTypeUpdate},
+			{Address: "aws_vpc.main", ChangeType: ChangeTypeNoOp},
+		},
+	})
+
+	require.NotNil(t, summary.Reconciliation)
+	assert.Equal(t, &ApplyReconciliation{
+		PlanFile: "danger.tfplan",
+		Planned:  4,
+		Applied:  2,
+		NotApplied: []ApplyPlannedChange{
+			{Address: "aws_instance.web_server", ChangeType: ChangeTypeUpdate, Status: applyStatusFailed},
+			{Address: "aws_security_group.web_sg", ChangeType: ChangeTypeUpdate, Status: applyStatusNotStarted},
+		},
+		Unplanned: []string{"aws_s3_bucket.extra"},
+	}, summary.Reconciliation)
+}
+
+func TestPlannedChangeStatus(t *testing.T) {
+	assert.Equal(t, applyStatusNotStarted, plannedChangeStatus(nil))
+	assert.Equal(t, applyStatusApplied, plannedChangeStatus([]string{applyStatusApplied, applyStatusApplied}))
+	assert.Equal(t, applyStatusIncomplete, plannedChangeStatus([]string{applyStatusApplied, applyStatusIncomplete}))
+	assert.Equal(t, applyStatusFailed, plannedChangeStatus([]string{applyStatusIncomplete, applyStatusFailed}))
+}
+
+func TestFormatter_OutputApplySummaryToFile(t *testing.T) {
+	summary := loadTestApplySummary(t)
+	ReconcileApply(summary, &PlanSummary{ResourceChanges: []ResourceChange{
+		{Address: "aws_security_group.web_sg", ChangeType: ChangeTypeUpdate},
+	}})
+
+	outputFile := filepath.Join(t.TempDir(), "apply.md")
+	err := NewFormatter(config.GetDefaultConfig()).OutputApplySummary(summary, &config.OutputConfiguration{
+		Format:           "json",
+		OutputFile:       outputFile,
+		OutputFileFormat: formatMarkdown,
+	})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "| Failed | 4m0s |")
+	assert.Contains(t, string(content), "| aws\\_db\\_instance.main | create | applied | 3m12s | db-main |")
+	assert.Contains(t, string(content), "### Diagnostics")
+	assert.Contains(t, string(content), "| aws\\_security\\_group.web\\_sg | update | not started |")
+}
+
+func TestFormatApplyDuration(t *testing.T) {
+	assert.Equal(t, "0s", formatApplyDuration(0))
+	assert.Equal(t, "1.5s", formatApplyDuration(1.5))
+	assert.Equal(t, "3m12s", formatApplyDuration(192))
+}
@@ -0,0 +1,306 @@
+package plan
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// approvalHashPrefix identifies the algorithm used for approval hashes
+	approvalHashPrefix = "sha256:"
+
+	// approvalSignatureContext is prepended to signed approvals so signatures can't be reused for other data
+	approvalSignatureContext = "strata-plan-approval-v1"
+)
+
+// canonicalResourceChange holds the fields of an analysed resource change that define what will be applied.
+// Display-only fields such as danger reasons are left out, so they can change without invalidating approvals.
+type canonicalResourceChange struct {
+	Address           string            `json:"address"`
+	Type              string            `json:"type"`
+	ChangeType        ChangeType        `json:"change_type"`
+	ReplacementType   ReplacementType   `json:"replacement_type"`
+	Before            any               `json:"before"`
+	After             any               `json:"after"`
+	UnknownProperties []string          `json:"unknown_properties"`
+	SensitiveChanges  map[string]string `json:"sensitive_changes"`
+	SensitiveDigest   string            `json:"sensitive_digest,omitempty"`
+}
+
+// ErrMissingHashSalt is returned when a plan with sensitive values is approved or verified without a hash salt
+var ErrMissingHashSalt = errors.New("approving sensitive values requires a hash salt (plan.sensitive_values.hash_salt or STRATA_HASH_SALT)")
+
+// NewApproval creates an approval for the resource changes in a plan summary
+func NewApproval(summary *PlanSummary, approver string, approvedAt time.Time) (*Approval, error) {
+	changes, err := ApprovedChanges(summary)
+	if err != nil {
+		return nil, err
+	}
+	return &Approval{
+		PlanFile:   summary.PlanFile,
+		Hash:       approvalSetHash(changes),
+		Approver:   approver,
+		ApprovedAt: approvedAt.UTC(),
+		Changes:    changes,
+	}, nil
+}
+
+// ApprovedChanges returns the canonical hash of every resource change in a plan summary, sorted by address.
+// No-op resources aren't changed by an apply, so they aren't included. Sensitive values are only covered
+// by a keyed digest, so changes with sensitive values can't be approved without a hash salt.
+func ApprovedChanges(summary *PlanSummary) ([]ApprovedChange, error) {
+	changes := []ApprovedChange{}
+	for _, change := range summary.ResourceChanges {
+		if change.ChangeType == ChangeTypeNoOp || change.IsNoOp {
+			continue
+		}
+		if change.HasSensitiveValues && change.SensitiveDigest == "" {
+			return nil, fmt.Errorf("%s has sensitive values: %w", change.Address, ErrMissingHashSalt)
+		}
+		hash, err := resourceChangeHash(change)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", change.Address, err)
+		}
+		changes = append(changes, ApprovedChange{Address: change.Address, ChangeType: change.ChangeType, Hash: hash})
+	}
+	slices.SortFunc(changes, func(a, b ApprovedChange) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return changes, nil
+}
+
+// resourceChangeHash computes the canonical hash of a single resource change. JSON encoding sorts map
+// keys, so the encoding only depends on the values. Sensitive values are masked by the analyzer, so
+// they are covered by the analyzer's keyed digest of the real values, and any change to them
+// invalidates the approval.
+func resourceChangeHash(change ResourceChange) (string, error) {
+	canonical := canonicalResourceChange{
+		Address:           change.Address,
+		Type:              change.Type,
+		ChangeType:        change.ChangeType,
+		ReplacementType:   change.ReplacementType,
+		Before:            change.Before,
+		After:             change.After,
+		UnknownProperties: slices.Sorted(slices.Values(change.UnknownProperties)),
+		SensitiveChanges:  map[string]string{},
+		SensitiveDigest:   change.SensitiveDigest,
+	}
+	for _, property := range change.PropertyChanges.Changes {
+		if property.Sensitive {
+			canonical.SensitiveChanges[propertyChangeKey(property)] = property.Action + " " + property.SensitiveStatus
+		}
+	}
+
+	data, err := json.Marshal(canonical)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return approvalHashPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// approvalSetHash combines the hashes of the approved changes into a single hash
+func approvalSetHash(changes []ApprovedChange) string {
+	hash := sha256.New()
+	for _, change := range changes {
+		fmt.Fprintf(hash, "%s\n%s\n", change.Address, change.Hash)
+	}
+	return approvalHashPrefix + hex.EncodeToString(hash.Sum(nil))
+}
+
+// signedPayload returns the data covered by the approval signature
+func (a *Approval) signedPayload() []byte {
+	return []byte(strings.Join([]string{
+		approvalSignatureContext,
+		a.PlanFile,
+		a.Hash,
+		a.Approver,
+		a.ApprovedAt.UTC().Format(time.RFC3339Nano),
+	}, "\n"))
+}
+
+// Sign signs the approval with an ed25519 private key and records the matching public key
+func (a *Approval) Sign(key ed25519.PrivateKey) {
+	public, _ := key.Public().(ed25519.PublicKey)
+	a.PublicKey = base64.StdEncoding.EncodeToString(public)
+	a.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, a.signedPayload()))
+}
+
+// VerifySignature checks the approval signature. When trusted is set, the approval must be signed
+// with that key; otherwise the signature is only checked against the public key in the approval.
+func (a *Approval) VerifySignature(trusted ed25519.PublicKey) error {
+	if a.Signature == "" {
+		if trusted != nil {
+			return fmt.Errorf("approval is not signed")
+		}
+		return nil
+	}
+
+	public, err := base64.StdEncoding.DecodeString(a.PublicKey)
+	if err != nil || len(public) != ed25519.PublicKeySize {
+		return fmt.Errorf("approval has an invalid public key")
+	}
+	if trusted != nil && !bytes.Equal(public, trusted) {
+		return fmt.Errorf("approval was signed with a different key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(a.Signature)
+	if err != nil {
+		return fmt.Errorf("approval has an invalid signature: %w", err)
+	}
+	if !ed25519.Verify(public, a.signedPayload(), signature) {
+		return fmt.Errorf("approval signature is invalid, the approval may have been modified")
+	}
+	return nil
+}
+
+// Verify compares the resource changes of a plan summary with the approval.
+// It returns an *ApprovalMismatch error when they differ.
+func (a *Approval) Verify(summary *PlanSummary) error {
+	changes, err := ApprovedChanges(summary)
+	if err != nil {
+		return err
+	}
+	if approvalSetHash(a.Changes) != a.Hash {
+		return fmt.Errorf("approval hash doesn't match its changes, the approval may have been modified")
+	}
+	if approvalSetHash(changes) == a.Hash {
+		return nil
+	}
+
+	approved := map[string]ApprovedChange{}
+	for _, change := range a.Changes {
+		approved[change.Address] = change
+	}
+	mismatch := &ApprovalMismatch{}
+	for _, change := range changes {
+		earlier, ok := approved[change.Address]
+		switch {
+		case !ok:
+			mismatch.Added = append(mismatch.Added, change)
+		case earlier.Hash != change.Hash:
+			mismatch.Changed = append(mismatch.Changed, change)
+		}
+		delete(approved, change.Address)
+	}
+	for _, change := range a.Changes {
+		if _, ok := approved[change.Address]; ok {
+			mismatch.Removed = append(mismatch.Removed, change)
+		}
+	}
+	return mismatch
+}
+
+// Error lists the resource changes that differ from the approval
+func (m *ApprovalMismatch) Error() string {
+	var parts []string
+	describe := func(label string, changes []ApprovedChange) {
+		if len(changes) == 0 {
+			return
+		}
+		addresses := make([]string, 0, len(changes))
+		for _, change := range changes {
+			addresses = append(addresses, fmt.Sprintf("%s (%s)", change.Address, change.ChangeType))
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", label, strings.Join(addresses, ", ")))
+	}
+	describe("not approved", m.Added)
+	describe("approved but not planned", m.Removed)
+	describe("changed since approval", m.Changed)
+	return "plan doesn't match the approval: " + strings.Join(parts, "; ")
+}
+
+// SaveApproval writes an approval record as JSON
+func SaveApproval(approval *Approval, path string) error {
+	data, err := json.MarshalIndent(approval, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approval: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write approval: %w", err)
+	}
+	return nil
+}
+
+// LoadApproval reads an approval record
+func LoadApproval(path string) (*Approval, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("approval does not exist: %s", path)
+		}
+		return nil, fmt.Errorf("failed to read approval: %w", err)
+	}
+	var approval Approval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return nil, fmt.Errorf("failed to parse approval: %w", err)
+	}
+	if !strings.HasPrefix(approval.Hash, approvalHashPrefix) {
+		return nil, fmt.Errorf("approval has no %s hash", strings.TrimSuffix(approvalHashPrefix, ":"))
+	}
+	return &approval, nil
+}
+
+// LoadSigningKey reads a PEM encoded PKCS #8 ed25519 private key, as created by
+// `openssl genpkey -algorithm ed25519`
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMBlock(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	signingKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an ed25519 key")
+	}
+	return signingKey, nil
+}
+
+// LoadPublicKey reads a PEM encoded PKIX ed25519 public key, as created by `openssl pkey -pubout`
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMBlock(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an ed25519 key")
+	}
+	return publicKey, nil
+}
+
+// readPEMBlock reads the first PEM block of the given type from a file
+func readPEMBlock(path, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no %s found in %s", blockType, path)
+		}
+		if block.Type == blockType {
+			return block, nil
+		}
+	}
+}
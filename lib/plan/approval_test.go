@@ -0,0 +1,212 @@
+package plan
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func approvalTestSummary() *PlanSummary {
+	return &PlanSummary{
+		PlanFile: "terraform.tfplan",
+		ResourceChanges: []ResourceChange{
+			{
+				Address:    "aws_s3_bucket.logs",
+				Type:       "aws_s3_bucket",
+				ChangeType: ChangeTypeUpdate,
+				Before:     map[string]any{"acl": "private", "tags": map[string]any{"Team": "ops"}},
+				After:      map[string]any{"acl": "private", "tags": map[string]any{"Team": "platform"}},
+			},
+			{
+				Address:    "aws_db_instance.main",
+				Type:       "aws_db_instance",
+				ChangeType: ChangeTypeUpdate,
+				PropertyChanges: PropertyChangeAnalysis{Changes: []PropertyChange{
+					{Name: "password", Path: []string{"password"}, Action: actionUpdate, Sensitive: true, SensitiveStatus: "changed"},
+				}},
+			},
+			{Address: "aws_vpc.main", Type: "aws_vpc", ChangeType: ChangeTypeNoOp, IsNoOp: true},
+		},
+	}
+}
+
+func TestNewApproval(t *testing.T) {
+	approvedAt := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	approval, err := NewApproval(approvalTestSummary(), "jane", approvedAt)
+	require.NoError(t, err)
+
+	assert.Equal(t, "jane", approval.Approver)
+	assert.Equal(t, approvedAt, approval.ApprovedAt)
+	require.Len(t, approval.Changes, 2, "no-op resources aren't approved")
+	assert.Equal(t, "aws_db_instance.main", approval.Changes[0].Address)
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, approval.Hash)
+
+	t.Run("hash doesn't depend on order or display fields", func(t *testing.T) {
+		summary := approvalTestSummary()
+		summary.ResourceChanges[0], summary.ResourceChanges[1] = summary.ResourceChanges[1], summary.ResourceChanges[0]
+		summary.ResourceChanges[0].IsDangerous = true
+		summary.ResourceChanges[0].DangerReason = "Sensitive resource"
+		other, err := NewApproval(summary, "john", approvedAt.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, approval.Hash, other.Hash)
+	})
+}
+
+func TestApproval_Verify(t *testing.T) {
+	approval, err := NewApproval(approvalTestSummary(), "jane", time.Now())
+	require.NoError(t, err)
+
+	t.Run("matching plan", func(t *testing.T) {
+		assert.NoError(t, approval.Verify(approvalTestSummary()))
+	})
+
+	t.Run("changed values", func(t *testing.T) {
+		summary := approvalTestSummary()
+		summary.ResourceChanges[0].After = map[string]any{"acl": "public-read", "tags": map[string]any{"Team": "platform"}}
+		summary.ResourceChanges[1].PropertyChanges.Changes[0].SensitiveStatus = "unchanged"
+		summary.ResourceChanges = append(summary.ResourceChanges, ResourceChange{Address: "aws_instance.web", ChangeType: ChangeTypeCreate})
+
+		err := approval.Verify(summary)
+		var mismatch *ApprovalMismatch
+		require.ErrorAs(t, err, &mismatch)
+		assert.Len(t, mismatch.Changed, 2)
+		assert.Len(t, mismatch.Added, 1)
+		assert.Empty(t, mismatch.Removed)
+		assert.ErrorContains(t, err, "not approved: aws_instance.web (create)")
+	})
+
+	t.Run("missing change", func(t *testing.T) {
+		summary := approvalTestSummary()
+		summary.ResourceChanges = summary.ResourceChanges[1:]
+
+		err := approval.Verify(summary)
+		assert.ErrorContains(t, err, "approved but not planned: aws_s3_bucket.logs (update)")
+	})
+
+	t.Run("modified approval", func(t *testing.T) {
+		modified := *approval
+		modified.Changes = modified.Changes[:1]
+		assert.ErrorContains(t, modified.Verify(approvalTestSummary()), "approval may have been modified")
+	})
+}
+
+func TestApproval_Verify_SensitiveValues(t *testing.T) {
+	// Both plans change the password, so the masked values and sensitive status are the same
+	sensitivePlan := func(password string) *tfjson.Plan {
+		return &tfjson.Plan{
+			FormatVersion: "1.2",
+			ResourceChanges: []*tfjson.ResourceChange{{
+				Address: "aws_db_instance.main",
+				Type:    "aws_db_instance",
+				Name:    "main",
+				Change: &tfjson.Change{
+					Actions:         tfjson.Actions{tfjson.ActionUpdate},
+					Before:          map[string]any{"password": "old-password", "port": float64(5432)},
+					After:           map[string]any{"password": password, "port": float64(5432)},
+					BeforeSensitive: map[string]any{"password": true},
+					AfterSensitive:  map[string]any{"password": true},
+				},
+			}},
+		}
+	}
+	summarize := func(password, salt string) *PlanSummary {
+		cfg := config.GetDefaultConfig()
+		cfg.Plan.SensitiveValues.HashSalt = salt
+		return NewAnalyzer(sensitivePlan(password), cfg).SummarizePlan("terraform.tfplan")
+	}
+
+	approved := summarize("approved-password", "approval-salt-0123")
+	approval, err := NewApproval(approved, "jane", time.Now())
+	require.NoError(t, err)
+	assert.NoError(t, approval.Verify(summarize("approved-password", "approval-salt-0123")))
+
+	swapped := summarize("swapped-password", "approval-salt-0123")
+	assert.Equal(t, approved.ResourceChanges[0].After, swapped.ResourceChanges[0].After, "the values are masked")
+	var mismatch *ApprovalMismatch
+	require.ErrorAs(t, approval.Verify(swapped), &mismatch)
+	assert.Len(t, mismatch.Changed, 1)
+
+	// The digest is keyed with the salt
+	assert.Error(t, approval.Verify(summarize("approved-password", "another-salt-4567")))
+
+	// Without a salt the sensitive values can't be covered, so approval and verification are refused
+	t.Setenv("STRATA_HASH_SALT", "")
+	unsalted := summarize("approved-password", "")
+	assert.Empty(t, unsalted.ResourceChanges[0].SensitiveDigest)
+	_, err = NewApproval(unsalted, "jane", time.Now())
+	require.ErrorIs(t, err, ErrMissingHashSalt)
+	assert.ErrorContains(t, err, "aws_db_instance.main has sensitive values")
+	assert.ErrorIs(t, approval.Verify(unsalted), ErrMissingHashSalt)
+
+	// Plans without sensitive values don't need a salt
+	plain := sensitivePlan("approved-password")
+	plain.ResourceChanges[0].Change.BeforeSensitive = map[string]any{}
+	plain.ResourceChanges[0].Change.AfterSensitive = map[string]any{}
+	_, err = NewApproval(NewAnalyzer(plain, config.GetDefaultConfig()).SummarizePlan("terraform.tfplan"), "jane", time.Now())
+	assert.NoError(t, err)
+}
+
+func TestApproval_Signature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherPublic, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	approval, err := NewApproval(approvalTestSummary(), "jane", time.Now())
+	require.NoError(t, err)
+	assert.NoError(t, approval.VerifySignature(nil), "unsigned approvals are accepted without a trusted key")
+	assert.ErrorContains(t, approval.VerifySignature(public), "approval is not signed")
+
+	approval.Sign(private)
+	assert.NoError(t, approval.VerifySignature(nil))
+	assert.NoError(t, approval.VerifySignature(public))
+	assert.ErrorContains(t, approval.VerifySignature(otherPublic), "signed with a different key")
+
+	approval.Approver = "mallory"
+	assert.ErrorContains(t, approval.VerifySignature(public), "signature is invalid")
+}
+
+func TestApprovalFiles(t *testing.T) {
+	dir := t.TempDir()
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "approval.key")
+	pubFile := filepath.Join(dir, "approval.pub")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600))
+	require.NoError(t, os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600))
+
+	signingKey, err := LoadSigningKey(keyFile)
+	require.NoError(t, err)
+	trusted, err := LoadPublicKey(pubFile)
+	require.NoError(t, err)
+
+	approval, err := NewApproval(approvalTestSummary(), "jane", time.Now())
+	require.NoError(t, err)
+	approval.Sign(signingKey)
+
+	path := filepath.Join(dir, "terraform.tfplan.approval.json")
+	require.NoError(t, SaveApproval(approval, path))
+	loaded, err := LoadApproval(path)
+	require.NoError(t, err)
+	assert.NoError(t, loaded.VerifySignature(trusted))
+	assert.NoError(t, loaded.Verify(approvalTestSummary()))
+
+	_, err = LoadApproval(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "approval does not exist")
+	_, err = LoadPublicKey(keyFile)
+	assert.ErrorContains(t, err, "no PUBLIC KEY found")
+}
@@ -0,0 +1,273 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// DecodePlan reads the JSON representation of a plan, as created by `terraform show -json`, from a stream.
+// Only the parts of the plan that are summarised are kept, see DecodePlanStream. All resource changes are
+// kept in the returned plan; use DecodePlanStream to process them without holding them all in memory.
+func DecodePlan(r io.Reader) (*tfjson.Plan, error) {
+	var changes []*tfjson.ResourceChange
+	plan, err := DecodePlanStream(r, func(change *tfjson.ResourceChange) error {
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.ResourceChanges = changes
+	return plan, nil
+}
+
+// DecodePlanStream reads the JSON representation of a plan from a stream and passes every resource change
+// to handle as soon as it's decoded, so only the changes that are being handled are held in memory. The
+// returned plan holds the rest of what is summarised: the format and Terraform versions, the output changes,
+// and the references between resources in the configuration, which dependencies are taken from. Other parts
+// such as prior_state, planned_values, resource_drift, and the values in the configuration are skipped token
+// by token. Decoding stops with the error of handle when it fails.
+func DecodePlanStream(r io.Reader, handle func(*tfjson.ResourceChange) error) (*tfjson.Plan, error) {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	plan := &tfjson.Plan{}
+	if err := decodePlanObject(decoder, plan, handle); err != nil {
+		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after the plan")
+		}
+		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	}
+	return plan, nil
+}
+
+// decodePlanObject decodes the top-level plan object, keeping only the fields used for summaries
+func decodePlanObject(decoder *json.Decoder, plan *tfjson.Plan, handle func(*tfjson.ResourceChange) error) error {
+	return decodeObject(decoder, func(key string) error {
+		switch key {
+		case "format_version":
+			return decoder.Decode(&plan.FormatVersion)
+		case "terraform_version":
+			return decoder.Decode(&plan.TerraformVersion)
+		case "resource_changes":
+			return decodeResourceChanges(decoder, handle)
+		case "output_changes":
+			return decoder.Decode(&plan.OutputChanges)
+		case "configuration":
+			config, err := decodeConfiguration(decoder)
+			plan.Config = config
+			return err
+		default:
+			return skipValue(decoder)
+		}
+	})
+}
+
+// decodeObject reads an object and calls field for every key, which must decode or skip the key's value
+func decodeObject(decoder *json.Decoder, field func(key string) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		if err := field(key); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+// decodeArray reads an array and calls item for every element, which must decode or skip the element.
+// A null value is read as an empty array.
+func decodeArray(decoder *json.Decoder, item func(index int) error) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array, got %v", token)
+	}
+	for index := 0; decoder.More(); index++ {
+		if err := item(index); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, ']')
+}
+
+// decodeResourceChanges decodes the resource_changes array one element at a time and hands every
+// change to handle before the next one is read
+func decodeResourceChanges(decoder *json.Decoder, handle func(*tfjson.ResourceChange) error) error {
+	return decodeArray(decoder, func(index int) error {
+		var change tfjson.ResourceChange
+		if err := decoder.Decode(&change); err != nil {
+			return fmt.Errorf("resource change %d: %w", index, err)
+		}
+		return handle(&change)
+	})
+}
+
+// decodeConfiguration decodes the references between resources from the configuration block.
+// Expressions are kept without their constant values, and variables, outputs, and provider
+// configuration are skipped.
+func decodeConfiguration(decoder *json.Decoder) (*tfjson.Config, error) {
+	config := &tfjson.Config{}
+	err := decodeObject(decoder, func(key string) error {
+		if key != "root_module" {
+			return skipValue(decoder)
+		}
+		module, err := decodeConfigModule(decoder)
+		config.RootModule = module
+		return err
+	})
+	return config, err
+}
+
+// decodeConfigModule decodes the resources and module calls of a configuration module, one resource at a time
+func decodeConfigModule(decoder *json.Decoder) (*tfjson.ConfigModule, error) {
+	module := &tfjson.ConfigModule{}
+	err := decodeObject(decoder, func(key string) error {
+		switch key {
+		case "resources":
+			return decodeArray(decoder, func(index int) error {
+				var resource configResourceReferences
+				if err := decoder.Decode(&resource); err != nil {
+					return fmt.Errorf("resource %d: %w", index, err)
+				}
+				module.Resources = append(module.Resources, resource.configResource())
+				return nil
+			})
+		case "module_calls":
+			module.ModuleCalls = map[string]*tfjson.ModuleCall{}
+			return decodeObject(decoder, func(name string) error {
+				call := &tfjson.ModuleCall{}
+				module.ModuleCalls[name] = call
+				return decodeObject(decoder, func(key string) error {
+					if key != "module" {
+						return skipValue(decoder)
+					}
+					child, err := decodeConfigModule(decoder)
+					call.Module = child
+					return err
+				})
+			})
+		default:
+			return skipValue(decoder)
+		}
+	})
+	return module, err
+}
+
+// configResourceReferences is a resource in the configuration with only the expressions that refer to
+// other resources
+type configResourceReferences struct {
+	Address           string                          `json:"address"`
+	Expressions       map[string]*referenceExpression `json:"expressions"`
+	CountExpression   *referenceExpression            `json:"count_expression"`
+	ForEachExpression *referenceExpression            `json:"for_each_expression"`
+	DependsOn         []string                        `json:"depends_on"`
+}
+
+// configResource converts the references of a resource to a configuration resource
+func (resource *configResourceReferences) configResource() *tfjson.ConfigResource {
+	expressions := make(map[string]*tfjson.Expression, len(resource.Expressions))
+	for name, expression := range resource.Expressions {
+		expressions[name] = expression.expression()
+	}
+	return &tfjson.ConfigResource{
+		Address:           resource.Address,
+		Expressions:       expressions,
+		CountExpression:   resource.CountExpression.expression(),
+		ForEachExpression: resource.ForEachExpression.expression(),
+		DependsOn:         resource.DependsOn,
+	}
+}
+
+// referenceExpression is an expression in the configuration without its constant value. Like
+// tfjson.Expression, an array holds the expressions of nested blocks.
+type referenceExpression struct {
+	References   []string
+	NestedBlocks []map[string]*referenceExpression
+}
+
+// UnmarshalJSON decodes the references of an expression or the expressions of nested blocks
+func (expression *referenceExpression) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, &expression.NestedBlocks)
+	}
+	var references struct {
+		References []string `json:"references"`
+	}
+	if err := json.Unmarshal(data, &references); err != nil {
+		return err
+	}
+	expression.References = references.References
+	return nil
+}
+
+// expression converts the references to a configuration expression
+func (expression *referenceExpression) expression() *tfjson.Expression {
+	if expression == nil {
+		return nil
+	}
+	data := &tfjson.ExpressionData{References: expression.References}
+	for _, block := range expression.NestedBlocks {
+		nested := make(map[string]*tfjson.Expression, len(block))
+		for name, child := range block {
+			nested[name] = child.expression()
+		}
+		data.NestedBlocks = append(data.NestedBlocks, nested)
+	}
+	return &tfjson.Expression{ExpressionData: data}
+}
+
+// skipValue reads past the next value without keeping it
+func skipValue(decoder *json.Decoder) error {
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// expectDelim reads the next token, which must be the given delimiter
+func expectDelim(decoder *json.Decoder, expected json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != expected {
+		return fmt.Errorf("expected %q, got %v", expected, token)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePlan(t *testing.T) {
+	for _, name := range []string{"simple_plan.json", "dependencies_plan.json", "output_refinements_plan.json"} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("../../testdata", name))
+			require.NoError(t, err)
+			var expected tfjson.Plan
+			require.NoError(t, json.Unmarshal(data, &expected))
+
+			file, err := os.Open(filepath.Join("../../testdata", name))
+			require.NoError(t, err)
+			defer file.Close()
+			decoded, err := DecodePlan(file)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected.FormatVersion, decoded.FormatVersion)
+			assert.Equal(t, expected.TerraformVersion, decoded.TerraformVersion)
+			assert.Equal(t, expected.ResourceChanges, decoded.ResourceChanges)
+			assert.Equal(t, expected.OutputChanges, decoded.OutputChanges)
+			// Only the references between resources are kept from the configuration
+			assert.Equal(t, configReferences(expected.Config), configReferences(decoded.Config))
+			// Blocks that aren't summarised are skipped
+			assert.Nil(t, decoded.PriorState)
+			assert.Nil(t, decoded.PlannedValues)
+		})
+	}
+}
+
+func TestDecodePlanStream(t *testing.T) {
+	file, err := os.Open("../../testdata/dependencies_plan.json")
+	require.NoError(t, err)
+	defer file.Close()
+
+	var addresses []string
+	decoded, err := DecodePlanStream(file, func(change *tfjson.ResourceChange) error {
+		addresses = append(addresses, change.Address)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, addresses)
+	assert.Empty(t, decoded.ResourceChanges, "resource changes are handed over instead of kept")
+	assert.Equal(t, "1.2", decoded.FormatVersion)
+
+	// Decoding stops at the first change that can't be handled
+	_, err = file.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	handled := 0
+	_, err = DecodePlanStream(file, func(*tfjson.ResourceChange) error {
+		handled++
+		return errors.New("handler failed")
+	})
+	assert.ErrorContains(t, err, "handler failed")
+	assert.Equal(t, 1, handled)
+}
+
+func TestDecodePlan_ConfigurationReferences(t *testing.T) {
+	input := `{
+		"format_version": "1.2",
+		"configuration": {
+			"provider_config": {"aws": {"name": "aws", "expressions": {"region": {"constant_value": "eu-west-1"}}}},
+			"root_module": {
+				"outputs": {"id": {"expression": {"references": ["aws_vpc.main.id"]}}},
+				"resources": [{
+					"address": "aws_subnet.main",
+					"expressions": {
+						"cidr_block": {"constant_value": "10.0.1.0/24"},
+						"vpc_id": {"references": ["aws_vpc.main.id", "aws_vpc.main"]},
+						"timeouts": [{"create": {"references": ["var.timeout"]}}]
+					},
+					"count_expression": {"references": ["var.count"]},
+					"depends_on": ["aws_internet_gateway.main"]
+				}],
+				"module_calls": {"network": {"source": "./network", "module": {"resources": [{"address": "aws_vpc.inner"}]}}}
+			}
+		}
+	}`
+
+	plan, err := DecodePlan(strings.NewReader(input))
+	require.NoError(t, err)
+	root := plan.Config.RootModule
+	require.Len(t, root.Resources, 1)
+	resource := root.Resources[0]
+	assert.Equal(t, "aws_subnet.main", resource.Address)
+	assert.Equal(t, []string{"aws_vpc.main.id", "aws_vpc.main"}, resource.Expressions["vpc_id"].References)
+	assert.Nil(t, resource.Expressions["cidr_block"].ConstantValue, "constant values aren't kept")
+	assert.Equal(t, []string{"var.timeout"}, resource.Expressions["timeouts"].NestedBlocks[0]["create"].References)
+	assert.Equal(t, []string{"var.count"}, resource.CountExpression.References)
+	assert.Equal(t, []string{"aws_internet_gateway.main"}, resource.DependsOn)
+	assert.Empty(t, root.Outputs)
+	assert.Empty(t, plan.Config.ProviderConfigs)
+	assert.Equal(t, "aws_vpc.inner", root.ModuleCalls["network"].Module.Resources[0].Address)
+}
+
+// configReferences returns the references of every resource in a configuration, keyed by configuration address
+func configReferences(config *tfjson.Config) map[string][]string {
+	references := map[string][]string{}
+	if config != nil && config.RootModule != nil {
+		collectModuleReferences(config.RootModule, "", references)
+	}
+	// References are collected from a map of expressions, so their order isn't stable
+	for _, referenced := range references {
+		slices.Sort(referenced)
+	}
+	return references
+}
+
+func TestDecodePlan_SkipsUnusedBlocks(t *testing.T) {
+	input := `{
+		"prior_state": {"values": {"root_module": {"resources": [{"address": "aws_vpc.main", "values": {"tags": ["a", {"b": null}]}}]}}},
+		"format_version": "1.2",
+		"resource_drift": [],
+		"resource_changes": [
+			{"address": "aws_vpc.main", "type": "aws_vpc", "name": "main", "change": {"actions": ["create"], "after": {"cidr_block": "10.0.0.0/16"}}}
+		],
+		"relevant_attributes": [{"resource": "aws_vpc.main", "attribute": ["id"]}],
+		"timestamp": "2025-06-01T12:00:00Z"
+	}`
+
+	plan, err := DecodePlan(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", plan.FormatVersion)
+	require.Len(t, plan.ResourceChanges, 1)
+	assert.Equal(t, "aws_vpc.main", plan.ResourceChanges[0].Address)
+	assert.Equal(t, tfjson.Actions{tfjson.ActionCreate}, plan.ResourceChanges[0].Change.Actions)
+	assert.Nil(t, plan.PriorState)
+	assert.Empty(t, plan.RelevantAttributes)
+}
+
+func TestDecodePlan_Errors(t *testing.T) {
+	tests := map[string]string{
+		"not json":               `not json`,
+		"not an object":          `["format_version"]`,
+		"missing format version": `{"resource_changes": []}`,
+		"unsupported version":    `{"format_version": "2.0"}`,
+		"truncated":              `{"format_version": "1.2", "resource_changes": [{"address": "aws_vpc.main"}`,
+		"resource changes type":  `{"format_version": "1.2", "resource_changes": {}}`,
+		"trailing data":          `{"format_version": "1.2"} {}`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodePlan(strings.NewReader(input))
+			assert.ErrorContains(t, err, "failed to parse plan JSON")
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package plan
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// instanceKeyPattern matches the instance keys in resource addresses, such as [0] or ["blue"]
+var instanceKeyPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// nonResourceReferences are the reference prefixes that don't refer to resources
+var nonResourceReferences = []string{"var", "local", "module", "each", "count", "path", "terraform", "self"}
+
+// addDependencies fills in the dependencies of resource changes from the references and depends_on
+// arguments in the plan's configuration. Only references to other resources in the summary are kept.
+// References are resolved within a module, so dependencies through module inputs and outputs aren't included.
+func (a *Analyzer) addDependencies(changes []ResourceChange) {
+	if a.plan == nil || a.plan.Config == nil || a.plan.Config.RootModule == nil {
+		return
+	}
+
+	references := map[string][]string{}
+	collectModuleReferences(a.plan.Config.RootModule, "", references)
+
+	// Resource instances share the configuration of their resource
+	instances := map[string][]string{}
+	for _, change := range changes {
+		configAddress := configurationAddress(change.Address)
+		instances[configAddress] = append(instances[configAddress], change.Address)
+	}
+
+	for i := range changes {
+		var dependencies []string
+		for _, reference := range references[configurationAddress(changes[i].Address)] {
+			for _, address := range instances[reference] {
+				if address != changes[i].Address && !slices.Contains(dependencies, address) {
+					dependencies = append(dependencies, address)
+				}
+			}
+		}
+		slices.Sort(dependencies)
+		changes[i].Dependencies = dependencies
+	}
+}
+
+// collectModuleReferences records the resources referenced by every resource in a module and its child modules,
+// keyed by configuration address
+func collectModuleReferences(module *tfjson.ConfigModule, prefix string, references map[string][]string) {
+	for _, resource := range module.Resources {
+		if resource == nil {
+			continue
+		}
+		var referenced []string
+		add := func(reference string) {
+			if address := resourceReference(reference); address != "" && !slices.Contains(referenced, prefix+address) {
+				referenced = append(referenced, prefix+address)
+			}
+		}
+		for _, expression := range resource.Expressions {
+			expressionReferences(expression, add)
+		}
+		expressionReferences(resource.CountExpression, add)
+		expressionReferences(resource.ForEachExpression, add)
+		for _, dependency := range resource.DependsOn {
+			add(dependency)
+		}
+		references[prefix+resource.Address] = referenced
+	}
+
+	for name, call := range module.ModuleCalls {
+		if call != nil && call.Module != nil {
+			collectModuleReferences(call.Module, prefix+"module."+name+".", references)
+		}
+	}
+}
+
+// expressionReferences calls add for every reference in an expression, including nested blocks
+func expressionReferences(expression *tfjson.Expression, add func(string)) {
+	if expression == nil || expression.ExpressionData == nil {
+		return
+	}
+	for _, reference := range expression.References {
+		add(reference)
+	}
+	for _, block := range expression.NestedBlocks {
+		for _, nested := range block {
+			expressionReferences(nested, add)
+		}
+	}
+}
+
+// resourceReference returns the resource address of a reference such as aws_vpc.main.id or
+// data.aws_ami.ubuntu.id, or an empty string when the reference isn't to a resource
+func resourceReference(reference string) string {
+	parts := strings.Split(instanceKeyPattern.ReplaceAllString(reference, ""), ".")
+	if len(parts) < 2 || slices.Contains(nonResourceReferences, parts[0]) {
+		return ""
+	}
+	if parts[0] == "data" {
+		if len(parts) < 3 {
+			return ""
+		}
+		return strings.Join(parts[:3], ".")
+	}
+	return strings.Join(parts[:2], ".")
+}
+
+// configurationAddress returns the configuration address of a resource instance, without instance keys
+func configurationAddress(address string) string {
+	return instanceKeyPattern.ReplaceAllString(address, "")
+}
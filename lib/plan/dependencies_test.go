@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzer_AddDependencies(t *testing.T) {
+	references := func(refs ...string) map[string]*tfjson.Expression {
+		return map[string]*tfjson.Expression{"value": {ExpressionData: &tfjson.ExpressionData{References: refs}}}
+	}
+	analyzer := NewAnalyzer(&tfjson.Plan{Config: &tfjson.Config{RootModule: &tfjson.ConfigModule{
+		Resources: []*tfjson.ConfigResource{
+			{Address: "aws_vpc.main"},
+			{Address: "aws_subnet.main", Expressions: references("aws_vpc.main.id", "aws_vpc.main", "var.cidr", "data.aws_availability_zones.all.names")},
+			{Address: "aws_instance.web", Expressions: references("aws_subnet.main[0].id", "module.app.role"), DependsOn: []string{"aws_vpc.main"}},
+		},
+		ModuleCalls: map[string]*tfjson.ModuleCall{
+			"app": {Module: &tfjson.ConfigModule{Resources: []*tfjson.ConfigResource{
+				{Address: "aws_iam_role.app"},
+				{Address: "aws_iam_role_policy.app", Expressions: references("aws_iam_role.app.name")},
+			}}},
+		},
+	}}}, config.GetDefaultConfig())
+
+	changes := []ResourceChange{
+		{Address: "aws_vpc.main"},
+		{Address: "aws_subnet.main[0]"},
+		{Address: "aws_subnet.main[1]"},
+		{Address: "data.aws_availability_zones.all"},
+		{Address: "aws_instance.web"},
+		{Address: `module.app["blue"].aws_iam_role.app`},
+		{Address: `module.app["blue"].aws_iam_role_policy.app`},
+	}
+	analyzer.addDependencies(changes)
+
+	assert.Empty(t, changes[0].Dependencies)
+	assert.Equal(t, []string{"aws_vpc.main", "data.aws_availability_zones.all"}, changes[1].Dependencies)
+	assert.Equal(t, []string{"aws_subnet.main[0]", "aws_subnet.main[1]", "aws_vpc.main"}, changes[4].Dependencies)
+	assert.Equal(t, []string{`module.app["blue"].aws_iam_role.app`}, changes[6].Dependencies)
+}
+
+func TestAnalyzer_GenerateSummary_Dependencies(t *testing.T) {
+	summary := NewAnalyzer(nil, config.GetDefaultConfig()).GenerateSummary(filepath.Join("../../testdata", "dependencies_plan.json"))
+	require.NotNil(t, summary)
+
+	dependencies := map[string][]string{}
+	for _, change := range summary.ResourceChanges {
+		dependencies[change.Address] = change.Dependencies
+	}
+	assert.Empty(t, dependencies["aws_vpc.main"])
+	assert.Equal(t, []string{"aws_vpc.main"}, dependencies["aws_internet_gateway.main"])
+	assert.Contains(t, dependencies["aws_nat_gateway.main[0]"], "aws_eip.nat[0]")
+	assert.Contains(t, dependencies["aws_nat_gateway.main[0]"], "aws_internet_gateway.main")
+}
+
+func TestResourceReference(t *testing.T) {
+	tests := map[string]string{
+		"aws_vpc.main.id":          "aws_vpc.main",
+		"aws_subnet.public[0].id":  "aws_subnet.public",
+		`aws_iam_role.app["blue"]`: "aws_iam_role.app",
+		"data.aws_ami.ubuntu.id":   "data.aws_ami.ubuntu",
+		"var.region":               "",
+		"local.tags":               "",
+		"module.vpc.vpc_id":        "",
+		"each.value":               "",
+		"aws_vpc":                  "",
+	}
+	for reference, expected := range tests {
+		assert.Equal(t, expected, resourceReference(reference), reference)
+	}
+}
@@ -0,0 +1,162 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	encodedFormatJSON = "json"
+	encodedFormatYAML = "yaml"
+
+	// maxEncodedDocumentDepth limits recursion when diffing decoded documents
+	maxEncodedDocumentDepth = 32
+)
+
+// decodeEncodedDocuments attempts to decode two string values as structured documents.
+// Both values must decode in the same format and produce an object or list; JSON is tried first.
+// It returns the format and the decoded values, or false if the values aren't encoded documents.
+func decodeEncodedDocuments(before, after any) (string, any, any, bool) {
+	beforeStr, beforeOk := before.(string)
+	afterStr, afterOk := after.(string)
+	if !beforeOk || !afterOk {
+		return "", nil, nil, false
+	}
+
+	if decodedBefore, ok := decodeJSONDocument(beforeStr); ok {
+		if decodedAfter, ok := decodeJSONDocument(afterStr); ok {
+			return encodedFormatJSON, decodedBefore, decodedAfter, true
+		}
+	}
+
+	if decodedBefore, ok := decodeYAMLDocument(beforeStr); ok {
+		if decodedAfter, ok := decodeYAMLDocument(afterStr); ok {
+			return encodedFormatYAML, decodedBefore, decodedAfter, true
+		}
+	}
+
+	return "", nil, nil, false
+}
+
+// decodeJSONDocument decodes a string holding a JSON object or array
+func decodeJSONDocument(value string) (any, bool) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, false
+	}
+
+	var decoded any
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// decodeYAMLDocument decodes a multi-line string holding a YAML mapping or sequence.
+// Single-line strings are rejected as almost any scalar is valid YAML.
+func decodeYAMLDocument(value string) (any, bool) {
+	if !strings.Contains(strings.TrimSpace(value), "\n") {
+		return nil, false
+	}
+
+	var decoded any
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, false
+	}
+
+	normalized := normalizeYAMLValue(decoded)
+	switch normalized.(type) {
+	case map[string]any, []any:
+		return normalized, true
+	default:
+		return nil, false
+	}
+}
+
+// normalizeYAMLValue converts YAML-decoded values into the shapes produced by encoding/json,
+// so decoded documents can be compared with the same machinery as plan values
+func normalizeYAMLValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = normalizeYAMLValue(item)
+		}
+		return result
+	case map[any]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[fmt.Sprintf("%v", key)] = normalizeYAMLValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalizeYAMLValue(item)
+		}
+		return result
+	case int:
+		return float64(v)
+	default:
+		return v
+	}
+}
+
+// analyzeEncodedDocuments attaches structural diffs to string property changes that hold JSON or YAML documents.
+// Policy documents, sensitive values, and unknown values are skipped.
+func (a *Analyzer) analyzeEncodedDocuments(analysis *PropertyChangeAnalysis) {
+	for i, change := range analysis.Changes {
+		if change.Sensitive || change.IsUnknown || change.PolicyDiff != nil {
+			continue
+		}
+
+		format, encodedChanges, ok := a.encodedDocumentChanges(change.Before, change.After)
+		if !ok {
+			continue
+		}
+
+		analysis.Changes[i].EncodedFormat = format
+		analysis.Changes[i].EncodedChanges = encodedChanges
+		analysis.Changes[i].NoSemanticChange = len(encodedChanges) == 0
+	}
+}
+
+// encodedDocumentChanges compares two strings holding JSON or YAML documents by their decoded content, so
+// whitespace and key order differences aren't reported as changes. It returns the format and the changes
+// within the documents, or false if the values aren't encoded documents.
+func (a *Analyzer) encodedDocumentChanges(before, after any) (string, []PropertyChange, bool) {
+	format, decodedBefore, decodedAfter, ok := decodeEncodedDocuments(before, after)
+	if !ok {
+		return "", nil, false
+	}
+
+	changes := []PropertyChange{}
+	// The comparison only stops early when the limit is reached
+	_ = a.compareValues(decodedBefore, decodedAfter, nil, 0, maxEncodedDocumentDepth, func(pc PropertyChange) bool {
+		pc.Name = formatDocumentPath(pc.Path)
+		changes = append(changes, pc)
+		return len(changes) < MaxPropertiesPerResource
+	})
+	return format, changes, true
+}
+
+// formatDocumentPath renders a path within a decoded document, using brackets for list indices
+// e.g., ["0", "environment", "1", "value"] becomes "[0].environment[1].value"
+func formatDocumentPath(path []string) string {
+	var builder strings.Builder
+	for _, part := range path {
+		if _, err := strconv.Atoi(part); err == nil {
+			builder.WriteString("[" + part + "]")
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(".")
+		}
+		builder.WriteString(part)
+	}
+	return builder.String()
+}
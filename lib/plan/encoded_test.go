@@ -0,0 +1,187 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEncodedDocuments(t *testing.T) {
+	tests := []struct {
+		name           string
+		before         any
+		after          any
+		expectedOk     bool
+		expectedFormat string
+	}{
+		{"JSON objects", `{"a": 1}`, `{"a": 2}`, true, encodedFormatJSON},
+		{"JSON arrays", `[{"name": "web"}]`, `[{"name": "api"}]`, true, encodedFormatJSON},
+		{"YAML mappings", "replicas: 1\nimage: nginx\n", "replicas: 2\nimage: nginx\n", true, encodedFormatYAML},
+		{"plain strings", "t3.micro", "t3.small", false, ""},
+		{"single-line YAML-like strings", "a: b", "a: c", false, ""},
+		{"shell scripts", "#!/bin/bash\necho one\n", "#!/bin/bash\necho two\n", false, ""},
+		{"mixed document and plain string", `{"a": 1}`, "plain", false, ""},
+		{"non-string values", 1, 2, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, _, _, ok := decodeEncodedDocuments(tt.before, tt.after)
+			assert.Equal(t, tt.expectedOk, ok)
+			assert.Equal(t, tt.expectedFormat, format)
+		})
+	}
+}
+
+func TestEncodedDocumentChanges(t *testing.T) {
+	analyzer := &Analyzer{}
+
+	tests := []struct {
+		name            string
+		before          any
+		after           any
+		expectedChanges []string
+	}{
+		{
+			name:            "whitespace-only JSON change",
+			before:          `{"a": 1, "b": [1, 2]}`,
+			after:           "{\n  \"a\": 1,\n  \"b\": [1, 2]\n}",
+			expectedChanges: []string{},
+		},
+		{
+			name:            "key-order-only JSON change",
+			before:          `{"a": 1, "b": 2}`,
+			after:           `{"b": 2, "a": 1}`,
+			expectedChanges: []string{},
+		},
+		{
+			name:            "single nested value change",
+			before:          `[{"name": "web", "environment": [{"name": "LOG", "value": "info"}]}]`,
+			after:           `[{"name": "web", "environment": [{"name": "LOG", "value": "debug"}]}]`,
+			expectedChanges: []string{"[0].environment[0].value"},
+		},
+		{
+			name:            "YAML values change",
+			before:          "replicas: 1\nimage: nginx:1.25\n",
+			after:           "image: nginx:1.27\nreplicas: 1\n",
+			expectedChanges: []string{"image"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, encodedChanges, ok := analyzer.encodedDocumentChanges(tt.before, tt.after)
+			require.True(t, ok)
+			changes := []string{}
+			for _, change := range encodedChanges {
+				changes = append(changes, change.Name)
+			}
+			assert.Equal(t, tt.expectedChanges, changes)
+		})
+	}
+}
+
+func TestEncodedDocumentChanges_StableLimit(t *testing.T) {
+	analyzer := &Analyzer{}
+	before := map[string]any{}
+	after := map[string]any{}
+	for i := range MaxPropertiesPerResource + 50 {
+		key := fmt.Sprintf("key%03d", i)
+		before[key] = i
+		after[key] = i + 1
+	}
+	beforeJSON, err := json.Marshal(before)
+	require.NoError(t, err)
+	afterJSON, err := json.Marshal(after)
+	require.NoError(t, err)
+
+	// The same changes are kept in the same order on every run
+	var first []string
+	for range 20 {
+		_, encodedChanges, ok := analyzer.encodedDocumentChanges(string(beforeJSON), string(afterJSON))
+		require.True(t, ok)
+		require.Len(t, encodedChanges, MaxPropertiesPerResource)
+		names := make([]string, 0, len(encodedChanges))
+		for _, change := range encodedChanges {
+			names = append(names, change.Name)
+		}
+		if first == nil {
+			first = names
+		}
+		assert.Equal(t, first, names)
+	}
+	assert.Equal(t, "key000", first[0])
+	assert.Equal(t, fmt.Sprintf("key%03d", MaxPropertiesPerResource-1), first[len(first)-1])
+}
+
+func TestAnalyzePropertyChanges_EncodedDocuments(t *testing.T) {
+	analyzer := NewAnalyzer(&tfjson.Plan{}, config.GetDefaultConfig())
+	analysis := analyzer.AnalyzePropertyChanges(&tfjson.ResourceChange{
+		Change: &tfjson.Change{
+			Actions: []tfjson.Action{tfjson.ActionUpdate},
+			Before: map[string]any{
+				"container_definitions": `[{"name":"web","cpu":256,"environment":[{"name":"LOG_LEVEL","value":"info"}]}]`,
+				"values":                "replicaCount: 2\nimage:\n  tag: v1\n",
+			},
+			After: map[string]any{
+				"container_definitions": `[{"name":"web","cpu":256,"environment":[{"name":"LOG_LEVEL","value":"debug"}]}]`,
+				"values":                "image:\n  tag: v1\nreplicaCount: 2\n",
+			},
+		},
+	})
+
+	require.Len(t, analysis.Changes, 2)
+	changes := map[string]PropertyChange{}
+	for _, change := range analysis.Changes {
+		changes[change.Name] = change
+	}
+
+	containers := changes["container_definitions"]
+	assert.Equal(t, encodedFormatJSON, containers.EncodedFormat)
+	assert.False(t, containers.NoSemanticChange)
+	require.Len(t, containers.EncodedChanges, 1)
+	assert.Equal(t, "[0].environment[0].value", containers.EncodedChanges[0].Name)
+	assert.Equal(t, "info", containers.EncodedChanges[0].Before)
+	assert.Equal(t, "debug", containers.EncodedChanges[0].After)
+
+	values := changes["values"]
+	assert.Equal(t, encodedFormatYAML, values.EncodedFormat)
+	assert.True(t, values.NoSemanticChange)
+	assert.Empty(t, values.EncodedChanges)
+}
+
+func TestFormatPropertyChange_EncodedDocuments(t *testing.T) {
+	formatter := NewFormatter(config.GetDefaultConfig())
+
+	changed := formatter.formatPropertyChange(PropertyChange{
+		Name:          "container_definitions",
+		Action:        actionUpdate,
+		EncodedFormat: encodedFormatJSON,
+		EncodedChanges: []PropertyChange{
+			{Name: "[0].cpu", Action: actionUpdate, Before: float64(256), After: float64(512)},
+			{Name: "[0].memory", Action: actionAdd, After: float64(1024)},
+		},
+	})
+	assert.Equal(t, indent+"~ container_definitions (json document)\n"+
+		nestedIndent+"~ [0].cpu = 256 -> 512\n"+
+		nestedIndent+"+ [0].memory = 1024", changed)
+
+	unchanged := formatter.formatPropertyChange(PropertyChange{
+		Name:             "values",
+		Action:           actionUpdate,
+		EncodedFormat:    encodedFormatYAML,
+		NoSemanticChange: true,
+	})
+	assert.Equal(t, indent+"~ values (yaml document): no semantic change", unchanged)
+}
+
+func TestFormatDocumentPath(t *testing.T) {
+	assert.Equal(t, "[0].environment[1].value", formatDocumentPath([]string{"0", "environment", "1", "value"}))
+	assert.Equal(t, "spec.replicas", formatDocumentPath([]string{"spec", "replicas"}))
+	assert.Equal(t, "", formatDocumentPath(nil))
+}
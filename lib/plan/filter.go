@@ -0,0 +1,147 @@
+package plan
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/ArjenSchwarz/strata/config"
+)
+
+// rootModuleFilter selects the resources outside of modules in a module filter
+const rootModuleFilter = "root"
+
+// WithResourceChanges returns a copy of the summary that only contains the given resource changes,
+// with the statistics recalculated for them. Output changes are kept as they are.
+func (s *PlanSummary) WithResourceChanges(changes []ResourceChange) *PlanSummary {
+	filtered := *s
+	filtered.ResourceChanges = changes
+	filtered.Statistics = (&Analyzer{}).calculateStatistics(changes, s.OutputChanges)
+	return &filtered
+}
+
+// ResourceFilter selects the resource changes that match a filter configuration
+type ResourceFilter struct {
+	config  config.ResourceFilterConfig
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+	types   []*regexp.Regexp
+}
+
+// NewResourceFilter prepares a filter configuration for matching resource changes
+func NewResourceFilter(filter config.ResourceFilterConfig) *ResourceFilter {
+	return &ResourceFilter{
+		config:  filter,
+		include: globPatterns(filter.Include),
+		exclude: globPatterns(filter.Exclude),
+		types:   globPatterns(filter.Types),
+	}
+}
+
+// Apply returns the resource changes that match the filter
+func (f *ResourceFilter) Apply(changes []ResourceChange) []ResourceChange {
+	if !f.config.IsActive() {
+		return changes
+	}
+	filtered := make([]ResourceChange, 0, len(changes))
+	for _, change := range changes {
+		if f.Matches(change) {
+			filtered = append(filtered, change)
+		}
+	}
+	return filtered
+}
+
+// Matches reports whether a resource change matches every setting of the filter
+func (f *ResourceFilter) Matches(change ResourceChange) bool {
+	switch {
+	case len(f.include) > 0 && !matchesAny(f.include, change.Address):
+		return false
+	case matchesAny(f.exclude, change.Address):
+		return false
+	case len(f.config.Actions) > 0 && !containsFold(f.config.Actions, string(change.ChangeType)):
+		return false
+	case len(f.config.Providers) > 0 && !containsFold(f.config.Providers, change.Provider):
+		return false
+	case len(f.types) > 0 && !matchesAny(f.types, change.Type):
+		return false
+	case len(f.config.Modules) > 0 && !matchesAnyModule(f.config.Modules, change.ModulePath):
+		return false
+	case f.config.MinRisk != "" && RiskLevelRank(change.RiskLevel()) < RiskLevelRank(f.config.MinRisk):
+		return false
+	}
+	return true
+}
+
+// matchesAny reports whether a value matches any of the patterns
+func matchesAny(patterns []*regexp.Regexp, value string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// globPatterns converts glob patterns to regular expressions that match the whole value.
+// Only * and ? are special, so the brackets and quotes of resource addresses don't need escaping.
+func globPatterns(globs []string) []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, 0, len(globs))
+	for _, glob := range globs {
+		patterns = append(patterns, globPattern(glob))
+	}
+	return patterns
+}
+
+// globPattern converts a glob pattern to a regular expression that matches the whole value
+func globPattern(pattern string) *regexp.Regexp {
+	expression := regexp.QuoteMeta(pattern)
+	expression = strings.ReplaceAll(expression, `\*`, ".*")
+	expression = strings.ReplaceAll(expression, `\?`, ".")
+	return regexp.MustCompile("^" + expression + "$")
+}
+
+// matchesAnyModule reports whether a module path is one of the modules or one of their child modules.
+// Modules can be given as module paths (app/storage) or as address prefixes (module.app.module.storage).
+func matchesAnyModule(modules []string, modulePath string) bool {
+	for _, module := range modules {
+		if module == rootModuleFilter {
+			if modulePath == "" || modulePath == "-" {
+				return true
+			}
+			continue
+		}
+		module = normalizeModuleFilter(module)
+		if modulePath == module || strings.HasPrefix(modulePath, module+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeModuleFilter converts a module address, such as module.app.module.storage, to a module path
+func normalizeModuleFilter(module string) string {
+	if !strings.HasPrefix(module, "module.") {
+		return strings.Trim(module, "/")
+	}
+	var parts []string
+	segments := strings.Split(module, ".")
+	for i := 0; i+1 < len(segments); i += 2 {
+		name := segments[i+1]
+		// Module instances are matched by their module path, which has no instance keys
+		if index := strings.Index(name, "["); index != -1 {
+			name = name[:index]
+		}
+		parts = append(parts, name)
+	}
+	return strings.Join(parts, "/")
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package plan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filterTestChanges returns resource changes across providers, modules, and risk levels
+func filterTestChanges() []ResourceChange {
+	return []ResourceChange{
+		{Address: "aws_instance.web", Type: "aws_instance", Provider: "aws", ChangeType: ChangeTypeUpdate, ModulePath: "-"},
+		{Address: `module.app["blue"].aws_iam_role.app`, Type: "aws_iam_role", Provider: "aws", ChangeType: ChangeTypeDelete, ModulePath: "app", IsDangerous: true},
+		{Address: "module.app.module.storage.aws_s3_bucket.data", Type: "aws_s3_bucket", Provider: "aws", ChangeType: ChangeTypeReplace, ModulePath: "app/storage", IsDangerous: true},
+		{Address: "module.network.azurerm_subnet.main", Type: "azurerm_subnet", Provider: "azurerm", ChangeType: ChangeTypeCreate, ModulePath: "network"},
+	}
+}
+
+func filteredAddresses(filter config.ResourceFilterConfig) []string {
+	addresses := []string{}
+	for _, change := range NewResourceFilter(filter).Apply(filterTestChanges()) {
+		addresses = append(addresses, change.Address)
+	}
+	return addresses
+}
+
+func TestResourceFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   config.ResourceFilterConfig
+		expected []string
+	}{
+		{"empty filter", config.ResourceFilterConfig{}, []string{"aws_instance.web", `module.app["blue"].aws_iam_role.app`, "module.app.module.storage.aws_s3_bucket.data", "module.network.azurerm_subnet.main"}},
+		{"include glob with brackets", config.ResourceFilterConfig{Include: []string{`module.app["*"].*`}}, []string{`module.app["blue"].aws_iam_role.app`}},
+		{"exclude glob", config.ResourceFilterConfig{Exclude: []string{"module.*"}}, []string{"aws_instance.web"}},
+		{"include and exclude", config.ResourceFilterConfig{Include: []string{"module.*"}, Exclude: []string{"*azurerm*"}}, []string{`module.app["blue"].aws_iam_role.app`, "module.app.module.storage.aws_s3_bucket.data"}},
+		{"actions", config.ResourceFilterConfig{Actions: []string{"create", "Delete"}}, []string{`module.app["blue"].aws_iam_role.app`, "module.network.azurerm_subnet.main"}},
+		{"provider", config.ResourceFilterConfig{Providers: []string{"azurerm"}}, []string{"module.network.azurerm_subnet.main"}},
+		{"type glob", config.ResourceFilterConfig{Types: []string{"aws_i*"}}, []string{"aws_instance.web", `module.app["blue"].aws_iam_role.app`}},
+		{"module includes child modules", config.ResourceFilterConfig{Modules: []string{"app"}}, []string{`module.app["blue"].aws_iam_role.app`, "module.app.module.storage.aws_s3_bucket.data"}},
+		{"module address", config.ResourceFilterConfig{Modules: []string{"module.app.module.storage"}}, []string{"module.app.module.storage.aws_s3_bucket.data"}},
+		{"root module", config.ResourceFilterConfig{Modules: []string{"root", "network"}}, []string{"aws_instance.web", "module.network.azurerm_subnet.main"}},
+		{"minimum risk", config.ResourceFilterConfig{MinRisk: "high"}, []string{`module.app["blue"].aws_iam_role.app`, "module.app.module.storage.aws_s3_bucket.data"}},
+		{"settings are combined", config.ResourceFilterConfig{Providers: []string{"aws"}, MinRisk: "critical"}, []string{`module.app["blue"].aws_iam_role.app`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, filteredAddresses(tt.filter))
+		})
+	}
+}
+
+func TestResourceFilterConfig_Validate(t *testing.T) {
+	assert.NoError(t, config.ResourceFilterConfig{Actions: []string{"no-op", "REPLACE"}, MinRisk: "Medium"}.Validate())
+	assert.ErrorContains(t, config.ResourceFilterConfig{Actions: []string{"destroy"}}.Validate(), `invalid filter action "destroy"`)
+	assert.ErrorContains(t, config.ResourceFilterConfig{MinRisk: "severe"}.Validate(), `invalid filter risk level "severe"`)
+}
+
+func TestFormatter_ResourceFilter(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.Filter = config.ResourceFilterConfig{Actions: []string{"create"}}
+	summary := &PlanSummary{PlanFile: "test.tfplan", ResourceChanges: filterTestChanges()}
+	summary.Statistics = (&Analyzer{}).calculateStatistics(summary.ResourceChanges, nil)
+
+	var rendered bytes.Buffer
+	outputConfig := cfg.NewOutputConfigurationForFormat("markdown")
+	require.NoError(t, NewFormatter(cfg).RenderSummary(&rendered, summary, outputConfig, true))
+
+	output := rendered.String()
+	assert.Contains(t, output, "Filter active (action=create): showing 1 of 4 resource changes")
+	assert.Contains(t, output, `module.network.azurerm\_subnet.main`)
+	assert.NotContains(t, output, `aws\_instance.web`)
+	// The statistics still cover the full plan
+	assert.Contains(t, output, "| 4 ")
+}
+
+func TestPlanSummary_WithResourceChanges(t *testing.T) {
+	summary := &PlanSummary{PlanFile: "test.tfplan", ResourceChanges: filterTestChanges()}
+	filtered := summary.WithResourceChanges(summary.ResourceChanges[1:3])
+	assert.Equal(t, "test.tfplan", filtered.PlanFile)
+	assert.Equal(t, 2, filtered.Statistics.Total)
+	assert.Equal(t, 2, filtered.Statistics.HighRisk)
+	assert.Len(t, summary.ResourceChanges, 4)
+}
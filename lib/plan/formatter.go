@@ -3,19 +3,24 @@ package plan
 import (
 	"context"
 	"fmt"
+	"io"
 	"reflect"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 
 	output "github.com/ArjenSchwarz/go-output/v2"
 	"github.com/ArjenSchwarz/strata/config"
+	"github.com/fatih/color"
 )
 
 const (
 	notApplicable       = "N/A"
 	formatTable         = "table"
 	formatMarkdown      = "markdown"
+	formatHTML          = "html"
+	formatJSON          = "json"
 	noPropertiesChanged = "No properties changed"
 	truncatedIndicator  = " [truncated]"
 	// Unicode En space (U+2002) constants for consistent indentation across output formats
@@ -35,6 +40,8 @@ const (
 // Formatter handles different output formats for plan summaries
 type Formatter struct {
 	config *config.Config
+	format string // Target output format when building a document, used for format-specific details
+	colors bool   // Whether the target output is coloured, which colours diff lines in table output
 }
 
 // NewFormatter creates a new formatter instance
@@ -44,9 +51,19 @@ func NewFormatter(cfg *config.Config) *Formatter {
 	}
 }
 
+// withFormat returns a copy of the formatter that builds documents for the given output format,
+// with or without colours
+func (f *Formatter) withFormat(format string, colors bool) *Formatter {
+	return &Formatter{
+		config: f.config,
+		format: strings.ToLower(format),
+		colors: colors,
+	}
+}
+
 // ValidateOutputFormat validates that the output format is supported
 func (f *Formatter) ValidateOutputFormat(outputFormat string) error {
-	supportedFormats := []string{formatTable, "json", "csv", "html", "markdown"}
+	supportedFormats := []string{formatTable, formatJSON, "csv", "html", "markdown"}
 	lowercaseFormat := strings.ToLower(outputFormat)
 	if slices.Contains(supportedFormats, lowercaseFormat) {
 		return nil
@@ -81,6 +98,11 @@ func (f *Formatter) OutputSummary(summary *PlanSummary, outputConfig *config.Out
 
 	ctx := context.Background()
 
+	// User-supplied templates and the HTML report are rendered outside of go-output
+	if isCustomFormat(outputConfig.Format) || (outputConfig.OutputFile != "" && isCustomFormat(outputConfig.OutputFileFormat)) {
+		return f.outputCustomSummary(summary, outputConfig, showDetails)
+	}
+
 	// Validate output format first
 	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
 		return err
@@ -107,56 +129,66 @@ func (f *Formatter) OutputSummary(summary *PlanSummary, outputConfig *config.Out
 		return stdoutOut.Render(ctx, doc)
 	}
 
-	// Build the document using v2 builder pattern
-	builder := output.New()
-
-	// Re-enable all tables using the proven NewTableContent pattern
-	// This fixes the multi-table rendering issue by using consistent table creation methods
+	return f.renderDocument(outputConfig, func(formatter *Formatter) (*output.Document, error) {
+		return formatter.buildSummaryDocument(summary, &filteredSummary, showDetails, outputConfig)
+	})
+}
 
-	// Plan Information table - RE-ENABLED using NewTableContent pattern
-	planData, err := f.createPlanInfoDataV2(&filteredSummary)
-	if err == nil && len(planData) > 0 {
-		planTable, err := output.NewTableContent("Plan Information", planData,
-			output.WithKeys("Plan File", "Version", "Workspace", "Backend", "Created"))
-		if err == nil {
-			builder = builder.AddContent(planTable)
-		} else {
-			// Log warning but continue operation - conservative error handling
-			fmt.Printf("Warning: Failed to create plan information table: %v\n", err)
-		}
+// RenderSummary renders a plan summary to a writer in the configured output format, without colours.
+// It is used when the summary isn't written to stdout, such as in responses to run tasks.
+func (f *Formatter) RenderSummary(w io.Writer, summary *PlanSummary, outputConfig *config.OutputConfiguration, showDetails bool) error {
+	if summary == nil {
+		return fmt.Errorf("plan summary cannot be nil")
+	}
+	if isCustomFormat(outputConfig.Format) {
+		return f.renderCustomFormat(w, summary, outputConfig.Format, outputConfig)
+	}
+	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
+		return err
 	}
 
-	// Summary Statistics table - RE-ENABLED using NewTableContent pattern
-	// TASK 4.3: Ensure statistics remain unchanged and count all resources including no-ops (Requirement 3.7)
-	// Use original summary for statistics to maintain count of all resources
-	statsData, err := f.createStatisticsSummaryDataV2(summary)
-	if err == nil && len(statsData) > 0 {
-		statsTable, err := output.NewTableContent("Summary Statistics", statsData,
-			output.WithKeys("Total Changes", "Added", "Removed", "Modified", "Replacements", "High Risk", "Unmodified"))
-		if err == nil {
-			builder = builder.AddContent(statsTable)
-		} else {
-			// Log warning but continue operation - conservative error handling
-			fmt.Printf("Warning: Failed to create summary statistics table: %v\n", err)
+	filteredSummary := *summary
+	filteredSummary.ResourceChanges = f.filterNoOps(summary.ResourceChanges)
+	filteredSummary.OutputChanges = f.filterNoOpOutputs(summary.OutputChanges)
+
+	var doc *output.Document
+	if len(filteredSummary.ResourceChanges) == 0 && len(filteredSummary.OutputChanges) == 0 {
+		doc = output.New().Text("No changes detected").Build()
+	} else {
+		var err error
+		doc, err = f.withFormat(outputConfig.Format, false).buildSummaryDocument(summary, &filteredSummary, showDetails, outputConfig)
+		if err != nil {
+			return err
 		}
 	}
 
-	// Resource Changes table - UNIFIED TABLE CREATION following go-output example pattern
-	// Use filtered summary for display
-	if err := f.handleResourceDisplay(&filteredSummary, showDetails, outputConfig, builder); err != nil {
-		return err
+	options := []output.OutputOption{
+		output.WithFormat(f.getFormatFromConfig(outputConfig.Format)),
+		output.WithWriter(output.WriterFunc(func(_ context.Context, _ string, data []byte) error {
+			_, err := w.Write(data)
+			return err
+		})),
 	}
-	// If no conditions above are met, we show only Plan Information and Summary Statistics tables
+	if outputConfig.UseEmoji {
+		options = append(options, output.WithTransformer(&output.EmojiTransformer{}))
+	}
+	if err := output.NewOutput(options...).Render(context.Background(), doc); err != nil {
+		return fmt.Errorf("failed to render summary: %w", err)
+	}
+	return nil
+}
 
-	// Output Changes table - placed after resource changes section (requirement 2.1)
-	// Use filtered summary for display
-	if err := f.handleOutputDisplay(&filteredSummary, builder); err != nil {
+// renderDocument renders a document to stdout and, when configured, to the output file.
+// The file format may differ from stdout, so build is called once per target with a formatter bound to its format.
+func (f *Formatter) renderDocument(outputConfig *config.OutputConfiguration, build func(*Formatter) (*output.Document, error)) error {
+	ctx := context.Background()
+
+	// Build the document for stdout using v2 builder pattern
+	doc, err := build(f.withFormat(outputConfig.Format, shouldUseColorTransformer(outputConfig.UseColors, outputConfig.Format)))
+	if err != nil {
 		return err
 	}
 
-	// Unified document building using output.New().AddContent().Build() pattern
-	doc := builder.Build()
-
 	// Render to stdout first - unified format handling delegated to go-output
 	stdoutFormat := f.getFormatFromConfig(outputConfig.Format)
 	if outputConfig.TableStyle != "" && outputConfig.Format == formatTable {
@@ -205,8 +237,13 @@ func (f *Formatter) OutputSummary(summary *PlanSummary, outputConfig *config.Out
 			fileOptions = append(fileOptions, output.WithTransformer(output.NewColorTransformer()))
 		}
 
+		fileDoc, err := build(f.withFormat(outputConfig.OutputFileFormat, shouldUseColorTransformer(outputConfig.UseColors, outputConfig.OutputFileFormat)))
+		if err != nil {
+			return err
+		}
+
 		fileOut := output.NewOutput(fileOptions...)
-		if err := fileOut.Render(ctx, doc); err != nil {
+		if err := fileOut.Render(ctx, fileDoc); err != nil {
 			return fmt.Errorf("failed to render to file: %w", err)
 		}
 	}
@@ -214,6 +251,73 @@ func (f *Formatter) OutputSummary(summary *PlanSummary, outputConfig *config.Out
 	return nil
 }
 
+// buildSummaryDocument builds the summary document for the formatter's target format.
+// Statistics are taken from the full summary, while all other sections use the filtered summary.
+func (f *Formatter) buildSummaryDocument(summary, filteredSummary *PlanSummary, showDetails bool, outputConfig *config.OutputConfiguration) (*output.Document, error) {
+	// Build the document using v2 builder pattern
+	builder := output.New()
+
+	// The resource filter only applies to the displayed resources, the statistics cover the full plan
+	var filterNote string
+	if f.config != nil && f.config.Plan.Filter.IsActive() {
+		resources := *filteredSummary
+		resources.ResourceChanges = NewResourceFilter(f.config.Plan.Filter).Apply(filteredSummary.ResourceChanges)
+		filterNote = fmt.Sprintf("Filter active (%s): showing %d of %d resource changes",
+			f.config.Plan.Filter, len(resources.ResourceChanges), len(filteredSummary.ResourceChanges))
+		filteredSummary = &resources
+	}
+
+	// Re-enable all tables using the proven NewTableContent pattern
+	// This fixes the multi-table rendering issue by using consistent table creation methods
+
+	// Plan Information table - RE-ENABLED using NewTableContent pattern
+	planData, err := f.createPlanInfoDataV2(filteredSummary)
+	if err == nil && len(planData) > 0 {
+		planTable, err := output.NewTableContent("Plan Information", planData,
+			output.WithKeys("Plan File", "Version", "Workspace", "Backend", "Created"))
+		if err == nil {
+			builder = builder.AddContent(planTable)
+		} else {
+			// Log warning but continue operation - conservative error handling
+			fmt.Printf("Warning: Failed to create plan information table: %v\n", err)
+		}
+	}
+
+	// Summary Statistics table - RE-ENABLED using NewTableContent pattern
+	// TASK 4.3: Ensure statistics remain unchanged and count all resources including no-ops (Requirement 3.7)
+	// Use original summary for statistics to maintain count of all resources
+	statsData, err := f.createStatisticsSummaryDataV2(summary)
+	if err == nil && len(statsData) > 0 {
+		statsTable, err := output.NewTableContent("Summary Statistics", statsData,
+			output.WithKeys("Total Changes", "Added", "Removed", "Modified", "Replacements", "High Risk", "Unmodified"))
+		if err == nil {
+			builder = builder.AddContent(statsTable)
+		} else {
+			// Log warning but continue operation - conservative error handling
+			fmt.Printf("Warning: Failed to create summary statistics table: %v\n", err)
+		}
+	}
+	if filterNote != "" {
+		builder = builder.Text(filterNote)
+	}
+
+	// Resource Changes table - UNIFIED TABLE CREATION following go-output example pattern
+	// Use filtered summary for display
+	if err := f.handleResourceDisplay(filteredSummary, showDetails, outputConfig, builder); err != nil {
+		return nil, err
+	}
+	// If no conditions above are met, we show only Plan Information and Summary Statistics tables
+
+	// Output Changes table - placed after resource changes section (requirement 2.1)
+	// Use filtered summary for display
+	if err := f.handleOutputDisplay(filteredSummary, builder); err != nil {
+		return nil, err
+	}
+
+	// Unified document building using output.New().AddContent().Build() pattern
+	return builder.Build(), nil
+}
+
 func shouldUseColorTransformer(useColors bool, outputFormat string) bool {
 	return useColors && strings.ToLower(outputFormat) != formatMarkdown
 }
@@ -331,13 +435,7 @@ func (f *Formatter) createSensitiveResourceChangesDataV2(summary *PlanSummary) (
 		}
 
 		// Format danger information
-		dangerInfo := ""
-		if change.IsDangerous {
-			dangerInfo = "⚠️ " + change.DangerReason
-			if len(change.DangerProperties) > 0 {
-				dangerInfo += ": " + strings.Join(change.DangerProperties, ", ")
-			}
-		}
+		dangerInfo := f.getDangerDisplay(change)
 
 		data = append(data, map[string]any{
 			"Action":      getActionDisplay(change.ChangeType),
@@ -374,8 +472,9 @@ func (f *Formatter) formatPropertyChangeDetails(changes []PropertyChange) string
 	var details []string
 	for _, change := range changes {
 		if change.Sensitive {
-			// Mask sensitive values
-			details = append(details, fmt.Sprintf("• %s: (sensitive value) → (sensitive value)", change.Name))
+			// Mask sensitive values, showing only whether they changed
+			details = append(details, fmt.Sprintf("• %s: %s", change.Name,
+				sensitiveChangeDisplay(change.SensitiveStatus, change.BeforeHash, change.AfterHash)))
 		} else {
 			// Show actual values for non-sensitive properties
 			details = append(details, fmt.Sprintf("• %s: %v → %v", change.Name, change.Before, change.After))
@@ -395,29 +494,7 @@ func (f *Formatter) propertyChangesFormatterTerraform() func(any) any {
 						return noPropertiesChanged
 					}
 
-					// Create summary
-					summary := fmt.Sprintf("%d properties changed", propAnalysis.Count)
-					if f.hasSensitive(propAnalysis.Changes) {
-						summary = fmt.Sprintf("⚠️ %s (includes sensitive)", summary)
-					}
-					if propAnalysis.Truncated {
-						summary += truncatedIndicator
-					}
-
-					// Format details in Terraform style
-					var details []string
-					for _, change := range propAnalysis.Changes {
-						line := f.formatPropertyChange(change)
-						details = append(details, line)
-					}
-
-					shouldExpand := (f.config.Plan.ExpandableSections.AutoExpandDangerous && f.hasSensitive(propAnalysis.Changes)) ||
-						f.config.ExpandAll
-
-					return output.NewCollapsibleValue(summary,
-						strings.Join(details, "\n"),
-						output.WithExpanded(shouldExpand),
-						output.WithMaxLength(f.config.Plan.ExpandableSections.MaxDetailLength))
+					return f.terraformPropertyChanges(propAnalysis)
 				}
 			}
 		}
@@ -428,42 +505,71 @@ func (f *Formatter) propertyChangesFormatterTerraform() func(any) any {
 				return noPropertiesChanged
 			}
 
-			// Create summary
-			summary := fmt.Sprintf("%d properties changed", propAnalysis.Count)
-			if f.hasSensitive(propAnalysis.Changes) {
-				summary = fmt.Sprintf("⚠️ %s (includes sensitive)", summary)
-			}
-			if propAnalysis.Truncated {
-				summary += truncatedIndicator
-			}
-
-			// Format details in Terraform style - use standard formatting without context
-			var details []string
-			for _, change := range propAnalysis.Changes {
-				line := f.formatPropertyChange(change)
-				details = append(details, line)
-			}
+			return f.terraformPropertyChanges(propAnalysis)
+		}
+		return val
+	}
+}
 
-			shouldExpand := (f.config.Plan.ExpandableSections.AutoExpandDangerous && f.hasSensitive(propAnalysis.Changes)) ||
-				f.config.ExpandAll
+// terraformPropertyChanges builds the collapsible Terraform-style diff for the property changes of a resource
+func (f *Formatter) terraformPropertyChanges(propAnalysis PropertyChangeAnalysis) *output.DefaultCollapsibleValue {
+	// Create summary
+	summary := fmt.Sprintf("%d properties changed", propAnalysis.Count)
+	if perpetual := perpetualDiffCount(propAnalysis.Changes); perpetual > 0 {
+		if perpetual == len(propAnalysis.Changes) {
+			summary += fmt.Sprintf(" (%s)", perpetualDiffLabel)
+		} else {
+			summary += fmt.Sprintf(" (%d %s)", perpetual, perpetualDiffLabel)
+		}
+	}
+	if f.hasSensitive(propAnalysis.Changes) {
+		summary = fmt.Sprintf("⚠️ %s (includes sensitive)", summary)
+	}
+	if propAnalysis.Truncated {
+		summary += truncatedIndicator
+	}
 
-			return output.NewCollapsibleValue(summary,
-				strings.Join(details, "\n"),
-				output.WithExpanded(shouldExpand),
-				output.WithMaxLength(f.config.Plan.ExpandableSections.MaxDetailLength))
+	// Format details in Terraform style, leaving out perpetual diffs when they are collapsed
+	collapse := f.config != nil && f.config.Plan.PerpetualDiff.Collapse
+	var details []string
+	for _, change := range propAnalysis.Changes {
+		if collapse && change.PerpetualDiff {
+			continue
+		}
+		details = append(details, f.formatPropertyChange(change))
+	}
+	if collapse {
+		if names := perpetualDiffNames(propAnalysis.Changes); len(names) > 0 {
+			details = append(details, fmt.Sprintf("%s# %s hidden: %s", indent, perpetualDiffLabel, strings.Join(names, ", ")))
 		}
-		return val
 	}
+
+	return f.propertyChangesCollapsible(summary, propAnalysis, details)
 }
 
 // formatPropertyChange formats a single property change in Terraform's diff-style format with optional context
 func (f *Formatter) formatPropertyChange(change PropertyChange) string {
 	var line string
-	replacementIndicator := ""
+	replacementIndicator := propertyChangeIndicator(change)
 
-	// Add replacement indicator if this change triggers replacement
-	if change.TriggersReplacement {
-		replacementIndicator = " # forces replacement"
+	// Sensitive values are never shown, only whether they changed
+	if change.Sensitive && !change.IsUnknown {
+		return f.formatSensitiveChange(change, replacementIndicator)
+	}
+
+	// Policy documents are shown as statement-level diffs instead of two large strings
+	if change.PolicyDiff != nil {
+		return f.formatPolicyChange(change, replacementIndicator)
+	}
+
+	// JSON and YAML encoded strings are shown as changes within the decoded document
+	if change.EncodedFormat != "" {
+		return f.formatEncodedDocumentChange(change, replacementIndicator)
+	}
+
+	// Multiline strings such as user_data and templates are shown as a unified diff
+	if f.usesMultilineDiff(change) {
+		return f.formatMultilineChange(change, replacementIndicator)
 	}
 
 	// Check if we're dealing with complex nested values that should use nested formatting
@@ -525,6 +631,194 @@ func (f *Formatter) formatPropertyChange(change PropertyChange) string {
 	return line
 }
 
+// propertyChangeIndicator returns the trailing comment for a property change, noting
+// whether it forces replacement and whether it is a perpetual diff
+func propertyChangeIndicator(change PropertyChange) string {
+	var notes []string
+	if change.TriggersReplacement {
+		notes = append(notes, "forces replacement")
+	}
+	if change.PerpetualDiff {
+		notes = append(notes, perpetualDiffLabel)
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " # " + strings.Join(notes, ", ")
+}
+
+// formatSensitiveChange formats a change to a sensitive property without revealing its value
+func (f *Formatter) formatSensitiveChange(change PropertyChange, replacementIndicator string) string {
+	switch change.Action {
+	case actionAdd:
+		return fmt.Sprintf("%s+ %s = %s%s", indent, change.Name, sensitiveValueDisplay(change.AfterHash), replacementIndicator)
+	case actionRemove:
+		return fmt.Sprintf("%s- %s = %s%s", indent, change.Name, sensitiveValueDisplay(change.BeforeHash), replacementIndicator)
+	case actionUpdate:
+		return fmt.Sprintf("%s~ %s = %s%s", indent, change.Name,
+			sensitiveChangeDisplay(change.SensitiveStatus, change.BeforeHash, change.AfterHash), replacementIndicator)
+	default:
+		return ""
+	}
+}
+
+// formatPolicyChange formats a policy document change as a statement-level diff
+func (f *Formatter) formatPolicyChange(change PropertyChange, replacementIndicator string) string {
+	symbol := "~"
+	switch change.Action {
+	case actionAdd:
+		symbol = "+"
+	case actionRemove:
+		symbol = "-"
+	}
+
+	lines := []string{fmt.Sprintf("%s%s %s (policy document)%s", indent, symbol, change.Name, replacementIndicator)}
+	if len(change.PolicyDiff.Statements) == 0 {
+		lines = append(lines, nestedIndent+"(no semantic change)")
+	}
+
+	for _, statement := range change.PolicyDiff.Statements {
+		statementSymbol := "~"
+		switch statement.Action {
+		case actionAdd:
+			statementSymbol = "+"
+		case actionRemove:
+			statementSymbol = "-"
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s (%s)", nestedIndent, statementSymbol, statementLabel(statement), statement.Effect))
+
+		elements := []struct {
+			name    string
+			added   []string
+			removed []string
+		}{
+			{"action", statement.AddedActions, statement.RemovedActions},
+			{"resource", statement.AddedResources, statement.RemovedResources},
+			{"principal", statement.AddedPrincipals, statement.RemovedPrincipals},
+			{"condition", statement.AddedConditions, statement.RemovedConditions},
+		}
+		for _, element := range elements {
+			for _, value := range element.added {
+				lines = append(lines, fmt.Sprintf("%s%s+ %s %s", nestedIndent, indent, element.name, value))
+			}
+			for _, value := range element.removed {
+				lines = append(lines, fmt.Sprintf("%s%s- %s %s", nestedIndent, indent, element.name, value))
+			}
+		}
+	}
+
+	for _, escalation := range change.PolicyDiff.Escalations {
+		lines = append(lines, fmt.Sprintf("%s⚠️ Privilege escalation: %s", nestedIndent, escalation))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatEncodedDocumentChange formats a change to a JSON or YAML encoded string as changes within the document
+func (f *Formatter) formatEncodedDocumentChange(change PropertyChange, replacementIndicator string) string {
+	if change.NoSemanticChange {
+		return fmt.Sprintf("%s~ %s (%s document): no semantic change%s", indent, change.Name, change.EncodedFormat, replacementIndicator)
+	}
+
+	lines := []string{fmt.Sprintf("%s~ %s (%s document)%s", indent, change.Name, change.EncodedFormat, replacementIndicator)}
+	for _, documentChange := range change.EncodedChanges {
+		switch documentChange.Action {
+		case actionAdd:
+			lines = append(lines, fmt.Sprintf("%s+ %s = %s", nestedIndent, documentChange.Name, f.formatValue(documentChange.After, false)))
+		case actionRemove:
+			lines = append(lines, fmt.Sprintf("%s- %s = %s", nestedIndent, documentChange.Name, f.formatValue(documentChange.Before, false)))
+		default:
+			lines = append(lines, fmt.Sprintf("%s~ %s = %s -> %s", nestedIndent, documentChange.Name,
+				f.formatValue(documentChange.Before, false), f.formatValue(documentChange.After, false)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// usesMultilineDiff determines if a property change should be rendered as a unified line diff
+func (f *Formatter) usesMultilineDiff(change PropertyChange) bool {
+	if f.config == nil || !f.config.Plan.MultilineDiff.Enabled {
+		return false
+	}
+	if change.Action != actionUpdate || change.Sensitive || change.IsUnknown {
+		return false
+	}
+	_, beforeIsString := change.Before.(string)
+	_, afterIsString := change.After.(string)
+	return beforeIsString && afterIsString && (isMultilineString(change.Before) || isMultilineString(change.After))
+}
+
+// formatMultilineChange formats a multiline string change as unified diff hunks.
+// Diff lines are kept flush-left so markdown and HTML diff code blocks can be highlighted, coloured for
+// table output with colours, and capped so the diff stays within the configured max_detail_length.
+func (f *Formatter) formatMultilineChange(change PropertyChange, replacementIndicator string) string {
+	before, _ := change.Before.(string)
+	after, _ := change.After.(string)
+
+	header := fmt.Sprintf("%s~ %s (multiline diff)%s", indent, change.Name, replacementIndicator)
+	hunkLines := unifiedDiff(before, after, f.config.Plan.MultilineDiff.ContextLines)
+
+	maxLength := f.config.Plan.ExpandableSections.MaxDetailLength
+	lines := []string{header}
+	length := len(header)
+	for i, line := range hunkLines {
+		if maxLength > 0 && length+len(line)+1 > maxLength {
+			lines = append(lines, fmt.Sprintf("... %d more diff lines", len(hunkLines)-i))
+			break
+		}
+		length += len(line) + 1
+		lines = append(lines, f.colorizeDiffLine(line))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// colorizeDiffLine adds terminal colours to a unified diff line for table output with colours.
+// Other formats are left plain: markdown and HTML mark the diff as a diff code block instead.
+func (f *Formatter) colorizeDiffLine(line string) string {
+	if !f.colors || f.format != formatTable || line == "" {
+		return line
+	}
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return color.CyanString("%s", line)
+	case line[0] == diffLineAdd:
+		return color.GreenString("%s", line)
+	case line[0] == diffLineRemove:
+		return color.RedString("%s", line)
+	default:
+		return line
+	}
+}
+
+// hasMultilineDiff checks if any of the changes will be rendered as a unified line diff
+func (f *Formatter) hasMultilineDiff(changes []PropertyChange) bool {
+	for _, change := range changes {
+		if f.usesMultilineDiff(change) {
+			return true
+		}
+	}
+	return false
+}
+
+// propertyChangesCollapsible creates the collapsible value for a resource's property changes.
+// Markdown and HTML details are wrapped in a diff code fence when a multiline diff is present.
+func (f *Formatter) propertyChangesCollapsible(summary string, propAnalysis PropertyChangeAnalysis, details []string) *output.DefaultCollapsibleValue {
+	shouldExpand := (f.config.Plan.ExpandableSections.AutoExpandDangerous && f.hasSensitive(propAnalysis.Changes)) ||
+		f.config.ExpandAll
+
+	opts := []output.CollapsibleOption{
+		output.WithExpanded(shouldExpand),
+		output.WithMaxLength(f.config.Plan.ExpandableSections.MaxDetailLength),
+	}
+	if (f.format == formatMarkdown || f.format == formatHTML) && f.hasMultilineDiff(propAnalysis.Changes) {
+		opts = append(opts, output.WithCodeFences("diff"))
+	}
+
+	return output.NewCollapsibleValue(summary, strings.Join(details, "\n"), opts...)
+}
+
 // formatValue formats a property value according to Terraform's formatting conventions
 func (f *Formatter) formatValue(val any, sensitive bool) string {
 	return f.formatValueWithContext(val, sensitive, false, "")
@@ -543,6 +837,10 @@ func (f *Formatter) formatValueWithContext(val any, sensitive bool, isNested boo
 		if v == knownAfterApply {
 			return v // Return without quotes to match Terraform's display
 		}
+		// Masked sensitive values within objects are placeholders, not strings
+		if isSensitivePlaceholder(v) {
+			return v
+		}
 		return fmt.Sprintf("%q", v)
 	case map[string]any:
 		if isNested && len(v) > 1 {
@@ -679,10 +977,7 @@ func (f *Formatter) formatNestedObjectChange(change PropertyChange) string {
 	var lines []string
 
 	// Add the opening line with the property name
-	replacementIndicator := ""
-	if change.TriggersReplacement {
-		replacementIndicator = " # forces replacement"
-	}
+	replacementIndicator := propertyChangeIndicator(change)
 	// Use Unicode En spaces (U+2002) for consistent spacing across formats
 	lines = append(lines, fmt.Sprintf("%s~ %s {%s", indent, change.Name, replacementIndicator))
 
@@ -702,7 +997,13 @@ func (f *Formatter) formatNestedObjectChange(change PropertyChange) string {
 			lines = append(lines, fmt.Sprintf("%s- %s = %s", nestedIndent, key, formattedValue))
 		case hasBeforeValue && hasAfterValue:
 			// Check if the value actually changed
-			if !f.valuesEqual(beforeValue, afterValue) {
+			switch {
+			case f.valuesEqual(beforeValue, afterValue):
+				// Unchanged properties are omitted
+			case !change.Sensitive && isSensitivePlaceholder(afterValue):
+				// Changed sensitive values within the object only show that they changed
+				lines = append(lines, fmt.Sprintf("%s~ %s = %s", nestedIndent, key, afterValue))
+			default:
 				// Modified property - use Unicode En spaces for indentation
 				beforeFormatted := f.formatValue(beforeValue, change.Sensitive)
 				afterFormatted := f.formatValue(afterValue, change.Sensitive)
@@ -746,17 +1047,7 @@ func (f *Formatter) prepareResourceTableData(changes []ResourceChange) []map[str
 		propChanges := change.PropertyChanges
 
 		// Determine risk level based on existing danger flags
-		riskLevel := "low"
-		if change.IsDangerous {
-			switch change.ChangeType {
-			case ChangeTypeDelete:
-				riskLevel = "critical"
-			case ChangeTypeReplace:
-				riskLevel = "high"
-			default:
-				riskLevel = "medium"
-			}
-		}
+		riskLevel := change.RiskLevel()
 
 		// Store raw action type for sorting (before decoration)
 		rawActionType := getActionDisplay(change.ChangeType)
@@ -830,17 +1121,21 @@ func (f *Formatter) getReplacementDisplay(change ResourceChange) string {
 	return string(change.ReplacementType)
 }
 
-// getDangerDisplay returns the danger information for display
+// getDangerDisplay returns the danger information and perpetual diff label for display
 func (f *Formatter) getDangerDisplay(change ResourceChange) string {
-	if !change.IsDangerous {
-		return ""
+	var notes []string
+	if change.IsDangerous {
+		dangerInfo := "⚠️ " + change.DangerReason
+		if len(change.DangerProperties) > 0 {
+			dangerInfo += ": " + strings.Join(change.DangerProperties, ", ")
+		}
+		notes = append(notes, dangerInfo)
 	}
-
-	dangerInfo := "⚠️ " + change.DangerReason
-	if len(change.DangerProperties) > 0 {
-		dangerInfo += ": " + strings.Join(change.DangerProperties, ", ")
+	// Perpetual diffs are shown here as well, so reviewers can skip them at a glance
+	if change.PerpetualDiff {
+		notes = append(notes, "🔁 "+perpetualDiffLabel)
 	}
-	return dangerInfo
+	return strings.Join(notes, "; ")
 }
 
 // addResourceChangesWithProgressiveDisclosure adds resource changes with collapsible features to existing builder
@@ -1291,6 +1586,19 @@ func (f *Formatter) createOutputChangesData(summary *PlanSummary) ([]map[string]
 		// Format planned (after) value
 		plannedValue := formatOutputValue(change.After, change.Sensitive, change.IsUnknown)
 
+		// Show whether a sensitive output changed without revealing it
+		if change.Sensitive {
+			if change.Before != nil {
+				currentValue = sensitiveValueDisplay(change.BeforeHash)
+			}
+			switch {
+			case change.SensitiveStatus != "":
+				plannedValue = sensitiveChangeDisplay(change.SensitiveStatus, change.BeforeHash, change.AfterHash)
+			case change.After != nil && !change.IsUnknown:
+				plannedValue = sensitiveValueDisplay(change.AfterHash)
+			}
+		}
+
 		// Format sensitive indicator (requirement 2.4)
 		sensitiveIndicator := ""
 		if change.Sensitive {
@@ -1506,3 +1814,363 @@ func applyDecorations(tableData []map[string]any) {
 		delete(row, "IsDangerous")
 	}
 }
+
+// OutputStateSummary renders a state inventory summary using the same output pipeline as plan summaries
+func (f *Formatter) OutputStateSummary(summary *StateSummary, outputConfig *config.OutputConfiguration, showDetails bool) error {
+	if summary == nil {
+		return fmt.Errorf("state summary cannot be nil")
+	}
+
+	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
+		return err
+	}
+
+	return f.renderDocument(outputConfig, func(formatter *Formatter) (*output.Document, error) {
+		return formatter.buildStateDocument(summary, showDetails)
+	})
+}
+
+// buildStateDocument builds the state summary document with totals, grouped counts, and optionally every resource
+func (f *Formatter) buildStateDocument(summary *StateSummary, showDetails bool) (*output.Document, error) {
+	builder := output.New()
+
+	infoTable, err := output.NewTableContent("State Information", []map[string]any{{
+		"State File": summary.StateFile,
+		"Version":    summary.TerraformVersion,
+		"Format":     summary.FormatVersion,
+	}}, output.WithKeys("State File", "Version", "Format"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state information table: %w", err)
+	}
+	builder = builder.AddContent(infoTable)
+
+	stats := summary.Statistics
+	statsTable, err := output.NewTableContent("State Statistics", []map[string]any{{
+		"Resources":    stats.Resources,
+		"Data Sources": stats.DataSources,
+		"Providers":    stats.Providers,
+		"Modules":      stats.Modules,
+		"Sensitive":    stats.Sensitive,
+		"Tainted":      stats.Tainted,
+		"Outputs":      stats.Outputs,
+	}}, output.WithKeys("Resources", "Data Sources", "Providers", "Modules", "Sensitive", "Tainted", "Outputs"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state statistics table: %w", err)
+	}
+	builder = builder.AddContent(statsTable)
+
+	if len(summary.Resources) == 0 {
+		builder = builder.Text("No managed resources in state")
+		return builder.Build(), nil
+	}
+
+	for _, group := range []struct {
+		title  string
+		key    string
+		counts []StateResourceCount
+	}{
+		{"Resources by Provider", "Provider", summary.ByProvider},
+		{"Resources by Type", "Type", summary.ByType},
+		{"Resources by Module", "Module", summary.ByModule},
+	} {
+		data := make([]map[string]any, 0, len(group.counts))
+		for _, count := range group.counts {
+			data = append(data, map[string]any{
+				group.key:   count.Name,
+				"Count":     count.Count,
+				"Sensitive": count.Sensitive,
+			})
+		}
+		table, err := output.NewTableContent(group.title, data, output.WithKeys(group.key, "Count", "Sensitive"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s table: %w", strings.ToLower(group.title), err)
+		}
+		builder = builder.AddContent(table)
+	}
+
+	// Sensitive resources are always listed so they stand out, even without details
+	resources := summary.Resources
+	title := "Resources"
+	if !showDetails {
+		resources = slices.DeleteFunc(slices.Clone(resources), func(resource StateResourceSummary) bool {
+			return !resource.IsSensitive
+		})
+		title = "Sensitive Resources"
+	}
+	if len(resources) == 0 {
+		return builder.Build(), nil
+	}
+
+	data := make([]map[string]any, 0, len(resources))
+	for _, resource := range resources {
+		var flags []string
+		if resource.IsSensitive {
+			flags = append(flags, "⚠️ Sensitive")
+		}
+		if resource.IsTainted {
+			flags = append(flags, "Tainted")
+		}
+		data = append(data, map[string]any{
+			"Address":  resource.Address,
+			"Type":     resource.Type,
+			"Provider": resource.Provider,
+			"Module":   resource.ModulePath,
+			"Flags":    strings.Join(flags, ", "),
+		})
+	}
+	table, err := output.NewTableContent(title, data, output.WithKeys("Address", "Type", "Provider", "Module", "Flags"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resources table: %w", err)
+	}
+	builder = builder.AddContent(table)
+
+	return builder.Build(), nil
+}
+
+// OutputApplySummary renders an apply summary using the same output pipeline as plan summaries
+func (f *Formatter) OutputApplySummary(summary *ApplySummary, outputConfig *config.OutputConfiguration) error {
+	if summary == nil {
+		return fmt.Errorf("apply summary cannot be nil")
+	}
+
+	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
+		return err
+	}
+
+	return f.renderDocument(outputConfig, func(formatter *Formatter) (*output.Document, error) {
+		return formatter.buildApplyDocument(summary)
+	})
+}
+
+// buildApplyDocument builds the apply summary document with totals, resource results, diagnostics, and the plan reconciliation
+func (f *Formatter) buildApplyDocument(summary *ApplySummary) (*output.Document, error) {
+	builder := output.New()
+
+	status := "Incomplete"
+	switch {
+	case summary.Statistics.Failed > 0 || summary.Statistics.Errors > 0:
+		status = "Failed"
+	case summary.Completed:
+		status = "Complete"
+	}
+	infoTable, err := output.NewTableContent("Apply Information", []map[string]any{{
+		"Log File": summary.LogFile,
+		"Version":  summary.TerraformVersion,
+		"Status":   status,
+		"Duration": formatApplyDuration(summary.Statistics.DurationSeconds),
+	}}, output.WithKeys("Log File", "Version", "Status", "Duration"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create apply information table: %w", err)
+	}
+	builder = builder.AddContent(infoTable)
+
+	stats := summary.Statistics
+	statsTable, err := output.NewTableContent("Apply Statistics", []map[string]any{{
+		"Added":      stats.Added,
+		"Changed":    stats.Changed,
+		"Removed":    stats.Removed,
+		"Imported":   stats.Imported,
+		"Succeeded":  stats.Succeeded,
+		"Failed":     stats.Failed,
+		"Incomplete": stats.Incomplete,
+		"Errors":     stats.Errors,
+		"Warnings":   stats.Warnings,
+	}}, output.WithKeys("Added", "Changed", "Removed", "Imported", "Succeeded", "Failed", "Incomplete", "Errors", "Warnings"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create apply statistics table: %w", err)
+	}
+	builder = builder.AddContent(statsTable)
+
+	if len(summary.Resources) == 0 {
+		builder = builder.Text("No resource operations in apply log")
+	} else {
+		data := make([]map[string]any, 0, len(summary.Resources))
+		for _, result := range summary.Resources {
+			resultStatus := result.Status
+			if result.Status == applyStatusFailed {
+				resultStatus = "⚠️ " + result.Status
+			}
+			data = append(data, map[string]any{
+				"Address":  result.Address,
+				"Action":   result.Action,
+				"Status":   resultStatus,
+				"Duration": formatApplyDuration(result.DurationSeconds),
+				"ID":       result.ID,
+			})
+		}
+		table, err := output.NewTableContent("Resource Results", data, output.WithKeys("Address", "Action", "Status", "Duration", "ID"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create resource results table: %w", err)
+		}
+		builder = builder.AddContent(table)
+	}
+
+	if len(summary.Diagnostics) > 0 {
+		data := make([]map[string]any, 0, len(summary.Diagnostics))
+		for _, diagnostic := range summary.Diagnostics {
+			data = append(data, map[string]any{
+				"Severity": diagnostic.Severity,
+				"Address":  diagnostic.Address,
+				"Summary":  diagnostic.Summary,
+				"Detail":   diagnostic.Detail,
+			})
+		}
+		table, err := output.NewTableContent("Diagnostics", data, output.WithKeys("Severity", "Address", "Summary", "Detail"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create diagnostics table: %w", err)
+		}
+		builder = builder.AddContent(table)
+	}
+
+	if reconciliation := summary.Reconciliation; reconciliation != nil {
+		data := make([]map[string]any, 0, len(reconciliation.NotApplied)+len(reconciliation.Unplanned))
+		for _, change := range reconciliation.NotApplied {
+			data = append(data, map[string]any{
+				"Address":        change.Address,
+				"Planned Action": string(change.ChangeType),
+				"Status":         change.Status,
+			})
+		}
+		for _, address := range reconciliation.Unplanned {
+			data = append(data, map[string]any{
+				"Address":        address,
+				"Planned Action": "-",
+				"Status":         "not planned",
+			})
+		}
+		if len(data) == 0 {
+			builder = builder.Text(fmt.Sprintf("All %d planned changes were applied", reconciliation.Planned))
+		} else {
+			table, err := output.NewTableContent("Plan Reconciliation", data, output.WithKeys("Address", "Planned Action", "Status"))
+			if err != nil {
+				return nil, fmt.Errorf("failed to create plan reconciliation table: %w", err)
+			}
+			builder = builder.AddContent(table)
+		}
+	}
+
+	return builder.Build(), nil
+}
+
+// formatApplyDuration formats a duration in seconds for display, e.g., "1m23s"
+func formatApplyDuration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(100 * time.Millisecond).String()
+}
+
+// OutputHistory renders the recorded plans, one row per plan
+func (f *Formatter) OutputHistory(records []HistoryRecord, outputConfig *config.OutputConfiguration) error {
+	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
+		return err
+	}
+
+	return f.renderDocument(outputConfig, func(formatter *Formatter) (*output.Document, error) {
+		builder := output.New()
+		if len(records) == 0 {
+			return builder.Text("No plans recorded in history").Build(), nil
+		}
+
+		data := make([]map[string]any, 0, len(records))
+		for _, record := range records {
+			summary := record.Summary
+			commit := record.Git.Commit
+			if len(commit) > 8 {
+				commit = commit[:8]
+			}
+			if record.Git.Dirty {
+				commit += " (dirty)"
+			}
+			data = append(data, map[string]any{
+				"Recorded":  record.RecordedAt.Local().Format("2006-01-02 15:04:05"),
+				"Root":      record.Root,
+				"Workspace": summary.Workspace,
+				"Backend":   fmt.Sprintf("%s (%s)", summary.Backend.Type, summary.Backend.Location),
+				"Commit":    commit,
+				"Branch":    record.Git.Branch,
+				"Added":     summary.Statistics.ToAdd,
+				"Changed":   summary.Statistics.ToChange,
+				"Destroyed": summary.Statistics.ToDestroy,
+				"Replaced":  summary.Statistics.Replacements,
+				"High Risk": summary.Statistics.HighRisk,
+			})
+		}
+		table, err := output.NewTableContent("Plan History", data, output.WithKeys(
+			"Recorded", "Root", "Workspace", "Backend", "Commit", "Branch", "Added", "Changed", "Destroyed", "Replaced", "High Risk"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create plan history table: %w", err)
+		}
+		return builder.AddContent(table).Build(), nil
+	})
+}
+
+// OutputHistoryTrends renders the change trends of every root module in the history
+func (f *Formatter) OutputHistoryTrends(trends []HistoryTrend, outputConfig *config.OutputConfiguration) error {
+	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
+		return err
+	}
+
+	return f.renderDocument(outputConfig, func(formatter *Formatter) (*output.Document, error) {
+		builder := output.New()
+		if len(trends) == 0 {
+			return builder.Text("No plans recorded in history").Build(), nil
+		}
+
+		data := make([]map[string]any, 0, len(trends))
+		for _, trend := range trends {
+			data = append(data, map[string]any{
+				"Root":          trend.Root,
+				"Runs":          trend.Runs,
+				"Last Run":      trend.LastRun.Local().Format("2006-01-02 15:04:05"),
+				"Added":         trend.Latest.ToAdd,
+				"Changed":       trend.Latest.ToChange,
+				"Destroyed":     trend.Latest.ToDestroy,
+				"High Risk":     trend.Latest.HighRisk,
+				"Avg Added":     fmt.Sprintf("%.1f", trend.Average.ToAdd),
+				"Avg Changed":   fmt.Sprintf("%.1f", trend.Average.ToChange),
+				"Avg Destroyed": fmt.Sprintf("%.1f", trend.Average.ToDestroy),
+				"Avg High Risk": fmt.Sprintf("%.1f", trend.Average.HighRisk),
+				"Trend":         trend.Direction,
+			})
+		}
+		table, err := output.NewTableContent("History Trends", data, output.WithKeys(
+			"Root", "Runs", "Last Run", "Added", "Changed", "Destroyed", "High Risk",
+			"Avg Added", "Avg Changed", "Avg Destroyed", "Avg High Risk", "Trend"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create history trends table: %w", err)
+		}
+		return builder.AddContent(table).Build(), nil
+	})
+}
+
+// OutputQueryResults renders the resource changes that matched a query
+func (f *Formatter) OutputQueryResults(changes []ResourceChange, outputConfig *config.OutputConfiguration) error {
+	if err := f.ValidateOutputFormat(outputConfig.Format); err != nil {
+		return err
+	}
+
+	return f.renderDocument(outputConfig, func(formatter *Formatter) (*output.Document, error) {
+		builder := output.New()
+		if len(changes) == 0 {
+			return builder.Text("No resource changes match the query").Build(), nil
+		}
+
+		data := make([]map[string]any, 0, len(changes))
+		for _, change := range changes {
+			data = append(data, map[string]any{
+				"Resource":   change.Address,
+				"Action":     getActionDisplay(change.ChangeType),
+				"Type":       change.Type,
+				"Provider":   change.Provider,
+				"Module":     change.ModulePath,
+				"Risk":       change.RiskLevel(),
+				"Danger":     change.DangerReason,
+				"Properties": len(change.PropertyChanges.Changes),
+			})
+		}
+		table, err := output.NewTableContent("Query Results", data, output.WithKeys(
+			"Resource", "Action", "Type", "Provider", "Module", "Risk", "Danger", "Properties"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create query results table: %w", err)
+		}
+		return builder.AddContent(table).Build(), nil
+	})
+}
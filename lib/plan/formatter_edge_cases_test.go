@@ -362,13 +362,14 @@ func TestFormatPropertyChange(t *testing.T) {
 		{
 			name: "update action with sensitive values",
 			change: PropertyChange{
-				Name:      "password",
-				Action:    "update",
-				Before:    "old_secret",
-				After:     "new_secret",
-				Sensitive: true,
+				Name:            "password",
+				Action:          "update",
+				Before:          "old_secret",
+				After:           "new_secret",
+				Sensitive:       true,
+				SensitiveStatus: sensitiveStatusChanged,
 			},
-			expected: `  ~ password = (sensitive value) -> (sensitive value)`,
+			expected: `  ~ password = (sensitive, changed)`,
 		},
 		{
 			name: "add action with number value",
@@ -0,0 +1,225 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// historyFileName is the JSON-lines file in the history directory that holds the recorded plans
+	historyFileName = "history.jsonl"
+
+	// Directions of a history trend
+	trendIncreasing = "increasing"
+	trendDecreasing = "decreasing"
+	trendStable     = "stable"
+)
+
+// HistoryStore records plan summaries in a JSON-lines file so they can be compared over time
+type HistoryStore struct {
+	dir string
+}
+
+// NewHistoryStore creates a history store in the given directory
+func NewHistoryStore(dir string) *HistoryStore {
+	return &HistoryStore{
+		dir: dir,
+	}
+}
+
+// NewHistoryRecord creates a record for a plan summary, with the root module and git metadata of workDir
+func NewHistoryRecord(summary *PlanSummary, workDir string) HistoryRecord {
+	recordedAt := time.Now().UTC()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	root, git := gitMetadata(workDir)
+	return HistoryRecord{
+		ID:         recordedAt.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		RecordedAt: recordedAt,
+		Root:       root,
+		Git:        git,
+		Summary:    summary,
+	}
+}
+
+// Record appends a record to the history store
+func (s *HistoryStore) Record(record HistoryRecord) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(s.dir, historyFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	// A single write per record keeps concurrent appends from interleaving
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history record: %w", err)
+	}
+	return nil
+}
+
+// List returns the recorded plans, oldest first. When root is set, only plans of that root module are returned.
+func (s *HistoryStore) List(root string) ([]HistoryRecord, error) {
+	file, err := os.Open(filepath.Join(s.dir, historyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []HistoryRecord{}, nil
+		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	// Records contain full summaries, so lines are read without a size limit
+	records := []HistoryRecord{}
+	reader := bufio.NewReader(file)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read history file: %w", err)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var record HistoryRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return nil, fmt.Errorf("failed to parse history record on line %d: %w", lineNumber, err)
+			}
+			if record.Summary != nil && (root == "" || record.Root == root) {
+				records = append(records, record)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	slices.SortStableFunc(records, func(x, y HistoryRecord) int {
+		return x.RecordedAt.Compare(y.RecordedAt)
+	})
+	return records, nil
+}
+
+// gitMetadata returns the root module name and git metadata for a directory.
+// Inside a git repository the root is the directory relative to the repository, prefixed with the repository name.
+func gitMetadata(dir string) (string, GitInfo) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		root = dir
+	}
+
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		out, err := cmd.Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	topLevel := git("rev-parse", "--show-toplevel")
+	if topLevel == "" {
+		return root, GitInfo{}
+	}
+	// git reports the top level with symlinks resolved, e.g., /private/var on macOS
+	resolved := root
+	if path, err := filepath.EvalSymlinks(root); err == nil {
+		resolved = path
+	}
+	if rel, err := filepath.Rel(topLevel, resolved); err == nil {
+		root = filepath.ToSlash(filepath.Join(filepath.Base(topLevel), rel))
+	}
+
+	return root, GitInfo{
+		Commit: git("rev-parse", "HEAD"),
+		Branch: git("rev-parse", "--abbrev-ref", "HEAD"),
+		Dirty:  git("status", "--porcelain") != "",
+	}
+}
+
+// HistoryTrends summarises recorded plans per root module. Records must be sorted oldest first, as returned by List.
+func HistoryTrends(records []HistoryRecord) []HistoryTrend {
+	byRoot := map[string][]HistoryRecord{}
+	for _, record := range records {
+		byRoot[record.Root] = append(byRoot[record.Root], record)
+	}
+
+	trends := make([]HistoryTrend, 0, len(byRoot))
+	for root, runs := range byRoot {
+		trend := HistoryTrend{
+			Root:     root,
+			Runs:     len(runs),
+			FirstRun: runs[0].RecordedAt,
+			LastRun:  runs[len(runs)-1].RecordedAt,
+			Latest:   runs[len(runs)-1].Summary.Statistics,
+		}
+
+		for _, run := range runs {
+			stats := run.Summary.Statistics
+			trend.Average.ToAdd += float64(stats.ToAdd)
+			trend.Average.ToChange += float64(stats.ToChange)
+			trend.Average.ToDestroy += float64(stats.ToDestroy)
+			trend.Average.Replacements += float64(stats.Replacements)
+			trend.Average.HighRisk += float64(stats.HighRisk)
+		}
+		count := float64(len(runs))
+		trend.Average.ToAdd /= count
+		trend.Average.ToChange /= count
+		trend.Average.ToDestroy /= count
+		trend.Average.Replacements /= count
+		trend.Average.HighRisk /= count
+
+		trend.Direction = trendDirection(runs)
+		trends = append(trends, trend)
+	}
+
+	slices.SortFunc(trends, func(x, y HistoryTrend) int {
+		return cmp.Compare(x.Root, y.Root)
+	})
+	return trends
+}
+
+// trendDirection compares the total changes in the latest plan with the average of the earlier plans
+func trendDirection(runs []HistoryRecord) string {
+	if len(runs) < 2 {
+		return ""
+	}
+
+	total := func(stats ChangeStatistics) float64 {
+		return float64(stats.ToAdd + stats.ToChange + stats.ToDestroy + stats.Replacements)
+	}
+	var earlier float64
+	for _, run := range runs[:len(runs)-1] {
+		earlier += total(run.Summary.Statistics)
+	}
+	earlier /= float64(len(runs) - 1)
+
+	latest := total(runs[len(runs)-1].Summary.Statistics)
+	switch {
+	case latest > earlier:
+		return trendIncreasing
+	case latest < earlier:
+		return trendDecreasing
+	default:
+		return trendStable
+	}
+}
@@ -0,0 +1,137 @@
+package plan
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func historyRecord(root string, recordedAt time.Time, stats ChangeStatistics) HistoryRecord {
+	return HistoryRecord{
+		ID:         recordedAt.Format(time.RFC3339),
+		RecordedAt: recordedAt,
+		Root:       root,
+		Summary:    &PlanSummary{Workspace: "default", Statistics: stats},
+	}
+}
+
+func TestHistoryStore_RecordAndList(t *testing.T) {
+	store := NewHistoryStore(filepath.Join(t.TempDir(), "history"))
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	// Records are returned oldest first, regardless of the order they were written in
+	require.NoError(t, store.Record(historyRecord("infra/prod", base.Add(time.Hour), ChangeStatistics{ToAdd: 2})))
+	require.NoError(t, store.Record(historyRecord("infra/prod", base, ChangeStatistics{ToAdd: 1})))
+	require.NoError(t, store.Record(historyRecord("infra/dev", base.Add(2*time.Hour), ChangeStatistics{ToChange: 3})))
+
+	records, err := store.List("")
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, 1, records[0].Summary.Statistics.ToAdd)
+	assert.Equal(t, 2, records[1].Summary.Statistics.ToAdd)
+	assert.Equal(t, "infra/dev", records[2].Root)
+
+	prod, err := store.List("infra/prod")
+	require.NoError(t, err)
+	assert.Len(t, prod, 2)
+}
+
+func TestHistoryStore_List(t *testing.T) {
+	t.Run("empty store", func(t *testing.T) {
+		records, err := NewHistoryStore(filepath.Join(t.TempDir(), "missing")).List("")
+		require.NoError(t, err)
+		assert.Empty(t, records)
+	})
+
+	t.Run("malformed record", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, historyFileName), []byte("{\"id\":\"a\",\"summary\":{}}\n\nnot json\n"), 0o600))
+		_, err := NewHistoryStore(dir).List("")
+		assert.ErrorContains(t, err, "failed to parse history record on line 3")
+	})
+}
+
+func TestNewHistoryRecord(t *testing.T) {
+	summary := &PlanSummary{Workspace: "prod"}
+
+	t.Run("outside a git repository", func(t *testing.T) {
+		dir := t.TempDir()
+		record := NewHistoryRecord(summary, dir)
+		assert.Equal(t, dir, record.Root)
+		assert.Equal(t, GitInfo{}, record.Git)
+		assert.Same(t, summary, record.Summary)
+		assert.NotEmpty(t, record.ID)
+	})
+
+	t.Run("inside a git repository", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git is not available")
+		}
+		repo := filepath.Join(t.TempDir(), "infrastructure")
+		rootModule := filepath.Join(repo, "envs", "prod")
+		require.NoError(t, os.MkdirAll(rootModule, 0o750))
+		for _, args := range [][]string{
+			{"init", "--quiet", "--initial-branch=main"},
+			{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "--allow-empty", "-m", "initial"},
+		} {
+			cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+			out, err := cmd.CombinedOutput()
+			require.NoError(t, err, string(out))
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(rootModule, "main.tf"), []byte(""), 0o600))
+
+		record := NewHistoryRecord(summary, rootModule)
+		assert.Equal(t, "infrastructure/envs/prod", record.Root)
+		assert.Len(t, record.Git.Commit, 40)
+		assert.Equal(t, "main", record.Git.Branch)
+		assert.True(t, record.Git.Dirty)
+	})
+}
+
+func TestHistoryTrends(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	records := []HistoryRecord{
+		historyRecord("prod", base, ChangeStatistics{ToAdd: 2, HighRisk: 1}),
+		historyRecord("dev", base, ChangeStatistics{ToChange: 1}),
+		historyRecord("prod", base.Add(time.Hour), ChangeStatistics{ToAdd: 4, ToDestroy: 1, HighRisk: 2}),
+		historyRecord("prod", base.Add(2*time.Hour), ChangeStatistics{ToAdd: 1}),
+	}
+
+	trends := HistoryTrends(records)
+	require.Len(t, trends, 2)
+
+	dev := trends[0]
+	assert.Equal(t, "dev", dev.Root)
+	assert.Equal(t, 1, dev.Runs)
+	assert.Empty(t, dev.Direction, "a single plan has no trend")
+
+	prod := trends[1]
+	assert.Equal(t, "prod", prod.Root)
+	assert.Equal(t, 3, prod.Runs)
+	assert.Equal(t, base, prod.FirstRun)
+	assert.Equal(t, base.Add(2*time.Hour), prod.LastRun)
+	assert.Equal(t, 1, prod.Latest.ToAdd)
+	assert.InDelta(t, 7.0/3, prod.Average.ToAdd, 0.001)
+	assert.InDelta(t, 1.0, prod.Average.HighRisk, 0.001)
+	assert.Equal(t, trendDecreasing, prod.Direction)
+}
+
+func TestTrendDirection(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	runs := func(totals ...int) []HistoryRecord {
+		records := make([]HistoryRecord, 0, len(totals))
+		for i, total := range totals {
+			records = append(records, historyRecord("prod", base.Add(time.Duration(i)*time.Hour), ChangeStatistics{ToChange: total}))
+		}
+		return records
+	}
+
+	assert.Equal(t, trendIncreasing, trendDirection(runs(1, 3, 5)))
+	assert.Equal(t, trendDecreasing, trendDirection(runs(4, 4, 1)))
+	assert.Equal(t, trendStable, trendDirection(runs(2, 4, 3)))
+}
@@ -0,0 +1,199 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	diffLineContext = ' '
+	diffLineAdd     = '+'
+	diffLineRemove  = '-'
+
+	// maxLineDiffCells caps the size of the LCS table; larger inputs fall back to a full remove/add diff
+	maxLineDiffCells = 4 * 1024 * 1024
+)
+
+// diffOp is a single line in a line-based diff, with its position in the before and after texts
+type diffOp struct {
+	kind        byte
+	text        string
+	beforeIndex int // number of before lines preceding this line
+	afterIndex  int // number of after lines preceding this line
+}
+
+// isMultilineString reports whether a value is a string spanning more than one line
+func isMultilineString(value any) bool {
+	str, ok := value.(string)
+	return ok && strings.Contains(strings.TrimRight(str, "\n"), "\n")
+}
+
+// splitLines splits text into lines, ignoring a single trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a line-based diff between two texts using the longest common subsequence.
+// Common leading and trailing lines are trimmed first, which keeps typical edits cheap.
+func diffLines(before, after string) []diffOp {
+	beforeLines := splitLines(before)
+	afterLines := splitLines(after)
+
+	prefix := 0
+	for prefix < len(beforeLines) && prefix < len(afterLines) && beforeLines[prefix] == afterLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(beforeLines)-prefix && suffix < len(afterLines)-prefix &&
+		beforeLines[len(beforeLines)-1-suffix] == afterLines[len(afterLines)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(beforeLines)+len(afterLines))
+	beforeIndex, afterIndex := 0, 0
+	appendOp := func(kind byte, text string) {
+		ops = append(ops, diffOp{kind: kind, text: text, beforeIndex: beforeIndex, afterIndex: afterIndex})
+		if kind != diffLineAdd {
+			beforeIndex++
+		}
+		if kind != diffLineRemove {
+			afterIndex++
+		}
+	}
+
+	for _, line := range beforeLines[:prefix] {
+		appendOp(diffLineContext, line)
+	}
+
+	middleBefore := beforeLines[prefix : len(beforeLines)-suffix]
+	middleAfter := afterLines[prefix : len(afterLines)-suffix]
+	for _, op := range diffLinesLCS(middleBefore, middleAfter) {
+		appendOp(op.kind, op.text)
+	}
+
+	for _, line := range beforeLines[len(beforeLines)-suffix:] {
+		appendOp(diffLineContext, line)
+	}
+
+	return ops
+}
+
+// diffLinesLCS diffs two line slices with a dynamic-programming LCS.
+// Only kind and text are set on the returned operations.
+func diffLinesLCS(before, after []string) []diffOp {
+	n, m := len(before), len(after)
+	ops := make([]diffOp, 0, n+m)
+
+	if (n+1)*(m+1) > maxLineDiffCells {
+		for _, line := range before {
+			ops = append(ops, diffOp{kind: diffLineRemove, text: line})
+		}
+		for _, line := range after {
+			ops = append(ops, diffOp{kind: diffLineAdd, text: line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] holds the LCS length of before[i:] and after[j:]
+	lcs := make([][]int32, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case before[i] == after[j]:
+			ops = append(ops, diffOp{kind: diffLineContext, text: before[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: diffLineRemove, text: before[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: diffLineAdd, text: after[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{kind: diffLineRemove, text: before[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{kind: diffLineAdd, text: after[j]})
+	}
+
+	return ops
+}
+
+// unifiedDiff renders the line diff between two texts as unified diff hunks with the given context size.
+// Each returned line starts with "@@", "+", "-", or a space, matching the unified diff format.
+func unifiedDiff(before, after string, contextLines int) []string {
+	ops := diffLines(before, after)
+	contextLines = max(contextLines, 0)
+
+	var lines []string
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == diffLineContext {
+			i++
+			continue
+		}
+
+		// Extend the hunk while the next change is close enough to share context
+		start := max(0, i-contextLines)
+		lastChange := i
+		for j := i + 1; j < len(ops); j++ {
+			if ops[j].kind != diffLineContext {
+				lastChange = j
+			} else if j-lastChange > 2*contextLines {
+				break
+			}
+		}
+		stop := min(len(ops), lastChange+contextLines+1)
+
+		lines = append(lines, hunkHeader(ops[start:stop]))
+		for _, op := range ops[start:stop] {
+			lines = append(lines, string(op.kind)+op.text)
+		}
+		i = stop
+	}
+
+	return lines
+}
+
+// hunkHeader returns the "@@ -a,b +c,d @@" header for a hunk
+func hunkHeader(hunk []diffOp) string {
+	beforeCount, afterCount := 0, 0
+	for _, op := range hunk {
+		if op.kind != diffLineAdd {
+			beforeCount++
+		}
+		if op.kind != diffLineRemove {
+			afterCount++
+		}
+	}
+
+	// Unified diff line numbers are 1-based, except for empty ranges which refer to the preceding line
+	beforeStart := hunk[0].beforeIndex
+	if beforeCount > 0 {
+		beforeStart++
+	}
+	afterStart := hunk[0].afterIndex
+	if afterCount > 0 {
+		afterStart++
+	}
+
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", beforeStart, beforeCount, afterStart, afterCount)
+}
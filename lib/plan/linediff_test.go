@@ -0,0 +1,181 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		context  int
+		expected []string
+	}{
+		{
+			name:     "identical text has no hunks",
+			before:   "a\nb\nc",
+			after:    "a\nb\nc",
+			context:  3,
+			expected: nil,
+		},
+		{
+			name:    "single changed line with context",
+			before:  "#!/bin/bash\necho start\nexport ENV=dev\necho done\n",
+			after:   "#!/bin/bash\necho start\nexport ENV=prod\necho done\n",
+			context: 1,
+			expected: []string{
+				"@@ -2,3 +2,3 @@",
+				" echo start",
+				"-export ENV=dev",
+				"+export ENV=prod",
+				" echo done",
+			},
+		},
+		{
+			name:    "distant changes produce separate hunks",
+			before:  "1\n2\n3\n4\n5\n6\n7\n8\n9",
+			after:   "one\n2\n3\n4\n5\n6\n7\n8\nnine",
+			context: 1,
+			expected: []string{
+				"@@ -1,2 +1,2 @@",
+				"-1",
+				"+one",
+				" 2",
+				"@@ -8,2 +8,2 @@",
+				" 8",
+				"-9",
+				"+nine",
+			},
+		},
+		{
+			name:    "nearby changes share a hunk",
+			before:  "1\n2\n3\n4",
+			after:   "one\n2\n3\nfour",
+			context: 1,
+			expected: []string{
+				"@@ -1,4 +1,4 @@",
+				"-1",
+				"+one",
+				" 2",
+				" 3",
+				"-4",
+				"+four",
+			},
+		},
+		{
+			name:    "added lines with zero context",
+			before:  "a\nc",
+			after:   "a\nb\nc",
+			context: 0,
+			expected: []string{
+				"@@ -1,0 +2,1 @@",
+				"+b",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, unifiedDiff(tt.before, tt.after, tt.context))
+		})
+	}
+}
+
+func TestIsMultilineString(t *testing.T) {
+	assert.True(t, isMultilineString("line1\nline2"))
+	assert.False(t, isMultilineString("single line\n"))
+	assert.False(t, isMultilineString("single line"))
+	assert.False(t, isMultilineString(42))
+}
+
+func TestFormatPropertyChange_MultilineDiff(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.MultilineDiff.ContextLines = 1
+	formatter := NewFormatter(cfg)
+
+	change := PropertyChange{
+		Name:   "user_data",
+		Action: actionUpdate,
+		Before: "#!/bin/bash\nyum update -y\nsystemctl start httpd\n",
+		After:  "#!/bin/bash\nyum update -y\nsystemctl start nginx\n",
+	}
+
+	formatted := formatter.formatPropertyChange(change)
+	assert.Equal(t, strings.Join([]string{
+		indent + "~ user_data (multiline diff)",
+		"@@ -2,2 +2,2 @@",
+		" yum update -y",
+		"-systemctl start httpd",
+		"+systemctl start nginx",
+	}, "\n"), formatted)
+
+	t.Run("disabled falls back to whole values", func(t *testing.T) {
+		disabledCfg := config.GetDefaultConfig()
+		disabledCfg.Plan.MultilineDiff.Enabled = false
+		formatted := NewFormatter(disabledCfg).formatPropertyChange(change)
+		assert.NotContains(t, formatted, "@@")
+		assert.Contains(t, formatted, `"#!/bin/bash\nyum update -y\nsystemctl start httpd\n"`)
+	})
+
+	t.Run("sensitive values are never diffed", func(t *testing.T) {
+		sensitive := change
+		sensitive.Sensitive = true
+		formatted := formatter.formatPropertyChange(sensitive)
+		assert.NotContains(t, formatted, "httpd")
+	})
+
+	t.Run("diff is capped by max detail length", func(t *testing.T) {
+		cappedCfg := config.GetDefaultConfig()
+		cappedCfg.Plan.ExpandableSections.MaxDetailLength = 60
+		var before, after []string
+		for i := range 20 {
+			before = append(before, strings.Repeat("x", i))
+			after = append(after, strings.Repeat("y", i))
+		}
+		formatted := NewFormatter(cappedCfg).formatPropertyChange(PropertyChange{
+			Name:   "template",
+			Action: actionUpdate,
+			Before: strings.Join(before, "\n"),
+			After:  strings.Join(after, "\n"),
+		})
+		assert.LessOrEqual(t, len(formatted), 60+len("\n... 99 more diff lines"))
+		assert.Contains(t, formatted, "more diff lines")
+	})
+}
+
+func TestPropertyChangesCollapsible_DiffCodeFences(t *testing.T) {
+	formatter := NewFormatter(config.GetDefaultConfig())
+	analysis := PropertyChangeAnalysis{
+		Changes: []PropertyChange{{
+			Name:   "user_data",
+			Action: actionUpdate,
+			Before: "a\nb",
+			After:  "a\nc",
+		}},
+		Count: 1,
+	}
+
+	markdownValue := formatter.withFormat(formatMarkdown, false).propertyChangesCollapsible("1 properties changed", analysis, nil)
+	assert.True(t, markdownValue.UseCodeFences())
+	assert.Equal(t, "diff", markdownValue.CodeLanguage())
+
+	tableValue := formatter.withFormat(formatTable, true).propertyChangesCollapsible("1 properties changed", analysis, nil)
+	assert.False(t, tableValue.UseCodeFences())
+}
+
+func TestColorizeDiffLine(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	formatter := NewFormatter(config.GetDefaultConfig())
+	assert.Contains(t, formatter.withFormat(formatTable, true).colorizeDiffLine("+added"), "\033[")
+	assert.Equal(t, "+added", formatter.withFormat(formatTable, false).colorizeDiffLine("+added"), "colours are disabled")
+	assert.Equal(t, "-removed", formatter.withFormat(formatHTML, true).colorizeDiffLine("-removed"), "only table output is coloured")
+}
@@ -1,6 +1,7 @@
 package plan
 
 import (
+	"strings"
 	"time"
 
 	tfjson "github.com/hashicorp/terraform-json"
@@ -53,16 +54,34 @@ type ResourceChange struct {
 	// New fields for unknown values (requirement 1.2, 1.5)
 	HasUnknownValues  bool     `json:"has_unknown_values"` // Whether resource contains unknown properties (requirement 1.2)
 	UnknownProperties []string `json:"unknown_properties"` // List of unknown property paths (requirement 1.5)
+	// Secrets detected (and redacted) in values that weren't marked as sensitive
+	SecretFindings []SecretFinding `json:"secret_findings,omitempty"`
+	// Set when every property change appeared identically in the configured number of consecutive plans
+	PerpetualDiff bool `json:"perpetual_diff,omitempty"`
+	// Addresses of the other changed resources this resource references in the configuration
+	Dependencies []string `json:"dependencies,omitempty"`
 	// Field for no-op filtering (Output Refinements feature)
-	IsNoOp bool `json:"-"` // Internal: true for no-op resources
+	IsNoOp bool `json:"is_no_op"` // True for no-op resources
+	// Keyed digest of the real sensitive values, which approvals cover. It's empty without a hash salt and
+	// never written out, as digests of low-entropy values could be guessed offline.
+	SensitiveDigest    string `json:"-"`
+	HasSensitiveValues bool   `json:"-"` // Whether any before or after value is sensitive
+}
+
+// SecretFinding records a secret that was detected in a non-sensitive value and redacted
+type SecretFinding struct {
+	Property string `json:"property"` // Path of the property containing the secret
+	Rule     string `json:"rule"`     // Name of the rule that detected the secret
 }
 
 // PlanSummary contains the summarised information from a Terraform plan
 type PlanSummary struct {
+	SchemaVersion    string           `json:"schema_version"` // Version of this JSON format, see SummarySchemaVersion
 	FormatVersion    string           `json:"format_version"`
 	TerraformVersion string           `json:"terraform_version"`
 	PlanFile         string           `json:"plan_file"`
 	Workspace        string           `json:"workspace"`
+	RootModule       string           `json:"root_module,omitempty"` // Absolute directory of a local plan file
 	Backend          BackendInfo      `json:"backend"`
 	CreatedAt        time.Time        `json:"created_at"`
 	ResourceChanges  []ResourceChange `json:"resource_changes"`
@@ -82,7 +101,11 @@ type OutputChange struct {
 	Action    string `json:"action"`     // "Add", "Modify", "Remove" actions (requirements 2.5, 2.6, 2.7)
 	Indicator string `json:"indicator"`  // "+", "~", "-" visual indicators (requirements 2.5, 2.6, 2.7)
 	// Field for no-op filtering (Output Refinements feature)
-	IsNoOp bool `json:"-"` // Internal: true if before equals after
+	IsNoOp bool `json:"is_no_op"` // True if before equals after
+	// Change status of sensitive values, which are compared but never shown
+	SensitiveStatus string `json:"sensitive_status,omitempty"` // "changed" or "unchanged" for updated sensitive outputs
+	BeforeHash      string `json:"before_hash,omitempty"`      // Salted short hash of the sensitive before value, when enabled
+	AfterHash       string `json:"after_hash,omitempty"`       // Salted short hash of the sensitive after value, when enabled
 }
 
 // BackendInfo contains information about the Terraform backend
@@ -150,6 +173,39 @@ func FromTerraformAction(actions tfjson.Actions) ChangeType {
 	}
 }
 
+// RiskLevel returns the risk level of a resource change based on its danger flag:
+// dangerous deletions are "critical", dangerous replacements "high", other dangerous
+// changes "medium", and everything else "low"
+func (rc ResourceChange) RiskLevel() string {
+	if !rc.IsDangerous {
+		return riskLevelLow
+	}
+	switch rc.ChangeType {
+	case ChangeTypeDelete:
+		return riskLevelCritical
+	case ChangeTypeReplace:
+		return riskLevelHigh
+	default:
+		return riskLevelMedium
+	}
+}
+
+// RiskLevelRank orders risk levels from "low" (0) to "critical" (3). It returns -1 for unknown levels.
+func RiskLevelRank(level string) int {
+	switch strings.ToLower(level) {
+	case riskLevelLow:
+		return 0
+	case riskLevelMedium:
+		return 1
+	case riskLevelHigh:
+		return 2
+	case riskLevelCritical:
+		return 3
+	default:
+		return -1
+	}
+}
+
 // ResourceAnalysis contains comprehensive analysis results for a single resource
 // This is used for progressive disclosure with go-output v2 collapsible sections
 type ResourceAnalysis struct {
@@ -179,6 +235,40 @@ type PropertyChange struct {
 	// New fields for unknown values (requirement 1.6)
 	IsUnknown   bool   `json:"is_unknown"`   // Whether this property has unknown values
 	UnknownType string `json:"unknown_type"` // "before", "after", "both" to track unknown states (requirement 1.7)
+	// Statement-level diff for JSON policy documents (IAM, bucket policies, etc.)
+	PolicyDiff *PolicyDiff `json:"policy_diff,omitempty"`
+	// Structural diff for string values that hold JSON or YAML documents
+	EncodedFormat    string           `json:"encoded_format,omitempty"`     // "json" or "yaml" when both values decode as documents
+	EncodedChanges   []PropertyChange `json:"encoded_changes,omitempty"`    // Changes within the decoded documents
+	NoSemanticChange bool             `json:"no_semantic_change,omitempty"` // True when only whitespace or key order changed
+	// Change status of sensitive values, which are compared but never shown
+	SensitiveStatus string `json:"sensitive_status,omitempty"` // "changed" or "unchanged" for updated sensitive properties
+	BeforeHash      string `json:"before_hash,omitempty"`      // Salted short hash of the sensitive before value, when enabled
+	AfterHash       string `json:"after_hash,omitempty"`       // Salted short hash of the sensitive after value, when enabled
+	// Set when the same change appeared in the configured number of consecutive plans
+	PerpetualDiff bool `json:"perpetual_diff,omitempty"`
+}
+
+// PolicyDiff describes the statement-level differences between two policy documents
+type PolicyDiff struct {
+	Statements  []StatementDiff `json:"statements"`            // Statements that were added, removed, or updated
+	Escalations []string        `json:"escalations,omitempty"` // Human-readable privilege escalation findings
+}
+
+// StatementDiff describes the changes to a single policy statement.
+// Statements are matched by Sid, or by their full content when no Sid is present.
+type StatementDiff struct {
+	Sid               string   `json:"sid,omitempty"`
+	Effect            string   `json:"effect"`
+	Action            string   `json:"action"` // "add", "remove", "update"
+	AddedActions      []string `json:"added_actions,omitempty"`
+	RemovedActions    []string `json:"removed_actions,omitempty"`
+	AddedResources    []string `json:"added_resources,omitempty"`
+	RemovedResources  []string `json:"removed_resources,omitempty"`
+	AddedPrincipals   []string `json:"added_principals,omitempty"`
+	RemovedPrincipals []string `json:"removed_principals,omitempty"`
+	AddedConditions   []string `json:"added_conditions,omitempty"`
+	RemovedConditions []string `json:"removed_conditions,omitempty"`
 }
 
 // PerformanceLimits defines memory and processing limits for analysis
@@ -189,3 +279,166 @@ type PerformanceLimits struct {
 	MaxDependencyDepth       int   `json:"max_dependency_depth"`        // Default: 10
 	MaxResourcesPerGroup     int   `json:"max_resources_per_group"`     // Default: 1000
 }
+
+// StateSummary is an inventory of the resources managed by a Terraform state
+type StateSummary struct {
+	StateFile        string                 `json:"state_file"`
+	FormatVersion    string                 `json:"format_version"`
+	TerraformVersion string                 `json:"terraform_version"`
+	Resources        []StateResourceSummary `json:"resources"`   // Managed resources, sorted by address
+	ByProvider       []StateResourceCount   `json:"by_provider"` // Resource counts per provider, largest first
+	ByType           []StateResourceCount   `json:"by_type"`     // Resource counts per resource type, largest first
+	ByModule         []StateResourceCount   `json:"by_module"`   // Resource counts per module path, largest first
+	Statistics       StateStatistics        `json:"statistics"`
+}
+
+// StateResourceSummary describes a single managed resource instance in a state
+type StateResourceSummary struct {
+	Address     string `json:"address"`
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Provider    string `json:"provider"`     // Short provider name, e.g., "aws"
+	ModulePath  string `json:"module_path"`  // Module hierarchy, or "-" for the root module
+	IsSensitive bool   `json:"is_sensitive"` // Whether the resource type is in sensitive_resources
+	IsTainted   bool   `json:"is_tainted"`   // Whether the instance is marked as tainted
+}
+
+// StateResourceCount is the number of resources in a single provider, type, or module group
+type StateResourceCount struct {
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+	Sensitive int    `json:"sensitive"` // Number of those resources that are sensitive
+}
+
+// StateStatistics holds the totals for a state summary
+type StateStatistics struct {
+	Resources   int `json:"resources"`    // Managed resource instances
+	DataSources int `json:"data_sources"` // Data source instances
+	Providers   int `json:"providers"`
+	Modules     int `json:"modules"` // Modules containing managed resources, including the root module
+	Sensitive   int `json:"sensitive"`
+	Tainted     int `json:"tainted"`
+	Outputs     int `json:"outputs"`
+}
+
+// ApplySummary describes the outcome of a terraform apply, built from its machine-readable log
+type ApplySummary struct {
+	LogFile          string                `json:"log_file"`
+	TerraformVersion string                `json:"terraform_version"`
+	Operation        string                `json:"operation"` // "apply" or "destroy", from the change summary
+	Completed        bool                  `json:"completed"` // Whether Terraform reported a final change summary
+	Resources        []ApplyResourceResult `json:"resources"` // Resource operations in the order they started
+	Diagnostics      []ApplyDiagnostic     `json:"diagnostics"`
+	Statistics       ApplyStatistics       `json:"statistics"`
+	Reconciliation   *ApplyReconciliation  `json:"reconciliation,omitempty"` // Comparison with the plan, when provided
+}
+
+// ApplyResourceResult is the result of a single operation on a resource during apply
+type ApplyResourceResult struct {
+	Address         string            `json:"address"`
+	Type            string            `json:"type"`
+	Action          string            `json:"action"` // Hook action, e.g., "create", "update", "delete", "read"
+	Status          string            `json:"status"` // "applied", "failed", or "incomplete"
+	DurationSeconds float64           `json:"duration_seconds"`
+	ID              string            `json:"id,omitempty"` // Resource ID reported on completion
+	Diagnostics     []ApplyDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// ApplyDiagnostic is an error or warning reported during apply
+type ApplyDiagnostic struct {
+	Severity string `json:"severity"` // "error" or "warning"
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail,omitempty"`
+	Address  string `json:"address,omitempty"` // Resource the diagnostic belongs to, when known
+}
+
+// ApplyStatistics holds the totals for an apply summary
+type ApplyStatistics struct {
+	Added           int     `json:"added"`     // From the change summary reported by Terraform
+	Changed         int     `json:"changed"`   // From the change summary reported by Terraform
+	Removed         int     `json:"removed"`   // From the change summary reported by Terraform
+	Imported        int     `json:"imported"`  // From the change summary reported by Terraform
+	Succeeded       int     `json:"succeeded"` // Resource operations that completed
+	Failed          int     `json:"failed"`    // Resource operations that errored
+	Incomplete      int     `json:"incomplete"`
+	Errors          int     `json:"errors"`
+	Warnings        int     `json:"warnings"`
+	DurationSeconds float64 `json:"duration_seconds"` // Wall-clock time between the first and last log message
+}
+
+// ApplyReconciliation compares an apply with the plan it was expected to carry out
+type ApplyReconciliation struct {
+	PlanFile   string               `json:"plan_file"`
+	Planned    int                  `json:"planned"` // Resource changes in the plan, excluding no-ops
+	Applied    int                  `json:"applied"` // Planned changes whose operations all completed
+	NotApplied []ApplyPlannedChange `json:"not_applied"`
+	Unplanned  []string             `json:"unplanned"` // Addresses changed during apply that weren't in the plan
+}
+
+// ApplyPlannedChange is a planned resource change that wasn't carried out
+type ApplyPlannedChange struct {
+	Address    string     `json:"address"`
+	ChangeType ChangeType `json:"change_type"`
+	Status     string     `json:"status"` // "failed", "incomplete", or "not started"
+}
+
+// HistoryRecord is a plan summary recorded in the history store
+type HistoryRecord struct {
+	ID         string       `json:"id"`
+	RecordedAt time.Time    `json:"recorded_at"`
+	Root       string       `json:"root"` // Terraform root module, relative to its git repository when available
+	Git        GitInfo      `json:"git"`
+	Summary    *PlanSummary `json:"summary"`
+}
+
+// GitInfo is the git metadata of the root module when a plan was recorded
+type GitInfo struct {
+	Commit string `json:"commit,omitempty"`
+	Branch string `json:"branch,omitempty"`
+	Dirty  bool   `json:"dirty"` // Whether there were uncommitted changes
+}
+
+// HistoryTrend summarises the recorded plans of a single root module
+type HistoryTrend struct {
+	Root      string           `json:"root"`
+	Runs      int              `json:"runs"`
+	FirstRun  time.Time        `json:"first_run"`
+	LastRun   time.Time        `json:"last_run"`
+	Latest    ChangeStatistics `json:"latest"`    // Statistics of the most recent plan
+	Average   HistoryAverages  `json:"average"`   // Average statistics across all plans
+	Direction string           `json:"direction"` // Total changes in the latest plan compared to earlier plans: "increasing", "decreasing", or "stable"
+}
+
+// HistoryAverages holds the average change counts across recorded plans
+type HistoryAverages struct {
+	ToAdd        float64 `json:"to_add"`
+	ToChange     float64 `json:"to_change"`
+	ToDestroy    float64 `json:"to_destroy"`
+	Replacements float64 `json:"replacements"`
+	HighRisk     float64 `json:"high_risk"`
+}
+
+// Approval records that a reviewer approved the resource changes of a plan
+type Approval struct {
+	PlanFile   string           `json:"plan_file"`
+	Hash       string           `json:"hash"` // Canonical hash of all approved resource changes
+	Approver   string           `json:"approver"`
+	ApprovedAt time.Time        `json:"approved_at"`
+	Changes    []ApprovedChange `json:"changes"`
+	PublicKey  string           `json:"public_key,omitempty"` // Base64 encoded ed25519 public key of the signer
+	Signature  string           `json:"signature,omitempty"`  // Base64 encoded ed25519 signature of the approval
+}
+
+// ApprovedChange is a single resource change covered by an approval
+type ApprovedChange struct {
+	Address    string     `json:"address"`
+	ChangeType ChangeType `json:"change_type"`
+	Hash       string     `json:"hash"` // Canonical hash of the analysed resource change
+}
+
+// ApprovalMismatch describes how the resource changes of a plan differ from an approval
+type ApprovalMismatch struct {
+	Added   []ApprovedChange // Changes in the plan that weren't approved
+	Removed []ApprovedChange // Approved changes that are missing from the plan
+	Changed []ApprovedChange // Changes whose type or values differ from what was approved
+}
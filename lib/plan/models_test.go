@@ -27,7 +27,7 @@ func TestResourceChange_SerializationWithNewFields(t *testing.T) {
 				TopChanges:       []string{"bucket", "versioning", "encryption"},
 				ReplacementHints: []string{"Bucket name changes require replacement"},
 			},
-			wantJSON: `{"address":"aws_s3_bucket.example","type":"aws_s3_bucket","name":"example","change_type":"create","is_destructive":false,"replacement_type":"Never","physical_id":"","planned_id":"","module_path":"","change_attributes":null,"is_dangerous":false,"danger_reason":"","danger_properties":null,"provider":"aws","top_changes":["bucket","versioning","encryption"],"replacement_hints":["Bucket name changes require replacement"],"property_changes":{"changes":null,"count":0,"total_size_bytes":0,"truncated":false},"has_unknown_values":false,"unknown_properties":null}`,
+			wantJSON: `{"address":"aws_s3_bucket.example","type":"aws_s3_bucket","name":"example","change_type":"create","is_destructive":false,"replacement_type":"Never","physical_id":"","planned_id":"","module_path":"","change_attributes":null,"is_dangerous":false,"danger_reason":"","danger_properties":null,"provider":"aws","top_changes":["bucket","versioning","encryption"],"replacement_hints":["Bucket name changes require replacement"],"property_changes":{"changes":null,"count":0,"total_size_bytes":0,"truncated":false},"has_unknown_values":false,"unknown_properties":null,"is_no_op":false}`,
 		},
 		{
 			name: "resource change with empty enhanced fields",
@@ -40,7 +40,7 @@ func TestResourceChange_SerializationWithNewFields(t *testing.T) {
 				ReplacementType: ReplacementNever,
 				Provider:        "azurerm",
 			},
-			wantJSON: `{"address":"azurerm_resource_group.example","type":"azurerm_resource_group","name":"example","change_type":"update","is_destructive":false,"replacement_type":"Never","physical_id":"","planned_id":"","module_path":"","change_attributes":null,"is_dangerous":false,"danger_reason":"","danger_properties":null,"provider":"azurerm","property_changes":{"changes":null,"count":0,"total_size_bytes":0,"truncated":false},"has_unknown_values":false,"unknown_properties":null}`,
+			wantJSON: `{"address":"azurerm_resource_group.example","type":"azurerm_resource_group","name":"example","change_type":"update","is_destructive":false,"replacement_type":"Never","physical_id":"","planned_id":"","module_path":"","change_attributes":null,"is_dangerous":false,"danger_reason":"","danger_properties":null,"provider":"azurerm","property_changes":{"changes":null,"count":0,"total_size_bytes":0,"truncated":false},"has_unknown_values":false,"unknown_properties":null,"is_no_op":false}`,
 		},
 	}
 
@@ -179,6 +179,36 @@ func TestChangeType_IsDestructive(t *testing.T) {
 	}
 }
 
+func TestResourceChange_RiskLevel(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		change   ResourceChange
+		expected string
+	}{
+		{"safe delete is low", ResourceChange{ChangeType: ChangeTypeDelete}, "low"},
+		{"dangerous delete is critical", ResourceChange{ChangeType: ChangeTypeDelete, IsDangerous: true}, "critical"},
+		{"dangerous replace is high", ResourceChange{ChangeType: ChangeTypeReplace, IsDangerous: true}, "high"},
+		{"dangerous update is medium", ResourceChange{ChangeType: ChangeTypeUpdate, IsDangerous: true}, "medium"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if result := tt.change.RiskLevel(); result != tt.expected {
+				t.Errorf("RiskLevel() = %v, expected %v", result, tt.expected)
+			}
+			if RiskLevelRank(tt.change.RiskLevel()) < 0 {
+				t.Errorf("RiskLevelRank(%q) is unknown", tt.change.RiskLevel())
+			}
+		})
+	}
+
+	if RiskLevelRank("Critical") <= RiskLevelRank("medium") || RiskLevelRank("severe") != -1 {
+		t.Error("RiskLevelRank() doesn't order risk levels")
+	}
+}
+
 func TestResourceAnalysis_Serialization(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
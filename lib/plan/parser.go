@@ -1,8 +1,10 @@
 package plan
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,58 +26,83 @@ func NewParser(planFile string) *Parser {
 	}
 }
 
-// LoadPlan loads and parses a Terraform plan file
+// LoadPlan loads and parses a Terraform plan file. The plan is decoded while it's read, see DecodePlan.
 func (p *Parser) LoadPlan() (*tfjson.Plan, error) {
+	var changes []*tfjson.ResourceChange
+	plan, err := p.StreamPlan(func(change *tfjson.ResourceChange) error {
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	plan.ResourceChanges = changes
+	return plan, nil
+}
+
+// StreamPlan reads a Terraform plan file and passes every resource change to handle as it's decoded,
+// see DecodePlanStream. The returned plan holds everything else that is summarised.
+func (p *Parser) StreamPlan(handle func(*tfjson.ResourceChange) error) (*tfjson.Plan, error) {
 	// Check if file exists
 	if _, err := os.Stat(p.planFile); os.IsNotExist(err) {
 		return nil, fmt.Errorf("plan file does not exist: %s", p.planFile)
 	}
 
-	// Determine if we need to convert the plan to JSON
-	var jsonData []byte
-	var err error
-
 	if strings.HasSuffix(p.planFile, ".json") {
 		// Already a JSON file, read directly
-		jsonData, err = os.ReadFile(p.planFile)
+		file, err := os.Open(p.planFile)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read plan file: %w", err)
 		}
-	} else {
-		// Binary plan file, convert to JSON using terraform show
-		jsonData, err = p.convertPlanToJSON()
-		if err != nil {
-			return nil, fmt.Errorf("failed to convert plan to JSON: %w", err)
-		}
+		defer file.Close()
+		return DecodePlanStream(file, handle)
 	}
 
-	// Parse the JSON
-	var plan tfjson.Plan
-	if err := json.Unmarshal(jsonData, &plan); err != nil {
-		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
+	// Binary plan file, convert to JSON using terraform show
+	plan, err := p.convertPlanToJSON(handle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert plan to JSON: %w", err)
 	}
+	return plan, nil
+}
 
-	return &plan, nil
+// ParsePlan parses the JSON representation of a plan, as created by `terraform show -json`
+func ParsePlan(data []byte) (*tfjson.Plan, error) {
+	return DecodePlan(bytes.NewReader(data))
 }
 
-// convertPlanToJSON converts a binary plan file to JSON using terraform show
-func (p *Parser) convertPlanToJSON() ([]byte, error) {
+// convertPlanToJSON converts a binary plan file to JSON using terraform show, decoding the output as it's written
+func (p *Parser) convertPlanToJSON(handle func(*tfjson.ResourceChange) error) (*tfjson.Plan, error) {
 	// Get the directory containing the plan file
 	planDir := filepath.Dir(p.planFile)
 
 	// Execute terraform show -json
 	cmd := exec.Command("terraform", "show", "-json", filepath.Base(p.planFile))
 	cmd.Dir = planDir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 
-	output, err := cmd.Output()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("terraform show failed: %s", string(exitError.Stderr))
+		return nil, fmt.Errorf("failed to execute terraform show: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to execute terraform show: %w", err)
+	}
+	plan, decodeErr := DecodePlanStream(stdout, handle)
+	// Drain the output, so terraform isn't blocked writing when decoding stopped early
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("terraform show failed: %s", stderr.String())
 		}
 		return nil, fmt.Errorf("failed to execute terraform show: %w", err)
 	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
 
-	return output, nil
+	return plan, nil
 }
 
 // ValidateStructure validates that the plan has the expected structure
@@ -97,6 +124,12 @@ func (p *Parser) ValidateStructure(plan *tfjson.Plan) error {
 	return nil
 }
 
+// Workspace returns the Terraform workspace of the plan, from TF_WORKSPACE or the workspace selected in
+// the plan file's directory, falling back to "default"
+func (p *Parser) Workspace() string {
+	return p.extractWorkspaceInfo(nil)
+}
+
 // extractWorkspaceInfo extracts workspace information from the plan
 func (p *Parser) extractWorkspaceInfo(_ *tfjson.Plan) string {
 	if workspace := p.getCurrentWorkspace(); workspace != "" {
@@ -1,14 +1,18 @@
 package plan
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
 )
 
 // BenchmarkAnalysis_SmallPlan benchmarks analysis with a small plan (10 resources)
@@ -59,6 +63,62 @@ func BenchmarkAnalysis_LargePlan(b *testing.B) {
 	}
 }
 
+// BenchmarkAnalyzeResourceChanges compares analysing resources one at a time with the worker pool,
+// using a plan of 1000 resources that each change 10 properties. The speedup depends on the number of CPUs.
+func BenchmarkAnalyzeResourceChanges(b *testing.B) {
+	plan := propertyHeavyPlan(1000, 10, 50)
+
+	workerCounts := []int{1, 2, 4, runtime.GOMAXPROCS(0)}
+	slices.Sort(workerCounts)
+	for _, workers := range slices.Compact(workerCounts) {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			analyzer := analyzerWithWorkers(plan, workers)
+			for b.Loop() {
+				if changes := analyzer.analyzeResourceChanges(); len(changes) != 1000 {
+					b.Fatalf("Expected 1000 resource changes, got %d", len(changes))
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecodePlan compares the streaming plan decoder with decoding the whole plan, for a plan with
+// 1000 resource changes and a prior state and planned values that aren't used for summaries
+func BenchmarkDecodePlan(b *testing.B) {
+	data, err := json.Marshal(CreateMultiProviderPlan(1000).Build())
+	if err != nil {
+		b.Fatal(err)
+	}
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		b.Fatal(err)
+	}
+	state := map[string]any{"format_version": "1.0", "values": map[string]any{"root_module": map[string]any{"resources": document["resource_changes"]}}}
+	document["prior_state"] = state
+	document["planned_values"] = state
+	if data, err = json.Marshal(document); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := DecodePlan(bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			var plan tfjson.Plan
+			if err := json.Unmarshal(data, &plan); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 // BenchmarkFormatting_ProgressiveDisclosure benchmarks the progressive disclosure formatter
 func BenchmarkFormatting_ProgressiveDisclosure(b *testing.B) {
 	planPath := createBenchmarkPlan("format_benchmark_plan.json", 100)
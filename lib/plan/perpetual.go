@@ -0,0 +1,175 @@
+package plan
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// defaultPerpetualDiffThreshold is the number of consecutive plans a change must appear in
+	defaultPerpetualDiffThreshold = 3
+
+	// perpetualDiffLabel marks changes that appeared identically in consecutive plans
+	perpetualDiffLabel = "perpetual diff"
+)
+
+// SaveSummary writes a plan summary as JSON, so later plans can be compared with it
+func SaveSummary(summary *PlanSummary, path string) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// LoadPreviousSummaries loads the summary JSON files in a directory, oldest first. When current is set, only
+// the summaries of the same plan are kept: the same workspace and root module, so plans of other workspaces
+// or root modules that share the directory aren't compared with it.
+func LoadPreviousSummaries(dir string, current *PlanSummary) ([]*PlanSummary, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list summaries: %w", err)
+	}
+
+	summaries := make([]*PlanSummary, 0, len(paths))
+	for _, path := range paths {
+		summary, err := LoadSummaryFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if current != nil && !samePlan(summary, current) {
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// Glob returns paths sorted by name, which is used as the order for summaries created at the same time
+	slices.SortStableFunc(summaries, func(x, y *PlanSummary) int {
+		return x.CreatedAt.Compare(y.CreatedAt)
+	})
+	return summaries, nil
+}
+
+// samePlan reports whether two summaries are of plans for the same workspace and root module.
+// The root module is recorded when a plan file is summarised, as the plan file name is often relative
+// and the same in every root module.
+func samePlan(x, y *PlanSummary) bool {
+	return x.Workspace == y.Workspace && x.RootModule == y.RootModule
+}
+
+// planRoot returns the absolute directory of a plan file, which is its root module
+func planRoot(planFile string) string {
+	dir := filepath.Dir(planFile)
+	if absolute, err := filepath.Abs(dir); err == nil {
+		return absolute
+	}
+	return dir
+}
+
+// DetectPerpetualDiffs marks property changes that appeared with identical before and after values
+// in the previous threshold-1 plans as well as this one. Only updates and replacements are checked.
+// Resources where every property change is perpetual are marked as a whole. Previous summaries must
+// be sorted oldest first.
+func DetectPerpetualDiffs(summary *PlanSummary, previous []*PlanSummary, threshold int) {
+	if threshold == 0 {
+		threshold = defaultPerpetualDiffThreshold
+	}
+	if summary == nil || threshold < 2 || len(previous) < threshold-1 {
+		return
+	}
+	recent := previous[len(previous)-(threshold-1):]
+
+	// Index the property changes of the earlier plans by resource address and property path
+	indexes := make([]map[string]map[string]PropertyChange, len(recent))
+	for i, plan := range recent {
+		indexes[i] = map[string]map[string]PropertyChange{}
+		for _, resource := range plan.ResourceChanges {
+			properties := map[string]PropertyChange{}
+			for _, change := range resource.PropertyChanges.Changes {
+				properties[propertyChangeKey(change)] = change
+			}
+			indexes[i][resource.Address] = properties
+		}
+	}
+
+	for r := range summary.ResourceChanges {
+		resource := &summary.ResourceChanges[r]
+		// Creating or deleting the same resource in every plan means the plans weren't applied,
+		// so only changes to existing resources can be perpetual
+		if resource.ChangeType != ChangeTypeUpdate && resource.ChangeType != ChangeTypeReplace {
+			continue
+		}
+		changes := resource.PropertyChanges.Changes
+		perpetual := 0
+		for c := range changes {
+			if isPerpetualChange(resource.Address, changes[c], indexes) {
+				changes[c].PerpetualDiff = true
+				perpetual++
+			}
+		}
+		resource.PerpetualDiff = perpetual > 0 && perpetual == len(changes) && !resource.PropertyChanges.Truncated
+	}
+}
+
+// isPerpetualChange reports whether a property change appears identically in every earlier plan.
+// Sensitive and unknown values can't be compared, so they are never perpetual.
+func isPerpetualChange(address string, change PropertyChange, indexes []map[string]map[string]PropertyChange) bool {
+	if change.Sensitive || change.IsUnknown {
+		return false
+	}
+	key := propertyChangeKey(change)
+	for _, index := range indexes {
+		earlier, ok := index[address][key]
+		if !ok || earlier.Action != change.Action || !sameJSONValue(earlier.Before, change.Before) || !sameJSONValue(earlier.After, change.After) {
+			return false
+		}
+	}
+	return true
+}
+
+// propertyChangeKey identifies a property within a resource across plans
+func propertyChangeKey(change PropertyChange) string {
+	if len(change.Path) > 0 {
+		return strings.Join(change.Path, ".")
+	}
+	return change.Name
+}
+
+// sameJSONValue compares values by their JSON encoding, as earlier plans are loaded from JSON
+func sameJSONValue(a, b any) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// perpetualDiffCount returns the number of perpetual property changes
+func perpetualDiffCount(changes []PropertyChange) int {
+	count := 0
+	for _, change := range changes {
+		if change.PerpetualDiff {
+			count++
+		}
+	}
+	return count
+}
+
+// perpetualDiffNames returns the names of the perpetual property changes, sorted
+func perpetualDiffNames(changes []PropertyChange) []string {
+	names := []string{}
+	for _, change := range changes {
+		if change.PerpetualDiff {
+			names = append(names, change.Name)
+		}
+	}
+	slices.SortFunc(names, cmp.Compare[string])
+	return slices.Compact(names)
+}
@@ -0,0 +1,159 @@
+package plan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// perpetualTestSummary creates a summary with a tags change that flaps and an AMI change that differs per plan
+func perpetualTestSummary(createdAt time.Time, ami string) *PlanSummary {
+	return &PlanSummary{
+		CreatedAt: createdAt,
+		ResourceChanges: []ResourceChange{
+			{
+				Address:    "aws_instance.web",
+				ChangeType: ChangeTypeUpdate,
+				PropertyChanges: PropertyChangeAnalysis{Count: 2, Changes: []PropertyChange{
+					{Name: "tags", Path: []string{"tags", "Owner"}, Action: actionUpdate, Before: "ops", After: "OPS"},
+					{Name: "ami", Path: []string{"ami"}, Action: actionUpdate, Before: "ami-old", After: ami},
+				}},
+			},
+			{
+				Address:    "aws_s3_bucket.logs",
+				ChangeType: ChangeTypeUpdate,
+				PropertyChanges: PropertyChangeAnalysis{Count: 1, Changes: []PropertyChange{
+					{Name: "policy", Path: []string{"policy"}, Action: actionUpdate, Before: map[string]any{"a": 1.0}, After: map[string]any{"a": 2.0}},
+				}},
+			},
+		},
+	}
+}
+
+func TestLoadPreviousSummaries(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, SaveSummary(perpetualTestSummary(start.Add(time.Hour), "ami-2"), filepath.Join(dir, "a.json")))
+	require.NoError(t, SaveSummary(perpetualTestSummary(start, "ami-1"), filepath.Join(dir, "b.json")))
+
+	summaries, err := LoadPreviousSummaries(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "ami-1", summaries[0].ResourceChanges[0].PropertyChanges.Changes[1].After)
+
+	t.Run("same plan only", func(t *testing.T) {
+		dir := t.TempDir()
+		// Relative plan files with the same name in different root modules are told apart by the root module
+		summaries := map[string]*PlanSummary{
+			"same.json":      {Workspace: "prod", PlanFile: "plan-1.tfplan", RootModule: "/work/envs/prod"},
+			"workspace.json": {Workspace: "staging", PlanFile: "plan-2.tfplan", RootModule: "/work/envs/prod"},
+			"root.json":      {Workspace: "prod", PlanFile: "plan-1.tfplan", RootModule: "/work/envs/network"},
+		}
+		for name, summary := range summaries {
+			summary.CreatedAt = start
+			require.NoError(t, SaveSummary(summary, filepath.Join(dir, name)))
+		}
+
+		current := &PlanSummary{Workspace: "prod", PlanFile: "plan-4.tfplan", RootModule: "/work/envs/prod"}
+		loaded, err := LoadPreviousSummaries(dir, current)
+		require.NoError(t, err)
+		require.Len(t, loaded, 1)
+		assert.Equal(t, "/work/envs/prod", loaded[0].RootModule)
+		assert.Equal(t, "plan-1.tfplan", loaded[0].PlanFile)
+	})
+
+	t.Run("root module recorded when summarising", func(t *testing.T) {
+		summary, err := NewAnalyzer(nil, config.GetDefaultConfig()).SummarizePlanFile(t.Context(), "../../testdata/simple_plan.json")
+		require.NoError(t, err)
+		expected, err := filepath.Abs("../../testdata")
+		require.NoError(t, err)
+		assert.Equal(t, expected, summary.RootModule)
+
+		// The recorded root module doesn't depend on the working directory when the summary is loaded
+		path := filepath.Join(t.TempDir(), "summary.json")
+		require.NoError(t, SaveSummary(summary, path))
+		t.Chdir(t.TempDir())
+		loaded, err := LoadSummaryFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, loaded.RootModule)
+	})
+
+	t.Run("invalid summary", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "c.json"), []byte("{"), 0o600))
+		_, err := LoadPreviousSummaries(dir, nil)
+		assert.ErrorContains(t, err, "failed to parse summary")
+	})
+}
+
+func TestDetectPerpetualDiffs(t *testing.T) {
+	start := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	previous := []*PlanSummary{
+		perpetualTestSummary(start, "ami-1"),
+		perpetualTestSummary(start.Add(time.Hour), "ami-2"),
+	}
+
+	t.Run("marks changes seen in every recent plan", func(t *testing.T) {
+		summary := perpetualTestSummary(start.Add(2*time.Hour), "ami-3")
+		DetectPerpetualDiffs(summary, previous, 0)
+
+		web := summary.ResourceChanges[0]
+		assert.True(t, web.PropertyChanges.Changes[0].PerpetualDiff)
+		assert.False(t, web.PropertyChanges.Changes[1].PerpetualDiff)
+		assert.False(t, web.PerpetualDiff, "only some of the changes are perpetual")
+		assert.True(t, summary.ResourceChanges[1].PerpetualDiff)
+	})
+
+	t.Run("not enough previous plans", func(t *testing.T) {
+		summary := perpetualTestSummary(start.Add(2*time.Hour), "ami-3")
+		DetectPerpetualDiffs(summary, previous, 4)
+		assert.False(t, summary.ResourceChanges[1].PerpetualDiff)
+	})
+
+	t.Run("change missing from an earlier plan", func(t *testing.T) {
+		older := perpetualTestSummary(start, "ami-1")
+		older.ResourceChanges = older.ResourceChanges[:1]
+		summary := perpetualTestSummary(start.Add(2*time.Hour), "ami-3")
+		DetectPerpetualDiffs(summary, []*PlanSummary{older, previous[1]}, 3)
+		assert.False(t, summary.ResourceChanges[1].PerpetualDiff)
+		assert.True(t, summary.ResourceChanges[0].PropertyChanges.Changes[0].PerpetualDiff)
+	})
+
+	t.Run("sensitive values are never perpetual", func(t *testing.T) {
+		summary := perpetualTestSummary(start.Add(2*time.Hour), "ami-3")
+		summary.ResourceChanges[1].PropertyChanges.Changes[0].Sensitive = true
+		DetectPerpetualDiffs(summary, previous, 3)
+		assert.False(t, summary.ResourceChanges[1].PerpetualDiff)
+	})
+}
+
+func TestFormatter_PerpetualDiffs(t *testing.T) {
+	start := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	summary := perpetualTestSummary(start.Add(2*time.Hour), "ami-3")
+	DetectPerpetualDiffs(summary, []*PlanSummary{
+		perpetualTestSummary(start, "ami-1"),
+		perpetualTestSummary(start.Add(time.Hour), "ami-2"),
+	}, 3)
+	web := summary.ResourceChanges[0].PropertyChanges
+
+	formatter := NewFormatter(config.GetDefaultConfig())
+	assert.Contains(t, formatter.formatPropertyChange(web.Changes[0]), "# perpetual diff")
+	assert.Equal(t, "🔁 perpetual diff", formatter.getDangerDisplay(summary.ResourceChanges[1]))
+	assert.Equal(t, " # forces replacement, perpetual diff",
+		propertyChangeIndicator(PropertyChange{TriggersReplacement: true, PerpetualDiff: true}))
+
+	t.Run("collapsed", func(t *testing.T) {
+		cfg := config.GetDefaultConfig()
+		cfg.Plan.PerpetualDiff.Collapse = true
+		content := NewFormatter(cfg).terraformPropertyChanges(web)
+		assert.Equal(t, "2 properties changed (1 perpetual diff)", content.Summary())
+		rendered, ok := content.Details().(string)
+		require.True(t, ok)
+		assert.NotContains(t, rendered, "OPS")
+		assert.Contains(t, rendered, "# perpetual diff hidden: tags")
+	})
+}
@@ -0,0 +1,383 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	policyEffectAllow = "Allow"
+	wildcard          = "*"
+
+	// notPrefix marks the entries of NotAction, NotResource and NotPrincipal elements
+	notPrefix = "NOT "
+)
+
+// policyStatement is a normalised view of a single statement in a policy document.
+// All list-like fields are flattened into sorted, de-duplicated string slices so that
+// statements can be compared as sets regardless of how Terraform rendered them.
+type policyStatement struct {
+	Sid        string
+	Effect     string
+	Actions    []string
+	Resources  []string
+	Principals []string
+	Conditions []string
+}
+
+// key returns a canonical string for the statement, used to match statements without a Sid
+func (s policyStatement) key() string {
+	return strings.Join([]string{
+		s.Effect,
+		strings.Join(s.Actions, ","),
+		strings.Join(s.Resources, ","),
+		strings.Join(s.Principals, ","),
+		strings.Join(s.Conditions, ","),
+	}, "|")
+}
+
+// parsePolicyDocument attempts to parse a value as a JSON policy document.
+// It returns false when the value is not a string containing a document with a Statement element.
+func parsePolicyDocument(value any) ([]policyStatement, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return nil, false
+	}
+	trimmed := strings.TrimSpace(str)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+
+	var document map[string]any
+	if err := json.Unmarshal([]byte(trimmed), &document); err != nil {
+		return nil, false
+	}
+
+	rawStatements, exists := document["Statement"]
+	if !exists {
+		return nil, false
+	}
+
+	// A single statement may be given as an object rather than a list
+	var statementList []any
+	switch v := rawStatements.(type) {
+	case []any:
+		statementList = v
+	case map[string]any:
+		statementList = []any{v}
+	default:
+		return nil, false
+	}
+
+	statements := make([]policyStatement, 0, len(statementList))
+	for _, raw := range statementList {
+		statementMap, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		statements = append(statements, normalizeStatement(statementMap))
+	}
+
+	return statements, true
+}
+
+// normalizeStatement flattens a raw statement map into a policyStatement.
+// NotAction, NotResource and NotPrincipal entries are prefixed with "NOT " so they remain distinguishable.
+func normalizeStatement(raw map[string]any) policyStatement {
+	statement := policyStatement{}
+	statement.Sid, _ = raw["Sid"].(string)
+	statement.Effect, _ = raw["Effect"].(string)
+
+	statement.Actions = append(policyStringList(raw["Action"], ""), policyStringList(raw["NotAction"], notPrefix)...)
+	statement.Resources = append(policyStringList(raw["Resource"], ""), policyStringList(raw["NotResource"], notPrefix)...)
+	statement.Principals = append(policyPrincipals(raw["Principal"], ""), policyPrincipals(raw["NotPrincipal"], notPrefix)...)
+	statement.Conditions = policyConditions(raw["Condition"])
+
+	statement.Actions = sortedUnique(statement.Actions)
+	statement.Resources = sortedUnique(statement.Resources)
+	statement.Principals = sortedUnique(statement.Principals)
+	statement.Conditions = sortedUnique(statement.Conditions)
+
+	return statement
+}
+
+// policyStringList converts a string or list of strings into a string slice with an optional prefix
+func policyStringList(value any, prefix string) []string {
+	var result []string
+	switch v := value.(type) {
+	case string:
+		result = append(result, prefix+v)
+	case []any:
+		for _, item := range v {
+			result = append(result, prefix+fmt.Sprintf("%v", item))
+		}
+	}
+	return result
+}
+
+// policyPrincipals flattens a Principal element into "Type:value" strings, keeping "*" as-is
+func policyPrincipals(value any, prefix string) []string {
+	var result []string
+	switch v := value.(type) {
+	case string:
+		result = append(result, prefix+v)
+	case map[string]any:
+		for principalType, principals := range v {
+			for _, principal := range policyStringList(principals, "") {
+				result = append(result, fmt.Sprintf("%s%s:%s", prefix, principalType, principal))
+			}
+		}
+	}
+	return result
+}
+
+// policyConditions flattens a Condition element into "Operator key=value" strings
+func policyConditions(value any) []string {
+	conditionMap, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var result []string
+	for operator, keys := range conditionMap {
+		keyMap, ok := keys.(map[string]any)
+		if !ok {
+			continue
+		}
+		for key, values := range keyMap {
+			switch v := values.(type) {
+			case []any:
+				for _, item := range v {
+					result = append(result, fmt.Sprintf("%s %s=%v", operator, key, item))
+				}
+			default:
+				result = append(result, fmt.Sprintf("%s %s=%v", operator, key, v))
+			}
+		}
+	}
+	return result
+}
+
+// sortedUnique returns a sorted copy of values without duplicates
+func sortedUnique(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	result := slices.Clone(values)
+	sort.Strings(result)
+	return slices.Compact(result)
+}
+
+// setDifference returns the values in a that are not present in b
+func setDifference(a, b []string) []string {
+	var result []string
+	for _, value := range a {
+		if !slices.Contains(b, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// diffPolicyDocuments compares two policy document values and returns a statement-level diff.
+// It returns nil when neither side is a policy document, or when one side is present but isn't a policy.
+// A nil before or after value is treated as an empty document so new and removed policies are still analysed.
+func diffPolicyDocuments(before, after any) *PolicyDiff {
+	beforeStatements, beforeIsPolicy := parsePolicyDocument(before)
+	afterStatements, afterIsPolicy := parsePolicyDocument(after)
+
+	switch {
+	case beforeIsPolicy && afterIsPolicy:
+	case beforeIsPolicy && after == nil:
+	case afterIsPolicy && before == nil:
+	default:
+		return nil
+	}
+
+	diff := &PolicyDiff{Statements: []StatementDiff{}}
+	matchedAfter := make([]bool, len(afterStatements))
+
+	for _, beforeStatement := range beforeStatements {
+		matchIndex := findMatchingStatement(beforeStatement, afterStatements, matchedAfter)
+		if matchIndex < 0 {
+			diff.Statements = append(diff.Statements, statementAddRemoveDiff(beforeStatement, actionRemove))
+			continue
+		}
+		matchedAfter[matchIndex] = true
+		if statementDiff, changed := statementUpdateDiff(beforeStatement, afterStatements[matchIndex]); changed {
+			diff.Statements = append(diff.Statements, statementDiff)
+		}
+	}
+
+	for i, afterStatement := range afterStatements {
+		if !matchedAfter[i] {
+			diff.Statements = append(diff.Statements, statementAddRemoveDiff(afterStatement, actionAdd))
+		}
+	}
+
+	diff.Escalations = detectPolicyEscalations(diff.Statements)
+	return diff
+}
+
+// findMatchingStatement finds the unmatched statement in candidates that corresponds to statement.
+// Statements with a Sid are matched by Sid; statements without one are matched by content.
+func findMatchingStatement(statement policyStatement, candidates []policyStatement, matched []bool) int {
+	for i, candidate := range candidates {
+		if matched[i] {
+			continue
+		}
+		if statement.Sid != "" {
+			if candidate.Sid == statement.Sid {
+				return i
+			}
+			continue
+		}
+		if candidate.Sid == "" && candidate.key() == statement.key() {
+			return i
+		}
+	}
+	return -1
+}
+
+// statementAddRemoveDiff creates a diff for a statement that was added or removed in its entirety
+func statementAddRemoveDiff(statement policyStatement, action string) StatementDiff {
+	diff := StatementDiff{
+		Sid:    statement.Sid,
+		Effect: statement.Effect,
+		Action: action,
+	}
+	if action == actionAdd {
+		diff.AddedActions = statement.Actions
+		diff.AddedResources = statement.Resources
+		diff.AddedPrincipals = statement.Principals
+		diff.AddedConditions = statement.Conditions
+	} else {
+		diff.RemovedActions = statement.Actions
+		diff.RemovedResources = statement.Resources
+		diff.RemovedPrincipals = statement.Principals
+		diff.RemovedConditions = statement.Conditions
+	}
+	return diff
+}
+
+// statementUpdateDiff compares two matched statements and reports whether anything changed
+func statementUpdateDiff(before, after policyStatement) (StatementDiff, bool) {
+	diff := StatementDiff{
+		Sid:               after.Sid,
+		Effect:            after.Effect,
+		Action:            actionUpdate,
+		AddedActions:      setDifference(after.Actions, before.Actions),
+		RemovedActions:    setDifference(before.Actions, after.Actions),
+		AddedResources:    setDifference(after.Resources, before.Resources),
+		RemovedResources:  setDifference(before.Resources, after.Resources),
+		AddedPrincipals:   setDifference(after.Principals, before.Principals),
+		RemovedPrincipals: setDifference(before.Principals, after.Principals),
+		AddedConditions:   setDifference(after.Conditions, before.Conditions),
+		RemovedConditions: setDifference(before.Conditions, after.Conditions),
+	}
+
+	changed := before.Effect != after.Effect ||
+		len(diff.AddedActions) > 0 || len(diff.RemovedActions) > 0 ||
+		len(diff.AddedResources) > 0 || len(diff.RemovedResources) > 0 ||
+		len(diff.AddedPrincipals) > 0 || len(diff.RemovedPrincipals) > 0 ||
+		len(diff.AddedConditions) > 0 || len(diff.RemovedConditions) > 0
+
+	// An effect flip from Deny to Allow grants everything in the statement
+	if changed && before.Effect != after.Effect && after.Effect == policyEffectAllow {
+		diff.AddedActions = after.Actions
+		diff.AddedPrincipals = after.Principals
+	}
+
+	return diff, changed
+}
+
+// detectPolicyEscalations flags newly granted wildcard actions and principals in Allow statements, and
+// Allow statements that grant through NotAction or NotPrincipal. Those grant everything except the listed
+// entries, so adding one grants broadly and removing one of its exceptions widens the grant.
+func detectPolicyEscalations(statements []StatementDiff) []string {
+	var escalations []string
+	for _, statement := range statements {
+		if statement.Effect != policyEffectAllow || statement.Action == actionRemove {
+			continue
+		}
+		label := statementLabel(statement)
+		for _, action := range statement.AddedActions {
+			if isWildcardEntry(action) {
+				escalations = append(escalations, fmt.Sprintf("wildcard action %s granted in %s", action, label))
+			}
+		}
+		for _, principal := range statement.AddedPrincipals {
+			if isWildcardEntry(principal) {
+				escalations = append(escalations, fmt.Sprintf("wildcard principal %s granted in %s", principal, label))
+			}
+		}
+		escalations = append(escalations, negatedEscalations("NotAction", "action", statement.AddedActions, statement.RemovedActions, label)...)
+		escalations = append(escalations, negatedEscalations("NotPrincipal", "principal", statement.AddedPrincipals, statement.RemovedPrincipals, label)...)
+	}
+	return escalations
+}
+
+// isWildcardEntry reports whether an action or principal entry matches everything, such as "*" or "s3:*".
+// Wildcards in Not elements exclude everything instead, so they don't count.
+func isWildcardEntry(entry string) bool {
+	if strings.HasPrefix(entry, notPrefix) {
+		return false
+	}
+	return entry == wildcard || strings.HasSuffix(entry, ":"+wildcard)
+}
+
+// negatedEscalations flags the NotAction or NotPrincipal entries of an Allow statement diff that grant more.
+// A statement has either the element or its Not form, so removed Not entries only widen the grant when no
+// plain entries were added in their place.
+func negatedEscalations(element, kind string, added, removed []string, label string) []string {
+	var escalations []string
+	for _, entry := range added {
+		if excluded, ok := strings.CutPrefix(entry, notPrefix); ok {
+			escalations = append(escalations, fmt.Sprintf("%s %s grants every other %s in %s", element, excluded, kind, label))
+		}
+	}
+	if slices.ContainsFunc(added, func(entry string) bool { return !strings.HasPrefix(entry, notPrefix) }) {
+		return escalations
+	}
+	for _, entry := range removed {
+		if excluded, ok := strings.CutPrefix(entry, notPrefix); ok {
+			escalations = append(escalations, fmt.Sprintf("%s exception %s removed in %s", element, excluded, label))
+		}
+	}
+	return escalations
+}
+
+// statementLabel returns a human-readable label for a statement
+func statementLabel(statement StatementDiff) string {
+	if statement.Sid != "" {
+		return fmt.Sprintf("statement %q", statement.Sid)
+	}
+	return "statement (no Sid)"
+}
+
+// analyzePolicyChanges attaches statement-level diffs to property changes that hold policy documents.
+// Sensitive and unknown values are left alone, as their contents can't (or mustn't) be inspected.
+func (a *Analyzer) analyzePolicyChanges(analysis *PropertyChangeAnalysis) {
+	for i, change := range analysis.Changes {
+		if change.Sensitive || change.IsUnknown {
+			continue
+		}
+		if diff := diffPolicyDocuments(change.Before, change.After); diff != nil {
+			analysis.Changes[i].PolicyDiff = diff
+		}
+	}
+}
+
+// policyEscalationProperties returns the names of properties whose policy changes escalate privileges
+func policyEscalationProperties(analysis PropertyChangeAnalysis) []string {
+	var properties []string
+	for _, change := range analysis.Changes {
+		if change.PolicyDiff != nil && len(change.PolicyDiff.Escalations) > 0 {
+			properties = append(properties, change.Name)
+		}
+	}
+	return properties
+}
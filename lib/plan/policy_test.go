@@ -0,0 +1,237 @@
+package plan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPolicyDocuments(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      any
+		after       any
+		expectNil   bool
+		validate    func(t *testing.T, diff *PolicyDiff)
+		escalations int
+	}{
+		{
+			name:      "non-policy strings are ignored",
+			before:    "t3.micro",
+			after:     "t3.small",
+			expectNil: true,
+		},
+		{
+			name:      "JSON without Statement is ignored",
+			before:    `{"Version": "2012-10-17"}`,
+			after:     `{"Version": "2012-10-17", "Id": "x"}`,
+			expectNil: true,
+		},
+		{
+			name:   "statements matched by Sid report added actions and resources",
+			before: `{"Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::a/*"}]}`,
+			after:  `{"Statement": [{"Sid": "Read", "Effect": "Allow", "Action": ["s3:GetObject", "s3:ListBucket"], "Resource": ["arn:aws:s3:::a/*", "arn:aws:s3:::a"]}]}`,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				require.Len(t, diff.Statements, 1)
+				statement := diff.Statements[0]
+				assert.Equal(t, "Read", statement.Sid)
+				assert.Equal(t, actionUpdate, statement.Action)
+				assert.Equal(t, []string{"s3:ListBucket"}, statement.AddedActions)
+				assert.Equal(t, []string{"arn:aws:s3:::a"}, statement.AddedResources)
+				assert.Empty(t, statement.RemovedActions)
+			},
+		},
+		{
+			name:   "statements without Sid are matched by content",
+			before: `{"Statement": [{"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}, {"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}]}`,
+			after:  `{"Statement": [{"Effect": "Deny", "Action": "s3:DeleteObject", "Resource": "*"}, {"Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				assert.Empty(t, diff.Statements, "reordered statements should not be reported")
+			},
+		},
+		{
+			name:   "removed statement and condition changes are reported",
+			before: `{"Statement": [{"Sid": "A", "Effect": "Allow", "Action": "sqs:SendMessage", "Resource": "*", "Condition": {"StringEquals": {"aws:SourceVpc": "vpc-1"}}}, {"Sid": "B", "Effect": "Allow", "Action": "sqs:ReceiveMessage", "Resource": "*"}]}`,
+			after:  `{"Statement": {"Sid": "A", "Effect": "Allow", "Action": "sqs:SendMessage", "Resource": "*", "Condition": {"StringEquals": {"aws:SourceVpc": "vpc-2"}}}}`,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				require.Len(t, diff.Statements, 2)
+				assert.Equal(t, []string{"StringEquals aws:SourceVpc=vpc-2"}, diff.Statements[0].AddedConditions)
+				assert.Equal(t, []string{"StringEquals aws:SourceVpc=vpc-1"}, diff.Statements[0].RemovedConditions)
+				assert.Equal(t, actionRemove, diff.Statements[1].Action)
+				assert.Equal(t, "B", diff.Statements[1].Sid)
+			},
+		},
+		{
+			name:        "new wildcard action is an escalation",
+			before:      `{"Statement": [{"Sid": "Admin", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			after:       `{"Statement": [{"Sid": "Admin", "Effect": "Allow", "Action": ["s3:GetObject", "iam:*"], "Resource": "*"}]}`,
+			escalations: 1,
+		},
+		{
+			name:        "wildcard principal in bucket policy is an escalation",
+			before:      `{"Statement": [{"Sid": "Public", "Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}, "Action": "s3:GetObject", "Resource": "*"}]}`,
+			after:       `{"Statement": [{"Sid": "Public", "Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			escalations: 1,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				require.Len(t, diff.Statements, 1)
+				assert.Equal(t, []string{"*"}, diff.Statements[0].AddedPrincipals)
+				assert.Equal(t, []string{"AWS:arn:aws:iam::123456789012:root"}, diff.Statements[0].RemovedPrincipals)
+			},
+		},
+		{
+			name:        "wildcard in Deny statement is not an escalation",
+			before:      nil,
+			after:       `{"Statement": [{"Effect": "Deny", "Action": "*", "Resource": "*"}]}`,
+			escalations: 0,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				require.Len(t, diff.Statements, 1)
+				assert.Equal(t, actionAdd, diff.Statements[0].Action)
+			},
+		},
+		{
+			name:        "new policy granting everything is an escalation",
+			before:      nil,
+			after:       `{"Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}`,
+			escalations: 1,
+		},
+		{
+			name:        "new NotAction in Allow statement is an escalation",
+			before:      nil,
+			after:       `{"Statement": [{"Sid": "AllButIAM", "Effect": "Allow", "NotAction": "iam:*", "Resource": "*"}]}`,
+			escalations: 1,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				assert.Equal(t, []string{`NotAction iam:* grants every other action in statement "AllButIAM"`}, diff.Escalations)
+			},
+		},
+		{
+			name:        "Action replaced by NotAction is an escalation",
+			before:      `{"Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			after:       `{"Statement": [{"Sid": "Read", "Effect": "Allow", "NotAction": "s3:DeleteBucket", "Resource": "*"}]}`,
+			escalations: 1,
+		},
+		{
+			name:        "widened NotAction is an escalation",
+			before:      `{"Statement": [{"Sid": "AllBut", "Effect": "Allow", "NotAction": ["iam:*", "organizations:*"], "Resource": "*"}]}`,
+			after:       `{"Statement": [{"Sid": "AllBut", "Effect": "Allow", "NotAction": "iam:*", "Resource": "*"}]}`,
+			escalations: 1,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				assert.Equal(t, []string{`NotAction exception organizations:* removed in statement "AllBut"`}, diff.Escalations)
+			},
+		},
+		{
+			name:        "widened NotPrincipal is an escalation",
+			before:      `{"Statement": [{"Sid": "Others", "Effect": "Allow", "NotPrincipal": {"AWS": ["arn:aws:iam::111111111111:root", "arn:aws:iam::222222222222:root"]}, "Action": "s3:GetObject", "Resource": "*"}]}`,
+			after:       `{"Statement": [{"Sid": "Others", "Effect": "Allow", "NotPrincipal": {"AWS": "arn:aws:iam::111111111111:root"}, "Action": "s3:GetObject", "Resource": "*"}]}`,
+			escalations: 1,
+			validate: func(t *testing.T, diff *PolicyDiff) {
+				assert.Equal(t, []string{`NotPrincipal exception AWS:arn:aws:iam::222222222222:root removed in statement "Others"`}, diff.Escalations)
+			},
+		},
+		{
+			name:        "NotAction replaced by Action is not an escalation",
+			before:      `{"Statement": [{"Sid": "Read", "Effect": "Allow", "NotAction": "iam:*", "Resource": "*"}]}`,
+			after:       `{"Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			escalations: 0,
+		},
+		{
+			name:        "NotAction in Deny statement is not an escalation",
+			before:      nil,
+			after:       `{"Statement": [{"Effect": "Deny", "NotAction": "s3:GetObject", "Resource": "*"}]}`,
+			escalations: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffPolicyDocuments(tt.before, tt.after)
+			if tt.expectNil {
+				assert.Nil(t, diff)
+				return
+			}
+			require.NotNil(t, diff)
+			assert.Len(t, diff.Escalations, tt.escalations)
+			if tt.validate != nil {
+				tt.validate(t, diff)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_PolicyEscalationMarksResourceDangerous(t *testing.T) {
+	plan := &tfjson.Plan{
+		FormatVersion: "1.2",
+		ResourceChanges: []*tfjson.ResourceChange{
+			{
+				Address: "aws_iam_policy.admin",
+				Type:    "aws_iam_policy",
+				Name:    "admin",
+				Change: &tfjson.Change{
+					Actions: []tfjson.Action{tfjson.ActionUpdate},
+					Before: map[string]any{
+						"name":   "admin",
+						"policy": `{"Version": "2012-10-17", "Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+					},
+					After: map[string]any{
+						"name":   "admin",
+						"policy": `{"Version": "2012-10-17", "Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "*", "Resource": "*"}]}`,
+					},
+				},
+			},
+		},
+	}
+
+	analyzer := NewAnalyzer(plan, config.GetDefaultConfig())
+	changes := analyzer.analyzeResourceChanges()
+	require.Len(t, changes, 1)
+
+	change := changes[0]
+	assert.True(t, change.IsDangerous)
+	assert.Equal(t, "Privilege escalation", change.DangerReason)
+	assert.Contains(t, change.DangerProperties, "policy")
+
+	require.Len(t, change.PropertyChanges.Changes, 1)
+	policyChange := change.PropertyChanges.Changes[0]
+	require.NotNil(t, policyChange.PolicyDiff)
+	assert.Equal(t, []string{"*"}, policyChange.PolicyDiff.Statements[0].AddedActions)
+	assert.Equal(t, []string{"s3:GetObject"}, policyChange.PolicyDiff.Statements[0].RemovedActions)
+}
+
+func TestAnalyzer_SensitivePolicyIsNotDiffed(t *testing.T) {
+	analyzer := NewAnalyzer(&tfjson.Plan{}, config.GetDefaultConfig())
+	analysis := analyzer.AnalyzePropertyChanges(&tfjson.ResourceChange{
+		Change: &tfjson.Change{
+			Actions:         []tfjson.Action{tfjson.ActionUpdate},
+			Before:          map[string]any{"policy": `{"Statement": []}`},
+			After:           map[string]any{"policy": `{"Statement": [{"Effect": "Allow", "Action": "*", "Resource": "*"}]}`},
+			BeforeSensitive: map[string]any{"policy": true},
+			AfterSensitive:  map[string]any{"policy": true},
+		},
+	})
+
+	require.Len(t, analysis.Changes, 1)
+	assert.Nil(t, analysis.Changes[0].PolicyDiff)
+}
+
+func TestFormatPropertyChange_PolicyDiff(t *testing.T) {
+	formatter := NewFormatter(config.GetDefaultConfig())
+	change := PropertyChange{
+		Name:   "policy",
+		Action: actionUpdate,
+		PolicyDiff: diffPolicyDocuments(
+			`{"Statement": [{"Sid": "Read", "Effect": "Allow", "Action": "s3:GetObject", "Resource": "*"}]}`,
+			`{"Statement": [{"Sid": "Read", "Effect": "Allow", "Action": ["s3:GetObject", "s3:*"], "Resource": "*"}]}`,
+		),
+	}
+
+	formatted := formatter.formatPropertyChange(change)
+
+	assert.True(t, strings.HasPrefix(formatted, indent+"~ policy (policy document)"), formatted)
+	assert.Contains(t, formatted, `~ statement "Read" (Allow)`)
+	assert.Contains(t, formatted, "+ action s3:*")
+	assert.Contains(t, formatted, "Privilege escalation: wildcard action s3:* granted")
+	assert.NotContains(t, formatted, `"Statement"`, "raw policy JSON should not be printed")
+}
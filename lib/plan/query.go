@@ -0,0 +1,117 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Query selects resource changes with an expression in the expr language (https://expr-lang.org).
+// Expressions use the JSON field names of resource changes, such as change_type, provider,
+// module_path, is_dangerous, and property_changes.changes, as well as the computed risk_level.
+type Query struct {
+	expression string
+	program    *vm.Program
+}
+
+// resourceChangeFields holds the kind of every JSON field of ResourceChange
+var resourceChangeFields = sync.OnceValue(func() map[string]reflect.Kind {
+	fields := map[string]reflect.Kind{}
+	changeType := reflect.TypeFor[ResourceChange]()
+	for i := range changeType.NumField() {
+		field := changeType.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = field.Type.Kind()
+	}
+	return fields
+})
+
+// CompileQuery parses and type checks a query expression, which must return a boolean.
+// Unknown field names are reported as errors.
+func CompileQuery(expression string) (*Query, error) {
+	env, err := queryRecord(ResourceChange{})
+	if err != nil {
+		return nil, err
+	}
+	program, err := expr.Compile(expression, expr.Env(env), expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return &Query{expression: expression, program: program}, nil
+}
+
+// Matches reports whether a resource change matches the query
+func (q *Query) Matches(change ResourceChange) (bool, error) {
+	record, err := queryRecord(change)
+	if err != nil {
+		return false, err
+	}
+	result, err := expr.Run(q.program, record)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate query for %s: %w", change.Address, err)
+	}
+	matched, _ := result.(bool)
+	return matched, nil
+}
+
+// Apply returns the resource changes that match the query
+func (q *Query) Apply(changes []ResourceChange) ([]ResourceChange, error) {
+	matches := make([]ResourceChange, 0, len(changes))
+	for _, change := range changes {
+		matched, err := q.Matches(change)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, change)
+		}
+	}
+	return matches, nil
+}
+
+// String returns the query expression
+func (q *Query) String() string {
+	return q.expression
+}
+
+// queryRecord converts a resource change to the variables available in queries. Fields that are
+// null or left out of the JSON are set to empty values, so queries don't need to check for nil.
+func queryRecord(change ResourceChange) (map[string]any, error) {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s for query: %w", change.Address, err)
+	}
+	record := map[string]any{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode %s for query: %w", change.Address, err)
+	}
+
+	for name, kind := range resourceChangeFields() {
+		if record[name] != nil {
+			continue
+		}
+		switch kind {
+		case reflect.Slice:
+			record[name] = []any{}
+		case reflect.String:
+			record[name] = ""
+		case reflect.Bool:
+			record[name] = false
+		case reflect.Struct, reflect.Map:
+			record[name] = map[string]any{}
+		}
+	}
+	if properties, ok := record["property_changes"].(map[string]any); ok && properties["changes"] == nil {
+		properties["changes"] = []any{}
+	}
+	record["risk_level"] = change.RiskLevel()
+	return record, nil
+}
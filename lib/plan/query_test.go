@@ -0,0 +1,53 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	changes := filterTestChanges()
+	changes[0].PropertyChanges = PropertyChangeAnalysis{Changes: []PropertyChange{{Name: "tags", Action: actionUpdate}}}
+	changes[2].ReplacementHints = []string{"bucket"}
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   []string
+	}{
+		{"change type and provider", `change_type == "delete" && provider == "aws"`, []string{`module.app["blue"].aws_iam_role.app`}},
+		{"property names", `any(property_changes.changes, .name == "tags")`, []string{"aws_instance.web"}},
+		{"list membership", `"tags" in map(property_changes.changes, .name)`, []string{"aws_instance.web"}},
+		{"risk level", `risk_level in ["high", "critical"]`, []string{`module.app["blue"].aws_iam_role.app`, "module.app.module.storage.aws_s3_bucket.data"}},
+		{"module path", `module_path startsWith "app/"`, []string{"module.app.module.storage.aws_s3_bucket.data"}},
+		{"fields left out of JSON", `len(replacement_hints) > 0`, []string{"module.app.module.storage.aws_s3_bucket.data"}},
+		{"danger flag", `!is_dangerous && type matches "^azurerm_"`, []string{"module.network.azurerm_subnet.main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := CompileQuery(tt.expression)
+			require.NoError(t, err)
+			matches, err := query.Apply(changes)
+			require.NoError(t, err)
+			addresses := []string{}
+			for _, change := range matches {
+				addresses = append(addresses, change.Address)
+			}
+			assert.Equal(t, tt.expected, addresses)
+		})
+	}
+}
+
+func TestCompileQuery_Errors(t *testing.T) {
+	_, err := CompileQuery(`chnage_type == "delete"`)
+	assert.ErrorContains(t, err, "unknown name chnage_type")
+
+	_, err = CompileQuery(`provider`)
+	assert.ErrorContains(t, err, "expected bool")
+
+	_, err = CompileQuery(`change_type ==`)
+	assert.ErrorContains(t, err, "invalid query")
+}
@@ -0,0 +1,84 @@
+package plan
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+// formatReport renders the summary as a self-contained interactive HTML report
+const formatReport = "report"
+
+//go:embed report/report.html.tmpl report/report.css report/report.js
+var reportFiles embed.FS
+
+// reportTemplate is the page of the HTML report. Its styles and script are added as trusted content.
+var reportTemplate = htmltemplate.Must(htmltemplate.ParseFS(reportFiles, "report/report.html.tmpl"))
+
+// IsReportFormat reports whether an output format renders the interactive HTML report
+func IsReportFormat(format string) bool {
+	return strings.EqualFold(format, formatReport)
+}
+
+// reportData holds the values used by the report template
+type reportData struct {
+	Summary     *PlanSummary
+	GeneratedAt string
+	Data        htmltemplate.JS // The summary as JSON, for the script
+	RiskLevels  htmltemplate.JS // The risk level of every resource change as JSON, by address
+	Style       htmltemplate.CSS
+	Script      htmltemplate.JS
+}
+
+// RenderReport writes a single HTML file containing the plan summary as JSON together with the styles
+// and script to search, filter, sort, and expand the resource changes, and to show their dependencies.
+// The report doesn't load anything from the network, so it works offline and can be archived or attached
+// to tickets. No-op resources and the resource filter are applied in the same way as for the other output formats.
+func (f *Formatter) RenderReport(w io.Writer, summary *PlanSummary) error {
+	if summary == nil {
+		return fmt.Errorf("plan summary cannot be nil")
+	}
+	filtered := f.filterSummary(summary)
+
+	riskLevels := make(map[string]string, len(filtered.ResourceChanges))
+	for _, change := range filtered.ResourceChanges {
+		riskLevels[change.Address] = change.RiskLevel()
+	}
+	// json.Marshal escapes <, >, and & in strings, so the data can't close the script element
+	data, err := json.Marshal(&filtered)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary for report: %w", err)
+	}
+	risks, err := json.Marshal(riskLevels)
+	if err != nil {
+		return fmt.Errorf("failed to encode risk levels for report: %w", err)
+	}
+	style, err := reportFiles.ReadFile("report/report.css")
+	if err != nil {
+		return fmt.Errorf("failed to read report styles: %w", err)
+	}
+	script, err := reportFiles.ReadFile("report/report.js")
+	if err != nil {
+		return fmt.Errorf("failed to read report script: %w", err)
+	}
+
+	var rendered bytes.Buffer
+	err = reportTemplate.Execute(&rendered, reportData{
+		Summary:     &filtered,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Data:        htmltemplate.JS(data),
+		RiskLevels:  htmltemplate.JS(risks),
+		Style:       htmltemplate.CSS(style),
+		Script:      htmltemplate.JS(script),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	_, err = w.Write(rendered.Bytes())
+	return err
+}
@@ -0,0 +1,456 @@
+(function () {
+  "use strict";
+
+  var summary = JSON.parse(document.getElementById("summary-data").textContent);
+  var riskLevels = JSON.parse(document.getElementById("risk-data").textContent);
+  var riskRank = { low: 0, medium: 1, high: 2, critical: 3 };
+  var actionOrder = { create: 0, update: 1, replace: 2, delete: 3, "no-op": 4 };
+  var rootModule = "(root)";
+
+  var resources = (summary.resource_changes || []).map(function (change, index) {
+    return {
+      index: index,
+      change: change,
+      risk: riskLevels[change.address] || "low",
+      module: moduleName(change),
+      changes: ((change.property_changes || {}).changes || []).length,
+      expanded: false
+    };
+  });
+  var byAddress = {};
+  resources.forEach(function (resource) { byAddress[resource.change.address] = resource; });
+
+  var state = { search: "", action: "", provider: "", module: "", risk: "", sort: "", descending: false, view: "table" };
+  var tbody = document.querySelector("#resources tbody");
+
+  function moduleName(change) {
+    return !change.module_path || change.module_path === "-" ? rootModule : change.module_path;
+  }
+
+  function element(tag, className, text) {
+    var node = document.createElement(tag);
+    if (className) {
+      node.className = className;
+    }
+    if (text !== undefined && text !== null) {
+      node.textContent = text;
+    }
+    return node;
+  }
+
+  function formatValue(value) {
+    if (value === undefined || value === null) {
+      return "null";
+    }
+    if (typeof value === "string") {
+      return JSON.stringify(value);
+    }
+    return JSON.stringify(value, null, 2);
+  }
+
+  function unique(values) {
+    return values.filter(function (value, index) { return value && values.indexOf(value) === index; }).sort();
+  }
+
+  function fillSelect(id, values) {
+    var select = document.getElementById(id);
+    values.forEach(function (value) {
+      var option = element("option", "", value);
+      option.value = value;
+      select.appendChild(option);
+    });
+    select.addEventListener("change", function () {
+      state[id.replace("filter-", "")] = select.value;
+      render();
+    });
+  }
+
+  function matches(resource) {
+    var change = resource.change;
+    if (state.action && change.change_type !== state.action) {
+      return false;
+    }
+    if (state.provider && change.provider !== state.provider) {
+      return false;
+    }
+    if (state.module && resource.module !== state.module) {
+      return false;
+    }
+    if (state.risk && riskRank[resource.risk] < riskRank[state.risk]) {
+      return false;
+    }
+    if (!state.search) {
+      return true;
+    }
+    var query = state.search.toLowerCase();
+    return [change.address, change.type, change.danger_reason || "", resource.module].some(function (field) {
+      return field.toLowerCase().indexOf(query) >= 0;
+    });
+  }
+
+  function sortValue(resource) {
+    switch (state.sort) {
+      case "change_type":
+        return actionOrder[resource.change.change_type];
+      case "risk":
+        return riskRank[resource.risk];
+      case "changes":
+        return resource.changes;
+      case "module":
+        return resource.module;
+      default:
+        return resource.change[state.sort] || "";
+    }
+  }
+
+  function visibleResources() {
+    var visible = resources.filter(matches);
+    if (state.sort) {
+      visible.sort(function (a, b) {
+        var left = sortValue(a);
+        var right = sortValue(b);
+        var result = left < right ? -1 : left > right ? 1 : a.index - b.index;
+        return state.descending ? -result : result;
+      });
+    }
+    return visible;
+  }
+
+  function propertyName(property) {
+    return property.path && property.path.length ? property.path.join(".") : property.name;
+  }
+
+  function propertyRow(property) {
+    var row = element("tr");
+    var before = formatValue(property.before);
+    var after = formatValue(property.after);
+    var notes = [];
+    if (property.sensitive) {
+      var status = property.sensitive_status || "changed";
+      before = "(sensitive value)";
+      after = "(sensitive value, " + status + ")";
+    }
+    if (property.action === "add") {
+      before = "";
+    }
+    if (property.action === "remove") {
+      after = "";
+    }
+    if (property.is_unknown && property.unknown_type !== "before") {
+      after = "(known after apply)";
+    }
+    if (property.triggers_replacement) {
+      notes.push("forces replacement");
+    }
+    if (property.perpetual_diff) {
+      notes.push("perpetual diff");
+    }
+    if (property.no_semantic_change) {
+      notes.push("no semantic change");
+    }
+    row.appendChild(element("td", "value", propertyName(property)));
+    row.appendChild(element("td", "value", before));
+    row.appendChild(element("td", "value", after));
+    row.appendChild(element("td", "note", notes.join(", ")));
+    return row;
+  }
+
+  function addDetail(list, label, value) {
+    if (!value || (Array.isArray(value) && value.length === 0)) {
+      return;
+    }
+    list.appendChild(element("dt", "", label));
+    list.appendChild(element("dd", label === "Danger" ? "danger" : "", Array.isArray(value) ? value.join(", ") : value));
+  }
+
+  function detailsRow(resource) {
+    var change = resource.change;
+    var row = element("tr", "details");
+    var cell = element("td");
+    cell.colSpan = 7;
+    var list = element("dl");
+    addDetail(list, "Danger", change.danger_reason);
+    addDetail(list, "Physical ID", change.physical_id !== "-" ? change.physical_id : "");
+    addDetail(list, "Replacement caused by", change.replacement_hints);
+    addDetail(list, "Known after apply", change.unknown_properties);
+    addDetail(list, "Depends on", change.dependencies);
+    cell.appendChild(list);
+
+    var properties = (change.property_changes || {}).changes || [];
+    if (properties.length) {
+      var table = element("table");
+      var head = element("tr");
+      ["Property", "Before", "After", ""].forEach(function (title) { head.appendChild(element("th", "", title)); });
+      table.appendChild(head);
+      properties.forEach(function (property) { table.appendChild(propertyRow(property)); });
+      cell.appendChild(table);
+      if (change.property_changes.truncated) {
+        cell.appendChild(element("p", "note", "More property changes were not included in the plan summary."));
+      }
+    } else {
+      cell.appendChild(element("p", "note", "No property changes."));
+    }
+    row.appendChild(cell);
+    row.hidden = !resource.expanded;
+    return row;
+  }
+
+  function resourceRow(resource) {
+    var change = resource.change;
+    var row = element("tr", "resource" + (resource.expanded ? " expanded" : ""));
+    row.id = "resource-" + resource.index;
+    var address = element("td");
+    if (change.is_dangerous) {
+      address.appendChild(element("span", "danger", "⚠ "));
+    }
+    address.appendChild(element("code", "", change.address));
+    row.appendChild(address);
+    var action = element("td");
+    action.appendChild(element("span", "badge " + change.change_type, change.change_type));
+    row.appendChild(action);
+    row.appendChild(element("td", "", change.type));
+    row.appendChild(element("td", "", change.provider || ""));
+    row.appendChild(element("td", "", resource.module));
+    var risk = element("td");
+    risk.appendChild(element("span", "badge risk-" + resource.risk, resource.risk));
+    row.appendChild(risk);
+    row.appendChild(element("td", "", String(resource.changes)));
+    return row;
+  }
+
+  function renderTable(visible) {
+    tbody.textContent = "";
+    visible.forEach(function (resource) {
+      var row = resourceRow(resource);
+      var details = detailsRow(resource);
+      row.addEventListener("click", function () {
+        resource.expanded = !resource.expanded;
+        row.classList.toggle("expanded", resource.expanded);
+        details.hidden = !resource.expanded;
+      });
+      tbody.appendChild(row);
+      tbody.appendChild(details);
+    });
+    if (!visible.length) {
+      var empty = element("tr");
+      var cell = element("td", "note", "No resources match the filters.");
+      cell.colSpan = 7;
+      empty.appendChild(cell);
+      tbody.appendChild(empty);
+    }
+    document.querySelectorAll("#resources th").forEach(function (th) {
+      th.classList.toggle("sorted-asc", th.dataset.sort === state.sort && !state.descending);
+      th.classList.toggle("sorted-desc", th.dataset.sort === state.sort && state.descending);
+    });
+  }
+
+  // renderGraph lays out the visible resources in columns by dependency depth, with resources
+  // that depend on nothing on the right and arrows pointing to their dependencies
+  function renderGraph(visible) {
+    var container = document.getElementById("graph");
+    container.textContent = "";
+    var included = {};
+    visible.forEach(function (resource) { included[resource.change.address] = resource; });
+    var dependencies = {};
+    visible.forEach(function (resource) {
+      dependencies[resource.change.address] = (resource.change.dependencies || []).filter(function (address) {
+        return included[address];
+      });
+    });
+
+    var depths = {};
+    function depth(address, path) {
+      if (depths[address] !== undefined) {
+        return depths[address];
+      }
+      if (path[address]) {
+        return 0; // Dependency cycles can't occur in valid plans, but don't loop forever
+      }
+      path[address] = true;
+      var result = 0;
+      dependencies[address].forEach(function (dependency) {
+        result = Math.max(result, depth(dependency, path) + 1);
+      });
+      delete path[address];
+      depths[address] = result;
+      return result;
+    }
+
+    var columns = [];
+    visible.forEach(function (resource) {
+      var level = depth(resource.change.address, {});
+      (columns[level] = columns[level] || []).push(resource);
+    });
+    columns.reverse();
+
+    var svgNS = "http://www.w3.org/2000/svg";
+    var nodeWidth = 280, nodeHeight = 26, columnGap = 70, rowGap = 10, margin = 16;
+    var height = 0;
+    var positions = {};
+    columns.forEach(function (column, columnIndex) {
+      (column || []).forEach(function (resource, rowIndex) {
+        positions[resource.change.address] = {
+          x: margin + columnIndex * (nodeWidth + columnGap),
+          y: margin + rowIndex * (nodeHeight + rowGap)
+        };
+      });
+      height = Math.max(height, (column || []).length * (nodeHeight + rowGap));
+    });
+
+    var svg = document.createElementNS(svgNS, "svg");
+    svg.setAttribute("width", String(margin * 2 + columns.length * (nodeWidth + columnGap)));
+    svg.setAttribute("height", String(margin * 2 + height));
+    var defs = document.createElementNS(svgNS, "defs");
+    var marker = document.createElementNS(svgNS, "marker");
+    marker.setAttribute("id", "arrow");
+    marker.setAttribute("viewBox", "0 0 10 10");
+    marker.setAttribute("refX", "10");
+    marker.setAttribute("refY", "5");
+    marker.setAttribute("markerWidth", "6");
+    marker.setAttribute("markerHeight", "6");
+    marker.setAttribute("orient", "auto");
+    var arrow = document.createElementNS(svgNS, "path");
+    arrow.setAttribute("d", "M0,0 L10,5 L0,10 z");
+    arrow.setAttribute("fill", "#8c959f");
+    marker.appendChild(arrow);
+    defs.appendChild(marker);
+    svg.appendChild(defs);
+
+    visible.forEach(function (resource) {
+      var from = positions[resource.change.address];
+      dependencies[resource.change.address].forEach(function (address) {
+        var to = positions[address];
+        var startX = from.x + nodeWidth, startY = from.y + nodeHeight / 2;
+        var endX = to.x, endY = to.y + nodeHeight / 2;
+        var middle = (startX + endX) / 2;
+        var edge = document.createElementNS(svgNS, "path");
+        edge.setAttribute("class", "edge");
+        edge.setAttribute("d", "M" + startX + "," + startY + " C" + middle + "," + startY + " " + middle + "," + endY + " " + endX + "," + endY);
+        edge.setAttribute("marker-end", "url(#arrow)");
+        svg.appendChild(edge);
+      });
+    });
+
+    visible.forEach(function (resource) {
+      var position = positions[resource.change.address];
+      var node = document.createElementNS(svgNS, "g");
+      node.setAttribute("class", "node " + resource.change.change_type + (resource.change.is_dangerous ? " dangerous" : ""));
+      node.setAttribute("transform", "translate(" + position.x + "," + position.y + ")");
+      var title = document.createElementNS(svgNS, "title");
+      title.textContent = resource.change.address + " (" + resource.change.change_type + ")";
+      node.appendChild(title);
+      var rect = document.createElementNS(svgNS, "rect");
+      rect.setAttribute("width", String(nodeWidth));
+      rect.setAttribute("height", String(nodeHeight));
+      node.appendChild(rect);
+      var label = document.createElementNS(svgNS, "text");
+      label.setAttribute("x", "8");
+      label.setAttribute("y", "17");
+      var text = resource.change.address;
+      label.textContent = text.length > 38 ? "…" + text.slice(text.length - 37) : text;
+      node.appendChild(label);
+      node.addEventListener("click", function () { showResource(resource); });
+      svg.appendChild(node);
+    });
+
+    if (!visible.length) {
+      container.appendChild(element("p", "note", "No resources match the filters."));
+      return;
+    }
+    container.appendChild(svg);
+  }
+
+  function showResource(resource) {
+    resource.expanded = true;
+    setView("table");
+    var row = document.getElementById("resource-" + resource.index);
+    if (row) {
+      document.querySelectorAll("tr.selected").forEach(function (selected) { selected.classList.remove("selected"); });
+      row.classList.add("selected");
+      row.scrollIntoView({ block: "center" });
+    }
+  }
+
+  function renderOutputs() {
+    var outputs = summary.output_changes || [];
+    var section = document.getElementById("outputs-view");
+    if (!outputs.length) {
+      section.hidden = true;
+      return;
+    }
+    var body = document.querySelector("#outputs tbody");
+    outputs.forEach(function (output) {
+      var row = element("tr");
+      row.appendChild(element("td", "value", output.name));
+      var action = element("td");
+      action.appendChild(element("span", "badge " + output.change_type, output.change_type));
+      row.appendChild(action);
+      var before = output.sensitive ? "(sensitive value)" : formatValue(output.before);
+      var after = output.sensitive ? "(sensitive value)" : output.is_unknown ? "(known after apply)" : formatValue(output.after);
+      row.appendChild(element("td", "value", output.change_type === "create" ? "" : before));
+      row.appendChild(element("td", "value", output.change_type === "delete" ? "" : after));
+      body.appendChild(row);
+    });
+  }
+
+  function render() {
+    var visible = visibleResources();
+    document.getElementById("match-count").textContent = "Showing " + visible.length + " of " + resources.length + " resource changes";
+    if (state.view === "graph") {
+      renderGraph(visible);
+    } else {
+      renderTable(visible);
+    }
+  }
+
+  function setView(view) {
+    state.view = view;
+    document.getElementById("table-view").hidden = view !== "table";
+    document.getElementById("graph-view").hidden = view !== "graph";
+    document.getElementById("view-table").classList.toggle("active", view === "table");
+    document.getElementById("view-graph").classList.toggle("active", view === "graph");
+    render();
+  }
+
+  function setExpanded(expanded) {
+    resources.forEach(function (resource) { resource.expanded = expanded; });
+    render();
+  }
+
+  fillSelect("filter-action", unique(resources.map(function (resource) { return resource.change.change_type; })));
+  fillSelect("filter-provider", unique(resources.map(function (resource) { return resource.change.provider; })));
+  fillSelect("filter-module", unique(resources.map(function (resource) { return resource.module; })));
+  fillSelect("filter-risk", []);
+
+  document.getElementById("search").addEventListener("input", function (event) {
+    state.search = event.target.value;
+    render();
+  });
+  document.getElementById("clear-filters").addEventListener("click", function () {
+    state.search = state.action = state.provider = state.module = state.risk = "";
+    document.getElementById("search").value = "";
+    document.querySelectorAll(".controls select").forEach(function (select) { select.value = ""; });
+    render();
+  });
+  document.querySelectorAll("#resources th").forEach(function (th) {
+    th.addEventListener("click", function () {
+      state.descending = state.sort === th.dataset.sort ? !state.descending : false;
+      state.sort = th.dataset.sort;
+      render();
+    });
+  });
+  document.getElementById("view-table").addEventListener("click", function () { setView("table"); });
+  document.getElementById("view-graph").addEventListener("click", function () { setView("graph"); });
+  document.getElementById("expand-all").addEventListener("click", function () { setExpanded(true); });
+  document.getElementById("collapse-all").addEventListener("click", function () { setExpanded(false); });
+  document.getElementById("print").addEventListener("click", function () { window.print(); });
+  // Printing always uses the table, which shows the property changes of every resource
+  window.addEventListener("beforeprint", function () {
+    if (state.view !== "table") {
+      setView("table");
+    }
+  });
+
+  renderOutputs();
+  render();
+})();
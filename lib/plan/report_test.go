@@ -0,0 +1,77 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportScripts returns the contents of the JSON data scripts in a report, by element ID
+func reportScripts(t *testing.T, report string) map[string]string {
+	t.Helper()
+	scripts := map[string]string{}
+	for _, match := range regexp.MustCompile(`<script type="application/json" id="([^"]+)">(.*?)</script>`).FindAllStringSubmatch(report, -1) {
+		scripts[match[1]] = match[2]
+	}
+	return scripts
+}
+
+func TestFormatter_RenderReport(t *testing.T) {
+	summary := templateTestSummary()
+	summary.ResourceChanges[0].Dependencies = []string{"aws_vpc.main"}
+	summary.ResourceChanges[0].DangerReason = "</script><script>alert(1)</script>"
+
+	var rendered bytes.Buffer
+	require.NoError(t, NewFormatter(config.GetDefaultConfig()).RenderReport(&rendered, summary))
+	report := rendered.String()
+
+	assert.Contains(t, report, "<title>Plan report: test.tfplan</title>")
+	assert.NotContains(t, report, "<script>alert(1)</script>", "data must not be able to close the script element")
+	assert.NotRegexp(t, `<(link|script) [^>]*(src|href)=`, report, "the report must not load external files")
+
+	scripts := reportScripts(t, report)
+	var data PlanSummary
+	require.NoError(t, json.Unmarshal([]byte(scripts["summary-data"]), &data))
+	// No-op resources are left out, as in the other output formats
+	assert.Len(t, data.ResourceChanges, 4)
+	assert.Equal(t, summary.ResourceChanges[0].DangerReason, data.ResourceChanges[0].DangerReason)
+	assert.Equal(t, []string{"aws_vpc.main"}, data.ResourceChanges[0].Dependencies)
+
+	var risks map[string]string
+	require.NoError(t, json.Unmarshal([]byte(scripts["risk-data"]), &risks))
+	assert.Equal(t, "critical", risks[`module.app["blue"].aws_iam_role.app`])
+}
+
+func TestFormatter_RenderReport_ResourceFilter(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.Filter.Providers = []string{"azurerm"}
+
+	var rendered bytes.Buffer
+	require.NoError(t, NewFormatter(cfg).RenderReport(&rendered, templateTestSummary()))
+
+	var data PlanSummary
+	require.NoError(t, json.Unmarshal([]byte(reportScripts(t, rendered.String())["summary-data"]), &data))
+	require.Len(t, data.ResourceChanges, 1)
+	assert.Equal(t, "module.network.azurerm_subnet.main", data.ResourceChanges[0].Address)
+}
+
+func TestFormatter_OutputSummary_ReportFile(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	outputFile := filepath.Join(t.TempDir(), "report.html")
+	outputConfig := cfg.NewOutputConfigurationForFormat("markdown")
+	outputConfig.OutputFile = outputFile
+	outputConfig.OutputFileFormat = "report"
+
+	require.NoError(t, NewFormatter(cfg).OutputSummary(templateTestSummary(), outputConfig, false))
+	written, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(written), "<!DOCTYPE html>")
+	assert.Contains(t, reportScripts(t, string(written)), "summary-data")
+}
@@ -0,0 +1,140 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SummarySchemaVersion is the version of the JSON format of plan summaries, as written by --save-summary,
+// the explore export, and the Go library. It changes whenever a field is added, removed, renamed, or
+// changes type, so consumers can tell which shape of summary they are reading.
+const SummarySchemaVersion = "1"
+
+// summarySchemaID identifies the published JSON Schema for a summary schema version
+const summarySchemaID = "https://github.com/ArjenSchwarz/strata/schema/summary-v%s.schema.json"
+
+// schemaEnums lists the values of the string types in the summary that only have a fixed set of values
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeFor[ChangeType](): {
+		string(ChangeTypeCreate), string(ChangeTypeUpdate), string(ChangeTypeDelete),
+		string(ChangeTypeReplace), string(ChangeTypeNoOp),
+	},
+	reflect.TypeFor[ReplacementType](): {string(ReplacementNever), string(ReplacementAlways)},
+}
+
+// SummarySchema returns the JSON Schema (draft 2020-12) of plan summaries in their JSON format.
+// The schema is generated from the summary types, so it always matches what is written.
+func SummarySchema() ([]byte, error) {
+	generator := &schemaGenerator{defs: map[string]map[string]any{}}
+	root := generator.schemaFor(reflect.TypeFor[PlanSummary]())
+
+	// The summary always carries the version of the schema it was written with
+	summary := generator.defs["PlanSummary"]
+	summary["properties"].(map[string]any)["schema_version"] = map[string]any{
+		"type":  "string",
+		"const": SummarySchemaVersion,
+	}
+
+	schema := map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id":     fmt.Sprintf(summarySchemaID, SummarySchemaVersion),
+		"title":   "Strata plan summary",
+		"$ref":    root["$ref"],
+		"$defs":   generator.defs,
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode summary schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// schemaGenerator builds JSON Schemas from Go types, following the rules of encoding/json.
+// Every struct becomes a definition that is referenced by name, which also handles recursive types.
+type schemaGenerator struct {
+	defs map[string]map[string]any
+}
+
+// schemaFor returns the schema of a value of the given type
+func (g *schemaGenerator) schemaFor(t reflect.Type) map[string]any {
+	if t == reflect.TypeFor[time.Time]() {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schemaFor(t.Elem()))
+	case reflect.Struct:
+		g.define(t)
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		// nil slices are written as null
+		return map[string]any{"type": []string{"array", "null"}, "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// define adds the definition of a struct type, unless it's already defined or being defined
+func (g *schemaGenerator) define(t reflect.Type) {
+	if _, ok := g.defs[t.Name()]; ok {
+		return
+	}
+	properties := map[string]any{}
+	required := []string{}
+	definition := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	g.defs[t.Name()] = definition
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaFor(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	definition["required"] = required
+}
+
+// nullable allows null in addition to the values of a schema
+func nullable(schema map[string]any) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+	}
+	if kind, ok := schema["type"].(string); ok {
+		schema["type"] = []string{kind, "null"}
+	}
+	return schema
+}
@@ -0,0 +1,258 @@
+package plan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// publishedSummarySchema is the schema file that is published with the repository
+const publishedSummarySchema = "../../schema/summary.schema.json"
+
+// summarySchemaFingerprints holds the SHA-256 of the generated schema for every summary schema version.
+// The schema describes the json output format as well as saved summaries, see TestSummarySchema_DescribesJSONOutput.
+// A version's fingerprint must never change: when the summary types change, bump SummarySchemaVersion,
+// add the new fingerprint here, and regenerate the published schema with `strata schema`.
+var summarySchemaFingerprints = map[string]string{
+	"1": "e429cf189f997ef5880bdae40269160362e382683ad8ed5bd700b27d0963dff4",
+}
+
+func TestSummarySchema_VersionMatchesShape(t *testing.T) {
+	schema, err := SummarySchema()
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(schema)
+	fingerprint := hex.EncodeToString(sum[:])
+	expected, ok := summarySchemaFingerprints[SummarySchemaVersion]
+	require.True(t, ok, "summary schema version %s has no fingerprint, add %q to summarySchemaFingerprints", SummarySchemaVersion, fingerprint)
+	assert.Equal(t, expected, fingerprint,
+		"the JSON format of plan summaries changed, bump SummarySchemaVersion and add its fingerprint to summarySchemaFingerprints")
+}
+
+func TestSummarySchema_MatchesPublishedFile(t *testing.T) {
+	schema, err := SummarySchema()
+	require.NoError(t, err)
+
+	published, err := os.ReadFile(publishedSummarySchema)
+	require.NoError(t, err)
+	assert.Equal(t, string(schema), string(published),
+		"the published schema is out of date, regenerate it with `strata schema > schema/summary.schema.json`")
+}
+
+func TestSummarySchema_Structure(t *testing.T) {
+	var schema map[string]any
+	data, err := SummarySchema()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+	assert.Contains(t, schema["$id"], "summary-v"+SummarySchemaVersion)
+	assert.Equal(t, "#/$defs/PlanSummary", schema["$ref"])
+
+	defs := schema["$defs"].(map[string]any)
+	for _, name := range []string{"PlanSummary", "ResourceChange", "OutputChange", "PropertyChange", "PolicyDiff", "ChangeStatistics"} {
+		assert.Contains(t, defs, name)
+	}
+
+	summary := defs["PlanSummary"].(map[string]any)
+	assert.Contains(t, summary["required"], "schema_version")
+	properties := summary["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string", "format": "date-time"}, properties["created_at"])
+
+	resource := defs["ResourceChange"].(map[string]any)
+	resourceProperties := resource["properties"].(map[string]any)
+	assert.Contains(t, resourceProperties, "is_no_op", "hidden fields should be part of the contract")
+	assert.Contains(t, resource["required"], "is_no_op")
+	assert.NotContains(t, resource["required"], "dependencies", "omitempty fields are optional")
+	assert.Equal(t, []any{"create", "update", "delete", "replace", "no-op"}, resourceProperties["change_type"].(map[string]any)["enum"])
+
+	// PropertyChange refers to itself for changes within encoded documents
+	change := defs["PropertyChange"].(map[string]any)["properties"].(map[string]any)
+	assert.Equal(t, "#/$defs/PropertyChange", change["encoded_changes"].(map[string]any)["items"].(map[string]any)["$ref"])
+}
+
+func TestSummarySchema_ValidatesSummaries(t *testing.T) {
+	var schema map[string]any
+	data, err := SummarySchema()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	samples := []string{
+		"../../samples/danger-sample.json",
+		"../../samples/complex-properties-sample.json",
+		"../../samples/replacements-sample.json",
+		"../../samples/nochange-sample.json",
+	}
+	for _, sample := range samples {
+		t.Run(sample, func(t *testing.T) {
+			tfPlan, err := NewParser(sample).LoadPlan()
+			require.NoError(t, err)
+			summary := NewAnalyzer(tfPlan, config.GetDefaultConfig()).GenerateSummary(sample)
+			assert.Equal(t, SummarySchemaVersion, summary.SchemaVersion)
+
+			encoded, err := json.Marshal(summary)
+			require.NoError(t, err)
+			var document any
+			require.NoError(t, json.Unmarshal(encoded, &document))
+
+			for _, problem := range validateSchema(schema, schema, document, "") {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+func TestSummarySchema_DescribesJSONOutput(t *testing.T) {
+	var schema map[string]any
+	data, err := SummarySchema()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.ShowNoOps = true
+	for _, sample := range []string{"../../samples/danger-sample.json", "../../samples/nochange-sample.json"} {
+		t.Run(sample, func(t *testing.T) {
+			tfPlan, err := NewParser(sample).LoadPlan()
+			require.NoError(t, err)
+			summary := NewAnalyzer(tfPlan, cfg).GenerateSummary(sample)
+
+			// The json output format is the contract the schema version covers, so it must be the summary document
+			var rendered bytes.Buffer
+			outputConfig := cfg.NewOutputConfigurationForFormat("json")
+			require.NoError(t, NewFormatter(cfg).RenderSummary(&rendered, summary, outputConfig, true))
+
+			var document map[string]any
+			require.NoError(t, json.Unmarshal(rendered.Bytes(), &document))
+			assert.Equal(t, SummarySchemaVersion, document["schema_version"])
+			for _, problem := range validateSchema(schema, schema, document, "") {
+				t.Error(problem)
+			}
+
+			expected, err := json.Marshal(summary)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), rendered.String(), "with nothing filtered, the json output is the summary")
+		})
+	}
+}
+
+func TestSummarySchema_RejectsUnknownFields(t *testing.T) {
+	var schema map[string]any
+	data, err := SummarySchema()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &schema))
+
+	encoded, err := json.Marshal(&PlanSummary{SchemaVersion: SummarySchemaVersion})
+	require.NoError(t, err)
+	var document map[string]any
+	require.NoError(t, json.Unmarshal(encoded, &document))
+	assert.Empty(t, validateSchema(schema, schema, document, ""))
+
+	document["unexpected"] = true
+	document["schema_version"] = "0"
+	delete(document, "statistics")
+	problems := strings.Join(validateSchema(schema, schema, document, ""), "\n")
+	assert.Contains(t, problems, "unexpected property unexpected")
+	assert.Contains(t, problems, "schema_version")
+	assert.Contains(t, problems, "missing required property statistics")
+}
+
+// validateSchema checks a decoded JSON document against the subset of JSON Schema that SummarySchema uses,
+// and returns the problems it finds
+func validateSchema(root, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs := root["$defs"].(map[string]any)
+		return validateSchema(root, defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any), value, path)
+	}
+	if options, ok := schema["anyOf"].([]any); ok {
+		for _, option := range options {
+			if len(validateSchema(root, option.(map[string]any), value, path)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: doesn't match any of the allowed schemas", path)}
+	}
+	if types, ok := schema["type"]; ok && !slices.Contains(schemaTypes(types), jsonType(value)) {
+		return []string{fmt.Sprintf("%s: %s isn't of type %v", path, jsonType(value), types)}
+	}
+	if constant, ok := schema["const"]; ok && value != constant {
+		return []string{fmt.Sprintf("%s: %v isn't %v", path, value, constant)}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s: %v isn't one of %v", path, value, enum)}
+	}
+
+	var problems []string
+	switch value := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range schemaStrings(schema["required"]) {
+			if _, ok := value[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required property %s", path, name))
+			}
+		}
+		for name, property := range value {
+			if propertySchema, ok := properties[name]; ok {
+				problems = append(problems, validateSchema(root, propertySchema.(map[string]any), property, path+"/"+name)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				problems = append(problems, validateSchema(root, additional, property, path+"/"+name)...)
+			} else if schema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s: unexpected property %s", path, name))
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				problems = append(problems, validateSchema(root, items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	}
+	return problems
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == float64(int64(value)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// schemaTypes returns the allowed types of a schema, which may be a single type or a list
+func schemaTypes(types any) []string {
+	if kind, ok := types.(string); ok {
+		return []string{kind}
+	}
+	return schemaStrings(types)
+}
+
+func schemaStrings(values any) []string {
+	list, _ := values.([]any)
+	result := make([]string, 0, len(list))
+	for _, value := range list {
+		result = append(result, value.(string))
+	}
+	return result
+}
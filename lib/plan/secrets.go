@@ -0,0 +1,388 @@
+package plan
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	secretRuleEntropy     = "high_entropy_string"
+	secretRuleKeywordName = "secret_keyword"
+
+	// redactedChange replaces a redacted value that changed but looks the same as before once redacted
+	redactedChange = "(redacted, changed)"
+)
+
+// secretRule is a pattern that identifies a secret in a string value.
+// When group is non-zero only that capture group is redacted, leaving surrounding context readable.
+type secretRule struct {
+	name    string
+	pattern *regexp.Regexp
+	group   int
+}
+
+// builtinSecretRules are the known credential patterns that are always checked
+var builtinSecretRules = []secretRule{
+	{name: "private_key", pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?:[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----)?`)},
+	{name: "aws_access_key_id", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA)[0-9A-Z]{16}\b`)},
+	{name: "aws_secret_access_key", pattern: regexp.MustCompile(`(?i)(?:aws_secret_access_key|secret_access_key|aws_secret_key)["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`), group: 1},
+	{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`)},
+	{name: "connection_string_password", pattern: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^:/?#\s@]+:([^@\s/]+)@`), group: 1},
+	{name: "github_token", pattern: regexp.MustCompile(`\b(?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}\b`)},
+	{name: "slack_token", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+}
+
+// secretKeyPattern matches map keys that usually hold secrets, such as DB_PASSWORD in an environment map
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[_-]?key|private[_-]?key|credential)`)
+
+// nonSecretKeySuffix excludes keys that reference a secret rather than contain one (e.g., secret_arn)
+var nonSecretKeySuffix = regexp.MustCompile(`(?i)[_-]?(arn|name|id|version|length|path|ttl|type|enabled|policy)$`)
+
+// entropyTokenSeparators splits strings into candidate tokens for the entropy heuristic
+var entropyTokenSeparators = regexp.MustCompile(`[\s"'=:,;()\[\]{}<>]+`)
+
+// secretScanner detects and redacts secrets in plan values
+type secretScanner struct {
+	rules            []secretRule
+	entropyThreshold float64
+	minEntropyLength int
+}
+
+// newSecretScanner creates a scanner from the secret scanning configuration.
+// It returns nil when scanning is disabled. Invalid custom patterns are skipped, as they are
+// rejected by configuration validation before analysis starts.
+func newSecretScanner(cfg *config.Config) *secretScanner {
+	if cfg == nil || !cfg.SecretScanning.Enabled {
+		return nil
+	}
+
+	scanner := &secretScanner{
+		rules:            slices.Clone(builtinSecretRules),
+		entropyThreshold: cfg.SecretScanning.EntropyThreshold,
+		minEntropyLength: cfg.SecretScanning.MinEntropyLength,
+	}
+	if scanner.entropyThreshold == 0 {
+		scanner.entropyThreshold = 4.5
+	}
+	if scanner.minEntropyLength == 0 {
+		scanner.minEntropyLength = 20
+	}
+
+	for _, custom := range cfg.SecretScanning.Patterns {
+		pattern, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			continue
+		}
+		scanner.rules = append(scanner.rules, secretRule{name: custom.Name, pattern: pattern})
+	}
+
+	return scanner
+}
+
+// redactedMarker returns the placeholder shown in place of a detected secret
+func redactedMarker(rule string) string {
+	return fmt.Sprintf("(redacted %s)", rule)
+}
+
+// redactString replaces every secret in the string and returns the names of the rules that matched
+func (s *secretScanner) redactString(value string) (string, []string) {
+	var matchedRules []string
+
+	for _, rule := range s.rules {
+		matches := rule.pattern.FindAllStringSubmatchIndex(value, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		matchedRules = append(matchedRules, rule.name)
+
+		var builder strings.Builder
+		last := 0
+		for _, match := range matches {
+			start, end := match[0], match[1]
+			if rule.group > 0 && len(match) > 2*rule.group+1 && match[2*rule.group] >= 0 {
+				start, end = match[2*rule.group], match[2*rule.group+1]
+			}
+			builder.WriteString(value[last:start])
+			builder.WriteString(redactedMarker(rule.name))
+			last = end
+		}
+		builder.WriteString(value[last:])
+		value = builder.String()
+	}
+
+	// Apply the entropy heuristic to whatever the known patterns left behind
+	for _, token := range entropyTokenSeparators.Split(value, -1) {
+		if s.isHighEntropyToken(token) {
+			value = strings.ReplaceAll(value, token, redactedMarker(secretRuleEntropy))
+			if !slices.Contains(matchedRules, secretRuleEntropy) {
+				matchedRules = append(matchedRules, secretRuleEntropy)
+			}
+		}
+	}
+
+	return value, matchedRules
+}
+
+// isHighEntropyToken reports whether a token looks like a random secret.
+// Tokens need a mix of character classes so hex digests and identifiers aren't flagged.
+func (s *secretScanner) isHighEntropyToken(token string) bool {
+	if len(token) < s.minEntropyLength || strings.HasPrefix(token, "(redacted") {
+		return false
+	}
+
+	var hasLower, hasUpper, hasDigit bool
+	for _, r := range token {
+		switch {
+		case r >= 'a' && r <= 'z':
+			hasLower = true
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= '0' && r <= '9':
+			hasDigit = true
+		}
+	}
+	if !hasLower || !hasUpper || !hasDigit {
+		return false
+	}
+
+	return shannonEntropy(token) >= s.entropyThreshold && !isEncodedText(token)
+}
+
+// isEncodedText reports whether a token is base64 that decodes to readable text, such as an
+// encoded user_data script. These have high entropy but aren't random key material.
+func isEncodedText(token string) bool {
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		decoded, err := encoding.DecodeString(token)
+		if err != nil {
+			continue
+		}
+		return utf8.Valid(decoded) && !strings.ContainsFunc(string(decoded), func(r rune) bool {
+			return !unicode.IsPrint(r) && !unicode.IsSpace(r)
+		})
+	}
+	return false
+}
+
+// shannonEntropy calculates the Shannon entropy of a string in bits per character
+func shannonEntropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	frequencies := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		frequencies[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range frequencies {
+		probability := float64(count) / float64(total)
+		entropy -= probability * math.Log2(probability)
+	}
+	return entropy
+}
+
+// redactValue returns a copy of value with all secrets redacted, along with findings for each affected path.
+// Values marked as sensitive are left untouched as they are masked separately.
+// The input is never modified, as it may be shared with the parsed plan.
+func (s *secretScanner) redactValue(path string, value any, sensitive any) (any, []SecretFinding) {
+	if isSensitiveMarker(sensitive) {
+		return value, nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		redacted, rules := s.redactString(v)
+		findings := make([]SecretFinding, 0, len(rules))
+		for _, rule := range rules {
+			findings = append(findings, SecretFinding{Property: path, Rule: rule})
+		}
+		return redacted, findings
+	case map[string]any:
+		var findings []SecretFinding
+		result := make(map[string]any, len(v))
+		sensitiveMap, _ := sensitive.(map[string]any)
+		for key, item := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			var childSensitive any
+			if sensitiveMap != nil {
+				childSensitive = sensitiveMap[key]
+			}
+
+			redacted, childFindings := s.redactValue(childPath, item, childSensitive)
+			// Plain values under keys such as DB_PASSWORD are secrets even when they look innocuous
+			if str, ok := redacted.(string); ok && len(childFindings) == 0 && !isSensitiveMarker(childSensitive) && isSecretKey(key, str) {
+				redacted = redactedMarker(secretRuleKeywordName)
+				childFindings = []SecretFinding{{Property: childPath, Rule: secretRuleKeywordName}}
+			}
+
+			result[key] = redacted
+			findings = append(findings, childFindings...)
+		}
+		return result, findings
+	case []any:
+		var findings []SecretFinding
+		result := make([]any, len(v))
+		for i, item := range v {
+			redacted, childFindings := s.redactValue(fmt.Sprintf("%s[%d]", path, i), item, extractSensitiveElement(sensitive, i))
+			result[i] = redacted
+			findings = append(findings, childFindings...)
+		}
+		return result, findings
+	default:
+		return value, nil
+	}
+}
+
+// isSecretKey reports whether a map entry's key suggests its string value is a secret
+func isSecretKey(key, value string) bool {
+	if len(value) < 8 || value == knownAfterApply || isSensitivePlaceholder(value) || strings.HasPrefix(value, "(redacted") {
+		return false
+	}
+	return secretKeyPattern.MatchString(key) && !nonSecretKeySuffix.MatchString(key)
+}
+
+// isSensitiveMarker reports whether a sensitive_values entry marks the whole value as sensitive
+func isSensitiveMarker(sensitive any) bool {
+	marked, ok := sensitive.(bool)
+	return ok && marked
+}
+
+// getSecretScanner returns the analyzer's secret scanner, creating it on first use
+func (a *Analyzer) getSecretScanner() *secretScanner {
+	a.secretScannerOnce.Do(func() {
+		a.secretScanner = newSecretScanner(a.config)
+	})
+	return a.secretScanner
+}
+
+// redactPropertySecrets redacts secrets in the before and after values of non-sensitive property changes
+func (a *Analyzer) redactPropertySecrets(analysis *PropertyChangeAnalysis) {
+	scanner := a.getSecretScanner()
+	if scanner == nil {
+		return
+	}
+	for i, change := range analysis.Changes {
+		if change.Sensitive {
+			continue
+		}
+		before, _ := scanner.redactValue(change.Name, change.Before, nil)
+		after, _ := scanner.redactValue(change.Name, change.After, nil)
+
+		// Attributes named like secrets are redacted the same way as map entries with such keys
+		key := change.Name
+		if len(change.Path) > 0 {
+			key = change.Path[len(change.Path)-1]
+		}
+		before = redactSecretKeyValue(key, before)
+		after = redactSecretKeyValue(key, after)
+
+		analysis.Changes[i].Before = before
+		analysis.Changes[i].After = markRedactedChanges(change.Before, change.After, before, after)
+	}
+}
+
+// redactSecretKeyValue redacts a plain string value when its key suggests it holds a secret
+func redactSecretKeyValue(key string, value any) any {
+	if str, ok := value.(string); ok && isSecretKey(key, str) {
+		return redactedMarker(secretRuleKeywordName)
+	}
+	return value
+}
+
+// markRedactedChanges returns the redacted after value with every secret that changed, but redacts
+// to the same text as before, replaced by a marker. Without it the change would look like a no-op.
+func markRedactedChanges(before, after, redactedBefore, redactedAfter any) any {
+	if reflect.DeepEqual(before, after) {
+		return redactedAfter
+	}
+
+	switch redacted := redactedAfter.(type) {
+	case string:
+		if redacted == redactedBefore && strings.Contains(redacted, "(redacted ") {
+			return redactedChange
+		}
+	case map[string]any:
+		beforeMap, _ := before.(map[string]any)
+		afterMap, _ := after.(map[string]any)
+		redactedBeforeMap, _ := redactedBefore.(map[string]any)
+		result := make(map[string]any, len(redacted))
+		for key, value := range redacted {
+			result[key] = markRedactedChanges(beforeMap[key], afterMap[key], redactedBeforeMap[key], value)
+		}
+		return result
+	case []any:
+		beforeList, _ := before.([]any)
+		afterList, _ := after.([]any)
+		redactedBeforeList, _ := redactedBefore.([]any)
+		result := make([]any, len(redacted))
+		for i, value := range redacted {
+			result[i] = markRedactedChanges(listElement(beforeList, i), listElement(afterList, i), listElement(redactedBeforeList, i), value)
+		}
+		return result
+	}
+	return redactedAfter
+}
+
+// listElement returns the element at index i, or nil when the list is shorter
+func listElement(list []any, i int) any {
+	if i < len(list) {
+		return list[i]
+	}
+	return nil
+}
+
+// redactResourceSecrets redacts secrets in a resource's before and after values and
+// records a finding for every property path and rule combination that matched
+func (a *Analyzer) redactResourceSecrets(rc *tfjson.ResourceChange, change *ResourceChange) {
+	scanner := a.getSecretScanner()
+	if scanner == nil || rc.Change == nil {
+		return
+	}
+
+	var beforeFindings, afterFindings []SecretFinding
+	change.Before, beforeFindings = scanner.redactValue("", change.Before, rc.Change.BeforeSensitive)
+	change.After, afterFindings = scanner.redactValue("", change.After, rc.Change.AfterSensitive)
+
+	for _, finding := range append(beforeFindings, afterFindings...) {
+		if !slices.Contains(change.SecretFindings, finding) {
+			change.SecretFindings = append(change.SecretFindings, finding)
+		}
+	}
+}
+
+// redactOutputSecrets redacts secrets in a non-sensitive output change
+func (a *Analyzer) redactOutputSecrets(outputChange *OutputChange) {
+	scanner := a.getSecretScanner()
+	if scanner == nil || outputChange.Sensitive {
+		return
+	}
+	outputChange.Before, _ = scanner.redactValue(outputChange.Name, outputChange.Before, nil)
+	if !outputChange.IsUnknown {
+		outputChange.After, _ = scanner.redactValue(outputChange.Name, outputChange.After, nil)
+	}
+}
+
+// secretFindingProperties returns the distinct property paths with secret findings
+func secretFindingProperties(findings []SecretFinding) []string {
+	var properties []string
+	for _, finding := range findings {
+		if !slices.Contains(properties, finding.Property) {
+			properties = append(properties, finding.Property)
+		}
+	}
+	return properties
+}
@@ -0,0 +1,274 @@
+package plan
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	sensitiveStatusChanged   = "changed"
+	sensitiveStatusUnchanged = "unchanged"
+
+	// sensitiveHashLength is the number of hex characters shown for sensitive value hashes
+	sensitiveHashLength = 8
+)
+
+// sensitiveValueDisplay returns the placeholder for a sensitive value, with its hash when available
+func sensitiveValueDisplay(hash string) string {
+	if hash == "" {
+		return sensitiveValue
+	}
+	return fmt.Sprintf("(sensitive value: %s)", hash)
+}
+
+// sensitiveChangeDisplay returns the placeholder for an updated sensitive value, showing whether it changed
+// e.g., "(sensitive, changed)" or "(sensitive, changed: 1a2b3c4d -> 5e6f7a8b)" when hashes are enabled
+func sensitiveChangeDisplay(status, beforeHash, afterHash string) string {
+	if status == sensitiveStatusUnchanged {
+		if afterHash == "" {
+			return "(sensitive, unchanged)"
+		}
+		return fmt.Sprintf("(sensitive, unchanged: %s)", afterHash)
+	}
+	if beforeHash == "" || afterHash == "" {
+		return "(sensitive, changed)"
+	}
+	return fmt.Sprintf("(sensitive, changed: %s -> %s)", beforeHash, afterHash)
+}
+
+// isSensitivePlaceholder reports whether a value is one of the placeholders that replace sensitive values
+func isSensitivePlaceholder(value any) bool {
+	str, ok := value.(string)
+	return ok && (strings.HasPrefix(str, "(sensitive value") || strings.HasPrefix(str, "(sensitive, "))
+}
+
+// sensitiveStatus compares the real before and after values of a sensitive property.
+// The values themselves never leave the analyzer; only the status and optional hashes do.
+func (a *Analyzer) sensitiveStatus(before, after any) (string, string, string) {
+	status := sensitiveStatusChanged
+	if reflect.DeepEqual(before, after) {
+		status = sensitiveStatusUnchanged
+	}
+	return status, a.sensitiveHash(before), a.sensitiveHash(after)
+}
+
+// sensitiveHash returns a salted short hash of a sensitive value, or an empty string when hashing is disabled.
+// An HMAC keyed with the salt prevents low-entropy values such as passwords from being guessed offline.
+func (a *Analyzer) sensitiveHash(value any) string {
+	if value == nil || a.config == nil || !a.config.Plan.SensitiveValues.ShowHash {
+		return ""
+	}
+	salt := a.config.GetHashSalt()
+	if salt == "" {
+		return ""
+	}
+
+	// encoding/json sorts map keys, so equal values always produce the same hash
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write(encoded)
+	return hex.EncodeToString(mac.Sum(nil))[:sensitiveHashLength]
+}
+
+// sensitiveDigest returns an HMAC keyed with the hash salt of the real sensitive values in the before and
+// after values of a resource change, and whether the change has sensitive values at all. It lets approvals
+// cover the sensitive values themselves instead of only whether they change. Without a salt the digest is
+// empty, as an unkeyed digest of low-entropy values such as passwords can be guessed offline.
+func (a *Analyzer) sensitiveDigest(change *tfjson.Change) (string, bool) {
+	before := sensitiveValues(change.Before, change.BeforeSensitive)
+	after := sensitiveValues(change.After, change.AfterSensitive)
+	if before == nil && after == nil {
+		return "", false
+	}
+	if a.config == nil || a.config.GetHashSalt() == "" {
+		return "", true
+	}
+
+	// encoding/json sorts map keys, so equal values always produce the same digest
+	encoded, err := json.Marshal([]any{before, after})
+	if err != nil {
+		return "", true
+	}
+	mac := hmac.New(sha256.New, []byte(a.config.GetHashSalt()))
+	mac.Write(encoded)
+	return hex.EncodeToString(mac.Sum(nil)), true
+}
+
+// sensitiveValues returns the parts of a value that are marked as sensitive, keeping the structure of
+// maps and lists so the values can't be moved between properties unnoticed. It returns nil when no part
+// of the value is sensitive.
+func sensitiveValues(value, sensitive any) any {
+	if isSensitiveMarker(sensitive) {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		values := map[string]any{}
+		for key, item := range v {
+			if child := sensitiveValues(item, sensitiveChild(sensitive, key)); child != nil {
+				values[key] = child
+			}
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		return values
+	case []any:
+		values := make([]any, len(v))
+		found := false
+		for i, item := range v {
+			values[i] = sensitiveValues(item, extractSensitiveElement(sensitive, i))
+			found = found || values[i] != nil
+		}
+		if !found {
+			return nil
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// maskSensitivePair returns copies of before and after with every sensitive value replaced by a placeholder.
+// After values that differ from their before value are marked as changed, so nested diffs still show the change.
+// The inputs are never modified, as they may be shared with the parsed plan.
+func (a *Analyzer) maskSensitivePair(before, after, beforeSensitive, afterSensitive any) (any, any) {
+	if isSensitiveMarker(beforeSensitive) || isSensitiveMarker(afterSensitive) {
+		var maskedBefore, maskedAfter any
+		if before != nil {
+			maskedBefore = sensitiveValueDisplay(a.sensitiveHash(before))
+		}
+		if after != nil {
+			if before != nil && !reflect.DeepEqual(before, after) {
+				maskedAfter = sensitiveChangeDisplay(a.sensitiveStatus(before, after))
+			} else {
+				maskedAfter = sensitiveValueDisplay(a.sensitiveHash(after))
+			}
+		}
+		return maskedBefore, maskedAfter
+	}
+
+	switch beforeVal := before.(type) {
+	case map[string]any:
+		afterMap, ok := after.(map[string]any)
+		if !ok {
+			return a.maskSensitiveSide(before, beforeSensitive), a.maskSensitiveSide(after, afterSensitive)
+		}
+		maskedBefore := make(map[string]any, len(beforeVal))
+		maskedAfter := make(map[string]any, len(afterMap))
+		for key, item := range beforeVal {
+			afterItem, exists := afterMap[key]
+			maskedBefore[key], maskedAfter[key] = a.maskSensitivePair(item, afterItem,
+				sensitiveChild(beforeSensitive, key), sensitiveChild(afterSensitive, key))
+			if !exists {
+				delete(maskedAfter, key)
+			}
+		}
+		for key, item := range afterMap {
+			if _, exists := beforeVal[key]; !exists {
+				maskedAfter[key] = a.maskSensitiveSide(item, sensitiveChild(afterSensitive, key))
+			}
+		}
+		return maskedBefore, maskedAfter
+	case []any:
+		afterSlice, ok := after.([]any)
+		if !ok {
+			return a.maskSensitiveSide(before, beforeSensitive), a.maskSensitiveSide(after, afterSensitive)
+		}
+		maskedBefore := make([]any, len(beforeVal))
+		maskedAfter := make([]any, len(afterSlice))
+		for i := range max(len(beforeVal), len(afterSlice)) {
+			var beforeItem, afterItem any
+			if i < len(beforeVal) {
+				beforeItem = beforeVal[i]
+			}
+			if i < len(afterSlice) {
+				afterItem = afterSlice[i]
+			}
+			maskedBeforeItem, maskedAfterItem := a.maskSensitivePair(beforeItem, afterItem,
+				extractSensitiveElement(beforeSensitive, i), extractSensitiveElement(afterSensitive, i))
+			if i < len(beforeVal) {
+				maskedBefore[i] = maskedBeforeItem
+			}
+			if i < len(afterSlice) {
+				maskedAfter[i] = maskedAfterItem
+			}
+		}
+		return maskedBefore, maskedAfter
+	}
+
+	// before is a primitive or nil, so only after can contain nested sensitive values
+	return before, a.maskSensitiveSide(after, afterSensitive)
+}
+
+// maskSensitiveSide returns a copy of a single value with every sensitive value replaced by a placeholder
+func (a *Analyzer) maskSensitiveSide(value, sensitive any) any {
+	if value == nil {
+		return nil
+	}
+	if isSensitiveMarker(sensitive) {
+		return sensitiveValueDisplay(a.sensitiveHash(value))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		masked := make(map[string]any, len(v))
+		for key, item := range v {
+			masked[key] = a.maskSensitiveSide(item, sensitiveChild(sensitive, key))
+		}
+		return masked
+	case []any:
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = a.maskSensitiveSide(item, extractSensitiveElement(sensitive, i))
+		}
+		return masked
+	default:
+		return value
+	}
+}
+
+// sensitiveChild returns the sensitive_values entry for a map key, propagating whole-object sensitivity
+func sensitiveChild(sensitive any, key string) any {
+	if isSensitiveMarker(sensitive) {
+		return true
+	}
+	if sensitiveMap, ok := sensitive.(map[string]any); ok {
+		return sensitiveMap[key]
+	}
+	return nil
+}
+
+// extractSensitiveElement returns the sensitive_values entry for a list element, propagating whole-list sensitivity
+func extractSensitiveElement(sensitive any, index int) any {
+	if isSensitiveMarker(sensitive) {
+		return true
+	}
+	if sensitiveSlice, ok := sensitive.([]any); ok && index >= 0 && index < len(sensitiveSlice) {
+		return sensitiveSlice[index]
+	}
+	return nil
+}
+
+// setSensitiveStatus records whether a sensitive property changed, and its hashes when enabled,
+// using the real values before they are discarded
+func (a *Analyzer) setSensitiveStatus(change *PropertyChange, before, after any) {
+	change.BeforeHash = a.sensitiveHash(before)
+	if !change.IsUnknown {
+		change.AfterHash = a.sensitiveHash(after)
+	}
+	if change.Action == actionUpdate && !change.IsUnknown {
+		change.SensitiveStatus, _, _ = a.sensitiveStatus(before, after)
+	}
+}
@@ -0,0 +1,150 @@
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHashSalt = "test-salt-0123456789"
+
+func hashingConfig() *config.Config {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.SensitiveValues = config.SensitiveValuesConfig{ShowHash: true, HashSalt: testHashSalt}
+	return cfg
+}
+
+func TestSensitiveChangeDisplay(t *testing.T) {
+	assert.Equal(t, "(sensitive, changed)", sensitiveChangeDisplay(sensitiveStatusChanged, "", ""))
+	assert.Equal(t, "(sensitive, unchanged)", sensitiveChangeDisplay(sensitiveStatusUnchanged, "", ""))
+	assert.Equal(t, "(sensitive, changed: aaaa1111 -> bbbb2222)", sensitiveChangeDisplay(sensitiveStatusChanged, "aaaa1111", "bbbb2222"))
+	assert.Equal(t, "(sensitive, unchanged: aaaa1111)", sensitiveChangeDisplay(sensitiveStatusUnchanged, "aaaa1111", "aaaa1111"))
+	assert.Equal(t, sensitiveValue, sensitiveValueDisplay(""))
+	assert.Equal(t, "(sensitive value: aaaa1111)", sensitiveValueDisplay("aaaa1111"))
+}
+
+func TestAnalyzer_SensitiveHash(t *testing.T) {
+	t.Setenv("STRATA_HASH_SALT", "")
+
+	t.Run("disabled by default", func(t *testing.T) {
+		analyzer := NewAnalyzer(&tfjson.Plan{}, config.GetDefaultConfig())
+		assert.Empty(t, analyzer.sensitiveHash("hunter2"))
+	})
+
+	t.Run("stable for equal values", func(t *testing.T) {
+		analyzer := NewAnalyzer(&tfjson.Plan{}, hashingConfig())
+		hash := analyzer.sensitiveHash("hunter2")
+		assert.Len(t, hash, sensitiveHashLength)
+		assert.Equal(t, hash, analyzer.sensitiveHash("hunter2"))
+		assert.NotEqual(t, hash, analyzer.sensitiveHash("hunter3"))
+		assert.Equal(t,
+			analyzer.sensitiveHash(map[string]any{"a": "1", "b": "2"}),
+			analyzer.sensitiveHash(map[string]any{"b": "2", "a": "1"}))
+	})
+
+	t.Run("depends on the salt", func(t *testing.T) {
+		otherCfg := hashingConfig()
+		otherCfg.Plan.SensitiveValues.HashSalt = "another-salt-0123456789"
+		assert.NotEqual(t,
+			NewAnalyzer(&tfjson.Plan{}, hashingConfig()).sensitiveHash("hunter2"),
+			NewAnalyzer(&tfjson.Plan{}, otherCfg).sensitiveHash("hunter2"))
+	})
+}
+
+func TestAnalyzePropertyChanges_SensitiveStatus(t *testing.T) {
+	t.Setenv("STRATA_HASH_SALT", "")
+	analyzer := NewAnalyzer(&tfjson.Plan{}, hashingConfig())
+
+	analysis := analyzer.AnalyzePropertyChanges(&tfjson.ResourceChange{
+		Change: &tfjson.Change{
+			Actions:         []tfjson.Action{tfjson.ActionUpdate},
+			Before:          map[string]any{"password": "old-password", "api_key": "same-key"},
+			After:           map[string]any{"password": "new-password", "api_key": "same-key", "token": "added-token"},
+			BeforeSensitive: map[string]any{"password": true, "api_key": true},
+			AfterSensitive:  map[string]any{"password": true, "api_key": true, "token": true},
+		},
+	})
+
+	changes := map[string]PropertyChange{}
+	for _, change := range analysis.Changes {
+		changes[change.Name] = change
+	}
+	require.Len(t, changes, 2, "unchanged sensitive values are not reported")
+
+	password := changes["password"]
+	assert.Equal(t, sensitiveStatusChanged, password.SensitiveStatus)
+	assert.Equal(t, analyzer.sensitiveHash("old-password"), password.BeforeHash)
+	assert.Equal(t, analyzer.sensitiveHash("new-password"), password.AfterHash)
+
+	token := changes["token"]
+	assert.Empty(t, token.SensitiveStatus)
+	assert.Empty(t, token.BeforeHash)
+	assert.Equal(t, analyzer.sensitiveHash("added-token"), token.AfterHash)
+
+	formatter := NewFormatter(hashingConfig())
+	assert.Equal(t, indent+"~ password = (sensitive, changed: "+password.BeforeHash+" -> "+password.AfterHash+")",
+		formatter.formatPropertyChange(password))
+	assert.Equal(t, indent+"+ token = (sensitive value: "+token.AfterHash+")", formatter.formatPropertyChange(token))
+}
+
+func TestAnalyzer_SensitivePlaintextNeverReachesSummary(t *testing.T) {
+	plan := &tfjson.Plan{
+		ResourceChanges: []*tfjson.ResourceChange{{
+			Address: "aws_ecs_task_definition.app",
+			Type:    "aws_ecs_task_definition",
+			Name:    "app",
+			Change: &tfjson.Change{
+				Actions: []tfjson.Action{tfjson.ActionUpdate},
+				Before: map[string]any{
+					// A sensitive object that is compared property by property
+					"credentials": map[string]any{"inner": map[string]any{"value": "plaintext-before"}},
+					// A grouped object with a single sensitive entry
+					"environment": map[string]any{"LOG_LEVEL": "info", "DB_PASSWORD": "plaintext-old"},
+					"secrets":     []any{"plaintext-list-before"},
+				},
+				After: map[string]any{
+					"credentials": map[string]any{"inner": map[string]any{"value": "plaintext-after"}},
+					"environment": map[string]any{"LOG_LEVEL": "debug", "DB_PASSWORD": "plaintext-new"},
+					"secrets":     []any{"plaintext-list-after"},
+				},
+				BeforeSensitive: map[string]any{"credentials": true, "environment": map[string]any{"DB_PASSWORD": true}, "secrets": true},
+				AfterSensitive:  map[string]any{"credentials": true, "environment": map[string]any{"DB_PASSWORD": true}, "secrets": true},
+			},
+		}},
+		OutputChanges: map[string]*tfjson.Change{
+			"db_password": {
+				Actions:         []tfjson.Action{tfjson.ActionUpdate},
+				Before:          "plaintext-output",
+				After:           "plaintext-output",
+				BeforeSensitive: true,
+				AfterSensitive:  true,
+			},
+		},
+	}
+
+	summary := NewAnalyzer(plan, config.GetDefaultConfig()).GenerateSummary("")
+	encoded, err := json.Marshal(summary)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encoded), "plaintext")
+
+	require.Len(t, summary.ResourceChanges, 1)
+	formatter := NewFormatter(config.GetDefaultConfig())
+	for _, change := range summary.ResourceChanges[0].PropertyChanges.Changes {
+		formatted := formatter.formatPropertyChange(change)
+		assert.NotContains(t, formatted, "plaintext")
+		if change.Name == "environment" {
+			assert.Contains(t, formatted, "DB_PASSWORD = (sensitive, changed)")
+			assert.Contains(t, formatted, `LOG_LEVEL = "info" -> "debug"`)
+		}
+	}
+
+	require.Len(t, summary.OutputChanges, 1)
+	assert.Equal(t, sensitiveStatusUnchanged, summary.OutputChanges[0].SensitiveStatus)
+	outputs, err := formatter.createOutputChangesData(summary)
+	require.NoError(t, err)
+	assert.Equal(t, "(sensitive, unchanged)", outputs[0]["Planned"])
+}
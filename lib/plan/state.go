@@ -0,0 +1,315 @@
+package plan
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+const (
+	// rawStateVersion is the only raw state file format version that can be read directly
+	rawStateVersion = 4
+
+	// rootModuleLabel is shown for resources in the root module when counting by module
+	rootModuleLabel = "(root)"
+)
+
+// rawStateProviderPattern extracts the provider source from a raw state provider reference
+// e.g., provider["registry.terraform.io/hashicorp/aws"].west
+var rawStateProviderPattern = regexp.MustCompile(`provider\["([^"]+)"\]`)
+
+// StateParser handles Terraform state file parsing
+type StateParser struct {
+	stateFile string
+}
+
+// NewStateParser creates a new state parser instance
+func NewStateParser(stateFile string) *StateParser {
+	return &StateParser{
+		stateFile: stateFile,
+	}
+}
+
+// LoadState loads a state file. Both the output of `terraform show -json` and
+// raw terraform.tfstate files are supported.
+func (p *StateParser) LoadState() (*tfjson.State, error) {
+	data, err := os.ReadFile(p.stateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("state file does not exist: %s", p.stateFile)
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	return ParseState(data)
+}
+
+// ParseState parses state JSON in either the `terraform show -json` format or the raw terraform.tfstate format
+func ParseState(data []byte) (*tfjson.State, error) {
+	var probe struct {
+		FormatVersion string `json:"format_version"`
+		Version       int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse state JSON: %w", err)
+	}
+
+	switch {
+	case probe.FormatVersion != "":
+		var state tfjson.State
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse state JSON: %w", err)
+		}
+		return &state, nil
+	case probe.Version != 0:
+		return convertRawState(data)
+	default:
+		return nil, fmt.Errorf("unrecognised state format: expected terraform show -json output or a terraform.tfstate file")
+	}
+}
+
+// rawState is the subset of the terraform.tfstate format needed for summaries
+type rawState struct {
+	Version          int                       `json:"version"`
+	TerraformVersion string                    `json:"terraform_version"`
+	Outputs          map[string]rawStateOutput `json:"outputs"`
+	Resources        []rawStateResource        `json:"resources"`
+}
+
+type rawStateOutput struct {
+	Value     any  `json:"value"`
+	Sensitive bool `json:"sensitive"`
+}
+
+type rawStateResource struct {
+	Module    string             `json:"module"`
+	Mode      string             `json:"mode"`
+	Type      string             `json:"type"`
+	Name      string             `json:"name"`
+	Provider  string             `json:"provider"`
+	Instances []rawStateInstance `json:"instances"`
+}
+
+type rawStateInstance struct {
+	IndexKey      any            `json:"index_key"`
+	SchemaVersion uint64         `json:"schema_version"`
+	Status        string         `json:"status"`
+	Deposed       string         `json:"deposed"`
+	Attributes    map[string]any `json:"attributes"`
+}
+
+// convertRawState converts a raw terraform.tfstate file into the structure produced by `terraform show -json`,
+// so it can be summarised without running Terraform
+func convertRawState(data []byte) (*tfjson.State, error) {
+	var raw rawState
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if raw.Version != rawStateVersion {
+		return nil, fmt.Errorf("unsupported state file version %d (only version %d is supported)", raw.Version, rawStateVersion)
+	}
+
+	root := &tfjson.StateModule{}
+	modules := map[string]*tfjson.StateModule{"": root}
+	var moduleFor func(address string) *tfjson.StateModule
+	moduleFor = func(address string) *tfjson.StateModule {
+		if module, ok := modules[address]; ok {
+			return module
+		}
+		parent := root
+		if idx := strings.LastIndex(address, ".module."); idx != -1 {
+			parent = moduleFor(address[:idx])
+		}
+		module := &tfjson.StateModule{Address: address}
+		parent.ChildModules = append(parent.ChildModules, module)
+		modules[address] = module
+		return module
+	}
+
+	for _, resource := range raw.Resources {
+		module := moduleFor(resource.Module)
+		providerName := resource.Provider
+		if match := rawStateProviderPattern.FindStringSubmatch(resource.Provider); match != nil {
+			providerName = match[1]
+		}
+
+		for _, instance := range resource.Instances {
+			module.Resources = append(module.Resources, &tfjson.StateResource{
+				Address:         rawStateResourceAddress(resource, instance.IndexKey),
+				Mode:            tfjson.ResourceMode(resource.Mode),
+				Type:            resource.Type,
+				Name:            resource.Name,
+				Index:           instance.IndexKey,
+				ProviderName:    providerName,
+				SchemaVersion:   instance.SchemaVersion,
+				AttributeValues: instance.Attributes,
+				Tainted:         instance.Status == "tainted",
+				DeposedKey:      instance.Deposed,
+			})
+		}
+	}
+
+	outputs := make(map[string]*tfjson.StateOutput, len(raw.Outputs))
+	for name, output := range raw.Outputs {
+		outputs[name] = &tfjson.StateOutput{Value: output.Value, Sensitive: output.Sensitive}
+	}
+
+	return &tfjson.State{
+		FormatVersion:    "1.0",
+		TerraformVersion: raw.TerraformVersion,
+		Values: &tfjson.StateValues{
+			Outputs:    outputs,
+			RootModule: root,
+		},
+	}, nil
+}
+
+// rawStateResourceAddress builds the full address of a resource instance from a raw state entry
+func rawStateResourceAddress(resource rawStateResource, indexKey any) string {
+	var builder strings.Builder
+	if resource.Module != "" {
+		builder.WriteString(resource.Module + ".")
+	}
+	if resource.Mode == string(tfjson.DataResourceMode) {
+		builder.WriteString("data.")
+	}
+	builder.WriteString(resource.Type + "." + resource.Name)
+
+	switch key := indexKey.(type) {
+	case float64:
+		fmt.Fprintf(&builder, "[%d]", int(key))
+	case string:
+		fmt.Fprintf(&builder, "[%q]", key)
+	}
+	return builder.String()
+}
+
+// AnalyzeState creates an inventory summary of the resources in a state
+func (a *Analyzer) AnalyzeState(state *tfjson.State, stateFile string) *StateSummary {
+	summary := &StateSummary{
+		StateFile:  stateFile,
+		Resources:  []StateResourceSummary{},
+		ByProvider: []StateResourceCount{},
+		ByType:     []StateResourceCount{},
+		ByModule:   []StateResourceCount{},
+	}
+	if state == nil {
+		return summary
+	}
+	summary.FormatVersion = state.FormatVersion
+	summary.TerraformVersion = state.TerraformVersion
+	if state.Values == nil {
+		return summary
+	}
+	summary.Statistics.Outputs = len(state.Values.Outputs)
+
+	var collect func(module *tfjson.StateModule)
+	collect = func(module *tfjson.StateModule) {
+		if module == nil {
+			return
+		}
+		for _, resource := range module.Resources {
+			// Deposed objects are pending destruction and aren't part of the inventory
+			if resource == nil || resource.DeposedKey != "" {
+				continue
+			}
+			if resource.Mode == tfjson.DataResourceMode {
+				summary.Statistics.DataSources++
+				continue
+			}
+			summary.Resources = append(summary.Resources, StateResourceSummary{
+				Address:     resource.Address,
+				Type:        resource.Type,
+				Name:        resource.Name,
+				Provider:    a.stateResourceProvider(resource),
+				ModulePath:  a.extractModulePath(resource.Address),
+				IsSensitive: a.IsSensitiveResource(resource.Type),
+				IsTainted:   resource.Tainted,
+			})
+		}
+		for _, child := range module.ChildModules {
+			collect(child)
+		}
+	}
+	collect(state.Values.RootModule)
+
+	slices.SortFunc(summary.Resources, func(x, y StateResourceSummary) int {
+		return cmp.Compare(x.Address, y.Address)
+	})
+
+	byProvider := map[string]*StateResourceCount{}
+	byType := map[string]*StateResourceCount{}
+	byModule := map[string]*StateResourceCount{}
+	for _, resource := range summary.Resources {
+		module := resource.ModulePath
+		if module == "-" {
+			module = rootModuleLabel
+		}
+		for _, group := range []struct {
+			counts map[string]*StateResourceCount
+			name   string
+		}{{byProvider, resource.Provider}, {byType, resource.Type}, {byModule, module}} {
+			count, ok := group.counts[group.name]
+			if !ok {
+				count = &StateResourceCount{Name: group.name}
+				group.counts[group.name] = count
+			}
+			count.Count++
+			if resource.IsSensitive {
+				count.Sensitive++
+			}
+		}
+
+		summary.Statistics.Resources++
+		if resource.IsSensitive {
+			summary.Statistics.Sensitive++
+		}
+		if resource.IsTainted {
+			summary.Statistics.Tainted++
+		}
+	}
+
+	summary.ByProvider = sortedStateCounts(byProvider)
+	summary.ByType = sortedStateCounts(byType)
+	summary.ByModule = sortedStateCounts(byModule)
+	summary.Statistics.Providers = len(summary.ByProvider)
+	summary.Statistics.Modules = len(summary.ByModule)
+
+	return summary
+}
+
+// stateResourceProvider returns the short provider name for a state resource,
+// preferring the provider source recorded in the state over the resource type prefix
+func (a *Analyzer) stateResourceProvider(resource *tfjson.StateResource) string {
+	if resource.ProviderName != "" {
+		source := resource.ProviderName
+		if idx := strings.LastIndex(source, "/"); idx != -1 {
+			source = source[idx+1:]
+		}
+		if source != "" {
+			return source
+		}
+	}
+	return a.extractProvider(resource.Type)
+}
+
+// sortedStateCounts returns the counts ordered by count (largest first) and then name
+func sortedStateCounts(counts map[string]*StateResourceCount) []StateResourceCount {
+	result := make([]StateResourceCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	slices.SortFunc(result, func(x, y StateResourceCount) int {
+		if x.Count != y.Count {
+			return cmp.Compare(y.Count, x.Count)
+		}
+		return cmp.Compare(x.Name, y.Name)
+	})
+	return result
+}
@@ -0,0 +1,148 @@
+package plan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStateFile = "testdata/state/terraform.tfstate"
+
+func TestStateParser_LoadRawState(t *testing.T) {
+	state, err := NewStateParser(testStateFile).LoadState()
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.9.5", state.TerraformVersion)
+	require.NotNil(t, state.Values)
+	assert.Len(t, state.Values.Outputs, 2)
+	assert.True(t, state.Values.Outputs["db_password"].Sensitive)
+
+	root := state.Values.RootModule
+	addresses := []string{}
+	for _, resource := range root.Resources {
+		addresses = append(addresses, resource.Address)
+	}
+	assert.Equal(t, []string{
+		"data.aws_caller_identity.current",
+		"aws_s3_bucket.assets",
+		"aws_instance.web[0]",
+		"aws_instance.web[1]",
+		"aws_instance.web[1]",
+	}, addresses)
+	assert.Equal(t, "registry.terraform.io/hashicorp/aws", root.Resources[2].ProviderName)
+	assert.True(t, root.Resources[3].Tainted)
+	assert.Equal(t, "00000001", root.Resources[4].DeposedKey)
+
+	require.Len(t, root.ChildModules, 1)
+	database := root.ChildModules[0]
+	assert.Equal(t, "module.database", database.Address)
+	require.Len(t, database.ChildModules, 1)
+	secrets := database.ChildModules[0]
+	assert.Equal(t, `module.database.module.secrets["primary"]`, secrets.Address)
+	assert.Equal(t, `module.database.module.secrets["primary"].random_password.master`, secrets.Resources[0].Address)
+}
+
+func TestParseState_Formats(t *testing.T) {
+	t.Run("terraform show -json output", func(t *testing.T) {
+		converted, err := NewStateParser(testStateFile).LoadState()
+		require.NoError(t, err)
+		data, err := json.Marshal(converted)
+		require.NoError(t, err)
+
+		state, err := ParseState(data)
+		require.NoError(t, err)
+		assert.Equal(t, converted.Values.RootModule.Resources[1].Address, state.Values.RootModule.Resources[1].Address)
+	})
+
+	t.Run("unsupported raw state version", func(t *testing.T) {
+		_, err := ParseState([]byte(`{"version": 3, "resources": []}`))
+		assert.ErrorContains(t, err, "unsupported state file version 3")
+	})
+
+	t.Run("unrecognised document", func(t *testing.T) {
+		_, err := ParseState([]byte(`{"planned_values": {}}`))
+		assert.ErrorContains(t, err, "unrecognised state format")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := NewStateParser(filepath.Join(t.TempDir(), "missing.tfstate")).LoadState()
+		assert.ErrorContains(t, err, "state file does not exist")
+	})
+}
+
+func TestAnalyzer_AnalyzeState(t *testing.T) {
+	state, err := NewStateParser(testStateFile).LoadState()
+	require.NoError(t, err)
+
+	cfg := config.GetDefaultConfig()
+	cfg.SensitiveResources = []config.SensitiveResource{{ResourceType: "aws_db_instance"}}
+	summary := NewAnalyzer(nil, cfg).AnalyzeState(state, testStateFile)
+
+	assert.Equal(t, StateStatistics{
+		Resources:   5,
+		DataSources: 1,
+		Providers:   2,
+		Modules:     3,
+		Sensitive:   1,
+		Tainted:     1,
+		Outputs:     2,
+	}, summary.Statistics)
+
+	assert.Equal(t, []StateResourceCount{
+		{Name: "aws", Count: 4, Sensitive: 1},
+		{Name: "random", Count: 1},
+	}, summary.ByProvider)
+	assert.Equal(t, []StateResourceCount{
+		{Name: rootModuleLabel, Count: 3},
+		{Name: "database", Count: 1, Sensitive: 1},
+		{Name: "database/secrets", Count: 1},
+	}, summary.ByModule)
+	assert.Equal(t, "aws_instance", summary.ByType[0].Name)
+	assert.Equal(t, 2, summary.ByType[0].Count)
+
+	require.Len(t, summary.Resources, 5)
+	assert.Equal(t, "aws_instance.web[0]", summary.Resources[0].Address)
+	assert.Equal(t, StateResourceSummary{
+		Address:     "module.database.aws_db_instance.main",
+		Type:        "aws_db_instance",
+		Name:        "main",
+		Provider:    "aws",
+		ModulePath:  "database",
+		IsSensitive: true,
+	}, summary.Resources[3])
+}
+
+func TestAnalyzer_AnalyzeEmptyState(t *testing.T) {
+	summary := NewAnalyzer(nil, config.GetDefaultConfig()).AnalyzeState(&tfjson.State{FormatVersion: "1.0"}, "empty.json")
+	assert.Empty(t, summary.Resources)
+	assert.Equal(t, StateStatistics{}, summary.Statistics)
+}
+
+func TestFormatter_OutputStateSummaryToFile(t *testing.T) {
+	state, err := NewStateParser(testStateFile).LoadState()
+	require.NoError(t, err)
+	cfg := config.GetDefaultConfig()
+	cfg.SensitiveResources = []config.SensitiveResource{{ResourceType: "aws_db_instance"}}
+	summary := NewAnalyzer(nil, cfg).AnalyzeState(state, testStateFile)
+
+	outputFile := filepath.Join(t.TempDir(), "inventory.md")
+	err = NewFormatter(cfg).OutputStateSummary(summary, &config.OutputConfiguration{
+		Format:           "json",
+		OutputFile:       outputFile,
+		OutputFileFormat: formatMarkdown,
+	}, false)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Resources by Module")
+	assert.Contains(t, string(content), "Sensitive Resources")
+	assert.Contains(t, string(content), "aws\\_db\\_instance.main")
+	assert.NotContains(t, string(content), "aws\\_s3\\_bucket.assets", "only sensitive resources are listed without details")
+}
@@ -0,0 +1,224 @@
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Message types in the `terraform plan -json` stream. Planned changes and drift are reported as events.
+const (
+	PlanStreamPlannedChange = "planned_change"
+	PlanStreamResourceDrift = "resource_drift"
+	planStreamChangeSummary = "change_summary"
+	planStreamOutputs       = "outputs"
+	planStreamDiagnostic    = "diagnostic"
+	planStreamVersion       = "version"
+)
+
+// planStreamActions maps the actions in the plan stream to the actions used in plan files
+var planStreamActions = map[string]tfjson.Actions{
+	"create":  {tfjson.ActionCreate},
+	"read":    {tfjson.ActionRead},
+	"update":  {tfjson.ActionUpdate},
+	"replace": {tfjson.ActionDelete, tfjson.ActionCreate},
+	"delete":  {tfjson.ActionDelete},
+	"remove":  {tfjson.ActionForget},
+	"noop":    {tfjson.ActionNoop},
+	"move":    {tfjson.ActionNoop},
+	"import":  {tfjson.ActionNoop},
+}
+
+// planStreamReplaceReasons describes why a resource is replaced. The stream doesn't include
+// the attributes that force replacement, so these are used as the replacement hints instead.
+var planStreamReplaceReasons = map[string]string{
+	"tainted":       "resource is tainted",
+	"requested":     "replacement was requested",
+	"cannot_update": "cannot be updated in place",
+}
+
+// planStreamMessage is the subset of a machine-readable UI message needed for live plan summaries
+type planStreamMessage struct {
+	Type       string                      `json:"type"`
+	Timestamp  string                      `json:"@timestamp"`
+	Terraform  string                      `json:"terraform"`
+	Change     *planStreamChange           `json:"change"`
+	Outputs    map[string]planStreamOutput `json:"outputs"`
+	Diagnostic *applyLogDiagnostic         `json:"diagnostic"`
+}
+
+type planStreamChange struct {
+	Resource struct {
+		Addr         string `json:"addr"`
+		ResourceType string `json:"resource_type"`
+		ResourceName string `json:"resource_name"`
+	} `json:"resource"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+type planStreamOutput struct {
+	Sensitive bool   `json:"sensitive"`
+	Action    string `json:"action"`
+}
+
+// PlanStreamEvent describes a change read from the plan stream, for progress reporting
+type PlanStreamEvent struct {
+	Type       string     // PlanStreamPlannedChange or PlanStreamResourceDrift
+	Address    string     // Address of the resource
+	ChangeType ChangeType // Planned change, or the change made outside of Terraform for drift
+}
+
+// PlanStream builds a plan summary incrementally from the output of `terraform plan -json`
+type PlanStream struct {
+	analyzer    *Analyzer
+	summary     *PlanSummary
+	outputs     map[string]*tfjson.Change
+	drift       []PlanStreamEvent
+	errors      []string
+	completed   bool
+	messageSeen bool
+}
+
+// NewPlanStream creates a plan stream that analyzes changes as they are read
+func NewPlanStream(analyzer *Analyzer, planFile string) *PlanStream {
+	parser := NewParser(planFile)
+	return &PlanStream{
+		analyzer: analyzer,
+		summary: &PlanSummary{
+			SchemaVersion:   SummarySchemaVersion,
+			PlanFile:        planFile,
+			Workspace:       parser.extractWorkspaceInfo(nil),
+			Backend:         parser.extractBackendInfo(nil),
+			CreatedAt:       time.Now(),
+			ResourceChanges: []ResourceChange{},
+			OutputChanges:   []OutputChange{},
+		},
+		outputs: map[string]*tfjson.Change{},
+	}
+}
+
+// Read processes the plan stream until it ends, calling progress for every resource change.
+// Lines that aren't JSON, such as output from wrapper scripts, are skipped.
+func (s *PlanStream) Read(r io.Reader, progress func(PlanStreamEvent)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxApplyLogLineSize)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		event, err := s.Process(line)
+		if err != nil {
+			return fmt.Errorf("failed to parse plan output line %d: %w", lineNumber, err)
+		}
+		if event != nil && progress != nil {
+			progress(*event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read plan output: %w", err)
+	}
+
+	switch {
+	case !s.messageSeen:
+		return fmt.Errorf("no machine-readable messages found: expected the output of terraform plan -json")
+	case len(s.errors) > 0:
+		return fmt.Errorf("terraform plan failed: %s", strings.Join(s.errors, "; "))
+	case !s.completed:
+		return fmt.Errorf("plan output ended without a change summary, the plan may have been interrupted")
+	}
+	return nil
+}
+
+// Process handles a single message from the plan stream. It returns an event when the message
+// describes a resource change, and nil for other messages.
+func (s *PlanStream) Process(line []byte) (*PlanStreamEvent, error) {
+	var message planStreamMessage
+	if err := json.Unmarshal(line, &message); err != nil {
+		return nil, err
+	}
+	s.messageSeen = true
+
+	switch message.Type {
+	case planStreamVersion:
+		s.summary.TerraformVersion = message.Terraform
+	case PlanStreamPlannedChange:
+		if message.Change == nil {
+			return nil, nil
+		}
+		change := s.analyzer.analyzeResourceChange(message.Change.resourceChange())
+		if len(change.ReplacementHints) == 0 && change.ChangeType == ChangeTypeReplace {
+			if hint, ok := planStreamReplaceReasons[message.Change.Reason]; ok {
+				change.ReplacementHints = []string{hint}
+			}
+		}
+		s.summary.ResourceChanges = append(s.summary.ResourceChanges, change)
+		return &PlanStreamEvent{Type: PlanStreamPlannedChange, Address: change.Address, ChangeType: change.ChangeType}, nil
+	case PlanStreamResourceDrift:
+		if message.Change == nil {
+			return nil, nil
+		}
+		event := PlanStreamEvent{
+			Type:       PlanStreamResourceDrift,
+			Address:    message.Change.Resource.Addr,
+			ChangeType: FromTerraformAction(planStreamActions[message.Change.Action]),
+		}
+		s.drift = append(s.drift, event)
+		return &event, nil
+	case planStreamOutputs:
+		for name, output := range message.Outputs {
+			// Output values aren't part of the stream, so only the action and sensitivity are known
+			s.outputs[name] = &tfjson.Change{
+				Actions:         planStreamActions[output.Action],
+				AfterUnknown:    output.Action != "delete" && output.Action != "noop",
+				BeforeSensitive: output.Sensitive,
+				AfterSensitive:  output.Sensitive,
+			}
+		}
+	case planStreamChangeSummary:
+		s.completed = true
+		if timestamp, err := time.Parse(time.RFC3339Nano, message.Timestamp); err == nil {
+			s.summary.CreatedAt = timestamp
+		}
+	case planStreamDiagnostic:
+		if message.Diagnostic != nil && message.Diagnostic.Severity == "error" {
+			s.errors = append(s.errors, message.Diagnostic.Summary)
+		}
+	}
+	return nil, nil
+}
+
+// resourceChange converts a planned change from the stream into a resource change without values
+func (c *planStreamChange) resourceChange() *tfjson.ResourceChange {
+	return &tfjson.ResourceChange{
+		Address: c.Resource.Addr,
+		Type:    c.Resource.ResourceType,
+		Name:    c.Resource.ResourceName,
+		Change: &tfjson.Change{
+			Actions: planStreamActions[c.Action],
+		},
+	}
+}
+
+// Drift returns the resources that were changed outside of Terraform
+func (s *PlanStream) Drift() []PlanStreamEvent {
+	return s.drift
+}
+
+// Summary returns the plan summary for the changes read so far
+func (s *PlanStream) Summary() *PlanSummary {
+	summary := *s.summary
+	summary.ResourceChanges = slices.Clone(s.summary.ResourceChanges)
+	summary.OutputChanges, _ = s.analyzer.ProcessOutputChanges(&tfjson.Plan{OutputChanges: s.outputs})
+	summary.Statistics = s.analyzer.calculateStatistics(summary.ResourceChanges, summary.OutputChanges)
+	return &summary
+}
@@ -0,0 +1,128 @@
+package plan
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPlanStreamFile = "testdata/stream/plan.jsonl"
+
+func readTestPlanStream(t *testing.T, cfg *config.Config) (*PlanStream, []PlanStreamEvent) {
+	t.Helper()
+	file, err := os.Open(testPlanStreamFile)
+	require.NoError(t, err)
+	defer file.Close()
+
+	stream := NewPlanStream(NewAnalyzer(nil, cfg), "(stdin)")
+	var events []PlanStreamEvent
+	require.NoError(t, stream.Read(file, func(event PlanStreamEvent) {
+		events = append(events, event)
+	}))
+	return stream, events
+}
+
+func TestPlanStream_Read(t *testing.T) {
+	stream, events := readTestPlanStream(t, config.GetDefaultConfig())
+
+	assert.Equal(t, []PlanStreamEvent{
+		{Type: PlanStreamResourceDrift, Address: "aws_security_group.web_sg", ChangeType: ChangeTypeUpdate},
+		{Type: PlanStreamPlannedChange, Address: "aws_db_instance.main", ChangeType: ChangeTypeReplace},
+		{Type: PlanStreamPlannedChange, Address: "aws_iam_instance_profile.app_profile", ChangeType: ChangeTypeCreate},
+		{Type: PlanStreamPlannedChange, Address: "aws_instance.web_server", ChangeType: ChangeTypeUpdate},
+		{Type: PlanStreamPlannedChange, Address: "aws_s3_bucket.old_logs", ChangeType: ChangeTypeDelete},
+	}, events)
+	assert.Len(t, stream.Drift(), 1)
+
+	summary := stream.Summary()
+	assert.Equal(t, "1.9.5", summary.TerraformVersion)
+	assert.Equal(t, "(stdin)", summary.PlanFile)
+	assert.Equal(t, "2025-06-01T10:00:05Z", summary.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"))
+	assert.Equal(t, 1, summary.Statistics.ToAdd)
+	assert.Equal(t, 1, summary.Statistics.ToChange)
+	assert.Equal(t, 1, summary.Statistics.ToDestroy)
+	assert.Equal(t, 1, summary.Statistics.Replacements)
+	assert.Equal(t, 2, summary.Statistics.OutputChanges)
+
+	require.Len(t, summary.ResourceChanges, 4)
+	database := summary.ResourceChanges[0]
+	assert.Equal(t, "aws_db_instance", database.Type)
+	assert.Equal(t, "main", database.Name)
+	assert.Equal(t, "aws", database.Provider)
+	assert.Equal(t, ReplacementAlways, database.ReplacementType)
+	assert.Equal(t, []string{"cannot be updated in place"}, database.ReplacementHints)
+
+	deletion := summary.ResourceChanges[3]
+	assert.True(t, deletion.IsDangerous)
+	assert.Equal(t, "Resource deletion", deletion.DangerReason)
+
+	outputs := map[string]OutputChange{}
+	for _, output := range summary.OutputChanges {
+		outputs[output.Name] = output
+	}
+	assert.True(t, outputs["db_password"].Sensitive)
+	assert.True(t, outputs["db_endpoint"].IsUnknown)
+}
+
+func TestPlanStream_SensitiveResources(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.SensitiveResources = []config.SensitiveResource{{ResourceType: "aws_db_instance"}}
+	stream, _ := readTestPlanStream(t, cfg)
+
+	database := stream.Summary().ResourceChanges[0]
+	assert.True(t, database.IsDangerous)
+	assert.Equal(t, "Database replacement", database.DangerReason)
+}
+
+func TestPlanStream_SummaryWhileReading(t *testing.T) {
+	stream := NewPlanStream(NewAnalyzer(nil, config.GetDefaultConfig()), "(stdin)")
+	_, err := stream.Process([]byte(`{"type":"planned_change","change":{"resource":{"addr":"aws_s3_bucket.logs","resource_type":"aws_s3_bucket","resource_name":"logs"},"action":"create"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, stream.Summary().Statistics.ToAdd)
+
+	_, err = stream.Process([]byte(`{"type":"planned_change","change":{"resource":{"addr":"aws_s3_bucket.data","resource_type":"aws_s3_bucket","resource_name":"data"},"action":"create"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 2, stream.Summary().Statistics.ToAdd)
+}
+
+func TestPlanStream_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "no messages",
+			input:   "Planning failed.\n",
+			wantErr: "no machine-readable messages found",
+		},
+		{
+			name: "error diagnostics",
+			input: `{"type":"version","terraform":"1.9.5"}
+{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Reference to undeclared resource"}}`,
+			wantErr: "terraform plan failed: Reference to undeclared resource",
+		},
+		{
+			name:    "interrupted plan",
+			input:   `{"type":"planned_change","change":{"resource":{"addr":"aws_s3_bucket.logs"},"action":"create"}}`,
+			wantErr: "plan output ended without a change summary",
+		},
+		{
+			name:    "malformed JSON",
+			input:   "{\"type\":",
+			wantErr: "failed to parse plan output line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := NewPlanStream(NewAnalyzer(nil, config.GetDefaultConfig()), "(stdin)")
+			err := stream.Read(strings.NewReader(tt.input), nil)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package plan
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadSummary reads a plan summary in JSON format, as written by --save-summary or the Go library, so it
+// can be rendered again without the plan. Fields that aren't written by every version of Strata but that
+// the formatter relies on, such as the no-op flags used for filtering and the providers used for grouping,
+// are restored from the rest of the summary. Summaries of a different schema version are rejected.
+func LoadSummary(r io.Reader) (*PlanSummary, error) {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	// Unknown fields mean the file isn't a summary, for example when a plan is passed instead
+	decoder.DisallowUnknownFields()
+	var summary PlanSummary
+	if err := decoder.Decode(&summary); err != nil {
+		return nil, fmt.Errorf("failed to parse summary JSON: %w", err)
+	}
+	// Summaries saved before the format was versioned have no schema version, and are read as version 1
+	if summary.SchemaVersion != "" && summary.SchemaVersion != SummarySchemaVersion {
+		return nil, fmt.Errorf("unsupported summary schema version %q, expected %q", summary.SchemaVersion, SummarySchemaVersion)
+	}
+	summary.SchemaVersion = SummarySchemaVersion
+	restoreSummaryFields(&summary)
+	return &summary, nil
+}
+
+// RenderJSON writes a plan summary as the versioned JSON document described by SummarySchema, which is
+// the output of the json format. No-op resources and the resource filter are applied to the resource
+// changes in the same way as for the other output formats, while the statistics cover the full plan.
+func (f *Formatter) RenderJSON(w io.Writer, summary *PlanSummary) error {
+	if summary == nil {
+		return fmt.Errorf("plan summary cannot be nil")
+	}
+	filtered := f.filterSummary(summary)
+	if filtered.SchemaVersion == "" {
+		filtered.SchemaVersion = SummarySchemaVersion
+	}
+	data, err := json.MarshalIndent(&filtered, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// LoadSummaryFile reads a plan summary from a JSON file
+func LoadSummaryFile(path string) (*PlanSummary, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary %s: %w", path, err)
+	}
+	defer file.Close()
+
+	summary, err := LoadSummary(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return summary, nil
+}
+
+// IsSummaryFile reports whether a file is a saved plan summary instead of a plan, which is detected by the
+// schema_version field of summaries. Only the top-level keys are read, up to schema_version. Files that
+// can't be read as a JSON object, such as binary plan files, aren't summaries.
+func IsSummaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	if expectDelim(decoder, '{') != nil {
+		return false
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if token == "schema_version" {
+			return true
+		}
+		if skipValue(decoder) != nil {
+			return false
+		}
+	}
+	return false
+}
+
+// restoreSummaryFields fills in the fields that the analyzer derives from the plan and that older
+// summaries didn't include
+func restoreSummaryFields(summary *PlanSummary) {
+	for i := range summary.ResourceChanges {
+		change := &summary.ResourceChanges[i]
+		change.IsNoOp = change.IsNoOp || change.ChangeType == ChangeTypeNoOp
+		if change.Provider == "" {
+			change.Provider = providerFromType(change.Type)
+		}
+	}
+	for i := range summary.OutputChanges {
+		change := &summary.OutputChanges[i]
+		change.IsNoOp = change.IsNoOp || change.ChangeType == ChangeTypeNoOp
+	}
+}
+
+// providerFromType returns the provider of a resource type, such as "aws" for "aws_s3_bucket"
+func providerFromType(resourceType string) string {
+	provider, _, _ := strings.Cut(resourceType, "_")
+	if provider == "" {
+		return "unknown"
+	}
+	return provider
+}
@@ -0,0 +1,99 @@
+package plan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSummary_RendersLikeTheOriginal(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.ShowNoOps = false
+	tfPlan, err := NewParser("../../samples/danger-sample.json").LoadPlan()
+	require.NoError(t, err)
+	summary := NewAnalyzer(tfPlan, cfg).GenerateSummary("danger-sample.json")
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	require.NoError(t, SaveSummary(summary, path))
+	loaded, err := LoadSummaryFile(path)
+	require.NoError(t, err)
+
+	for _, format := range []string{"markdown", "json", "html"} {
+		t.Run(format, func(t *testing.T) {
+			var original, reloaded bytes.Buffer
+			formatter := NewFormatter(cfg)
+			require.NoError(t, formatter.RenderSummary(&original, summary, cfg.NewOutputConfigurationForFormat(format), true))
+			require.NoError(t, formatter.RenderSummary(&reloaded, loaded, cfg.NewOutputConfigurationForFormat(format), true))
+			assert.Equal(t, original.String(), reloaded.String())
+		})
+	}
+}
+
+func TestLoadSummary_RestoresDerivedFields(t *testing.T) {
+	// A summary saved before the format was versioned, without no-op flags or providers
+	legacy := `{
+		"format_version": "1.2",
+		"plan_file": "old.tfplan",
+		"resource_changes": [
+			{"address": "aws_instance.web", "type": "aws_instance", "name": "web", "change_type": "no-op"},
+			{"address": "google_storage_bucket.logs", "type": "google_storage_bucket", "name": "logs", "change_type": "create"}
+		],
+		"output_changes": [
+			{"name": "ip", "change_type": "no-op"},
+			{"name": "url", "change_type": "update"}
+		],
+		"statistics": {"to_add": 1, "unmodified": 1, "total": 2, "output_changes": 1}
+	}`
+
+	summary, err := LoadSummary(strings.NewReader(legacy))
+	require.NoError(t, err)
+	assert.Equal(t, SummarySchemaVersion, summary.SchemaVersion)
+	assert.True(t, summary.ResourceChanges[0].IsNoOp)
+	assert.False(t, summary.ResourceChanges[1].IsNoOp)
+	assert.Equal(t, "aws", summary.ResourceChanges[0].Provider)
+	assert.Equal(t, "google", summary.ResourceChanges[1].Provider)
+	assert.True(t, summary.OutputChanges[0].IsNoOp)
+	assert.False(t, summary.OutputChanges[1].IsNoOp)
+}
+
+func TestLoadSummary_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"invalid JSON", `{`, "failed to parse summary JSON"},
+		{"unsupported version", `{"schema_version": "99"}`, `unsupported summary schema version "99"`},
+		{"plan instead of summary", `{"format_version": "1.2", "planned_values": {}}`, `unknown field "planned_values"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSummary(strings.NewReader(tt.input))
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestLoadSummaryFile_Missing(t *testing.T) {
+	_, err := LoadSummaryFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestIsSummaryFile(t *testing.T) {
+	dir := t.TempDir()
+	summaryFile := filepath.Join(dir, "summary.json")
+	require.NoError(t, SaveSummary(&PlanSummary{SchemaVersion: SummarySchemaVersion, PlanFile: "terraform.tfplan"}, summaryFile))
+	binaryPlan := filepath.Join(dir, "terraform.tfplan")
+	require.NoError(t, os.WriteFile(binaryPlan, []byte("PK\x03\x04"), 0o600))
+
+	assert.True(t, IsSummaryFile(summaryFile))
+	assert.False(t, IsSummaryFile("../../samples/danger-sample.json"), "plans have no schema version")
+	assert.False(t, IsSummaryFile(binaryPlan))
+	assert.False(t, IsSummaryFile(filepath.Join(dir, "missing.json")))
+}
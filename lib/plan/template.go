@@ -0,0 +1,209 @@
+package plan
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/ArjenSchwarz/strata/config"
+)
+
+// formatTemplate renders the summary with a user-supplied Go template
+const formatTemplate = "template"
+
+// IsTemplateFormat reports whether an output format renders a user-supplied template
+func IsTemplateFormat(format string) bool {
+	return strings.EqualFold(format, formatTemplate)
+}
+
+// ResourceGroup is a named group of resource changes, as returned by the grouping template functions
+type ResourceGroup struct {
+	Name    string
+	Changes []ResourceChange
+}
+
+// executor is implemented by both text and HTML templates
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// RenderTemplate executes a Go template file against a plan summary. Templates ending in .html,
+// .htm, or .gohtml (optionally followed by .tmpl) use html/template, which escapes values for HTML;
+// all other templates use text/template. No-op resources and the resource filter are applied to the
+// resource changes in the same way as for the other output formats.
+func (f *Formatter) RenderTemplate(w io.Writer, summary *PlanSummary, templatePath string) error {
+	if summary == nil {
+		return fmt.Errorf("plan summary cannot be nil")
+	}
+	if templatePath == "" {
+		return fmt.Errorf("the template output format requires a template file, use --template")
+	}
+	source, err := os.ReadFile(templatePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	name := filepath.Base(templatePath)
+	formatter := f.withFormat(formatTemplate, false)
+	var tmpl executor
+	if isHTMLTemplate(name) {
+		tmpl, err = htmltemplate.New(name).Funcs(htmltemplate.FuncMap(formatter.templateFuncs())).Parse(string(source))
+	} else {
+		tmpl, err = template.New(name).Funcs(formatter.templateFuncs()).Parse(string(source))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	filtered := f.filterSummary(summary)
+
+	// Execute into a buffer, so a failing template doesn't leave partial output behind
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, &filtered); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	_, err = w.Write(rendered.Bytes())
+	return err
+}
+
+// isCustomFormat reports whether an output format is rendered by strata itself instead of go-output
+func isCustomFormat(format string) bool {
+	return IsTemplateFormat(format) || IsReportFormat(format) || strings.EqualFold(format, formatJSON)
+}
+
+// renderCustomFormat renders a summary in one of the formats rendered by strata itself
+func (f *Formatter) renderCustomFormat(w io.Writer, summary *PlanSummary, format string, outputConfig *config.OutputConfiguration) error {
+	if IsReportFormat(format) {
+		return f.RenderReport(w, summary)
+	}
+	if strings.EqualFold(format, formatJSON) {
+		return f.RenderJSON(w, summary)
+	}
+	return f.RenderTemplate(w, summary, outputConfig.Template)
+}
+
+// outputCustomSummary renders a summary when stdout or the output file uses the JSON, template, or report format.
+// The other destination, if any, is rendered in its own format.
+func (f *Formatter) outputCustomSummary(summary *PlanSummary, outputConfig *config.OutputConfiguration, showDetails bool) error {
+	if isCustomFormat(outputConfig.Format) {
+		// Render into a buffer first, so a failure doesn't leave partial output behind
+		var rendered bytes.Buffer
+		if err := f.renderCustomFormat(&rendered, summary, outputConfig.Format, outputConfig); err != nil {
+			return err
+		}
+		if _, err := os.Stdout.Write(rendered.Bytes()); err != nil {
+			return fmt.Errorf("failed to render to stdout: %w", err)
+		}
+	} else {
+		stdoutConfig := *outputConfig
+		stdoutConfig.OutputFile = ""
+		if err := f.OutputSummary(summary, &stdoutConfig, showDetails); err != nil {
+			return err
+		}
+	}
+
+	if outputConfig.OutputFile == "" {
+		return nil
+	}
+	fileConfig := *outputConfig
+	fileConfig.Format = outputConfig.OutputFileFormat
+	var rendered bytes.Buffer
+	if err := f.RenderSummary(&rendered, summary, &fileConfig, showDetails); err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputConfig.OutputFile, rendered.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// filterSummary returns a copy of a summary without the no-op changes, unless they are shown,
+// and with only the resource changes that match the resource filter
+func (f *Formatter) filterSummary(summary *PlanSummary) PlanSummary {
+	filtered := *summary
+	filtered.ResourceChanges = f.filterNoOps(summary.ResourceChanges)
+	filtered.OutputChanges = f.filterNoOpOutputs(summary.OutputChanges)
+	if f.config != nil {
+		filtered.ResourceChanges = NewResourceFilter(f.config.Plan.Filter).Apply(filtered.ResourceChanges)
+	}
+	return filtered
+}
+
+// isHTMLTemplate reports whether a template file name indicates an HTML template
+func isHTMLTemplate(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".tmpl")
+	return slices.Contains([]string{".html", ".htm", ".gohtml"}, filepath.Ext(name))
+}
+
+// templateFuncs returns the helper functions available in summary templates
+func (f *Formatter) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// Filtering
+		"changeType": func(changes []ResourceChange, types ...string) []ResourceChange {
+			return NewResourceFilter(config.ResourceFilterConfig{Actions: types}).Apply(changes)
+		},
+		"dangerous": func(changes []ResourceChange) []ResourceChange {
+			return slices.DeleteFunc(slices.Clone(changes), func(change ResourceChange) bool { return !change.IsDangerous })
+		},
+		// Grouping
+		"groupByProvider": func(changes []ResourceChange) []ResourceGroup {
+			return groupResourceChanges(changes, func(change ResourceChange) string { return change.Provider })
+		},
+		"groupByModule": func(changes []ResourceChange) []ResourceGroup {
+			return groupResourceChanges(changes, func(change ResourceChange) string { return change.ModulePath })
+		},
+		"groupByType": func(changes []ResourceChange) []ResourceGroup {
+			return groupResourceChanges(changes, func(change ResourceChange) string { return change.Type })
+		},
+		"groupByChangeType": func(changes []ResourceChange) []ResourceGroup {
+			return groupResourceChanges(changes, func(change ResourceChange) string { return string(change.ChangeType) })
+		},
+		// Formatting
+		"action":               getActionDisplay,
+		"formatPropertyChange": f.formatPropertyChange,
+		"formatValue":          f.formatValue,
+		"mask": func(value any, sensitive bool) any {
+			if sensitive {
+				return sensitiveValue
+			}
+			return value
+		},
+		"join":   strings.Join,
+		"lower":  strings.ToLower,
+		"upper":  strings.ToUpper,
+		"indent": indentLines,
+	}
+}
+
+// groupResourceChanges groups resource changes by a key, with the groups sorted by name
+// and the changes in each group kept in their original order
+func groupResourceChanges(changes []ResourceChange, key func(ResourceChange) string) []ResourceGroup {
+	indexes := map[string]int{}
+	groups := []ResourceGroup{}
+	for _, change := range changes {
+		name := key(change)
+		index, ok := indexes[name]
+		if !ok {
+			index = len(groups)
+			indexes[name] = index
+			groups = append(groups, ResourceGroup{Name: name})
+		}
+		groups[index].Changes = append(groups[index].Changes, change)
+	}
+	slices.SortStableFunc(groups, func(a, b ResourceGroup) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return groups
+}
+
+// indentLines indents every line of text by the given number of spaces
+func indentLines(spaces int, text string) string {
+	prefix := strings.Repeat(" ", spaces)
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
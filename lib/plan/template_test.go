@@ -0,0 +1,101 @@
+package plan
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTemplate writes a template file to a temporary directory and returns its path
+func writeTemplate(t *testing.T, name, source string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(source), 0o600))
+	return path
+}
+
+func templateTestSummary() *PlanSummary {
+	changes := filterTestChanges()
+	changes[0].PropertyChanges = PropertyChangeAnalysis{Changes: []PropertyChange{
+		{Name: "instance_type", Path: []string{"instance_type"}, Action: actionUpdate, Before: "t3.micro", After: "t3.large"},
+		{Name: "password", Path: []string{"password"}, Action: actionUpdate, Sensitive: true, Before: "(sensitive value)", After: "(sensitive value)"},
+	}}
+	changes = append(changes, ResourceChange{Address: "aws_vpc.main", Provider: "aws", ChangeType: ChangeTypeNoOp})
+	return &PlanSummary{PlanFile: "test.tfplan", ResourceChanges: changes}
+}
+
+func TestFormatter_RenderTemplate(t *testing.T) {
+	path := writeTemplate(t, "notes.tmpl", `{{ .PlanFile }}
+{{ range groupByProvider .ResourceChanges }}{{ .Name }}: {{ len .Changes }}
+{{ end }}{{ range changeType .ResourceChanges "create" "replace" }}{{ action .ChangeType }} {{ .Address }}
+{{ end }}{{ range dangerous .ResourceChanges }}{{ upper .RiskLevel }} {{ .Address }}
+{{ end }}{{ range (index .ResourceChanges 0).PropertyChanges.Changes }}{{ formatPropertyChange . }}|{{ mask .After .Sensitive }}
+{{ end }}`)
+
+	var rendered bytes.Buffer
+	require.NoError(t, NewFormatter(config.GetDefaultConfig()).RenderTemplate(&rendered, templateTestSummary(), path))
+
+	output := rendered.String()
+	assert.Contains(t, output, "test.tfplan\n")
+	// No-op resources are left out, as in the other output formats
+	assert.Contains(t, output, "aws: 3\nazurerm: 1\n")
+	assert.Contains(t, output, "Replace module.app.module.storage.aws_s3_bucket.data\nAdd module.network.azurerm_subnet.main\n")
+	assert.Contains(t, output, "CRITICAL module.app[\"blue\"].aws_iam_role.app\nHIGH module.app.module.storage.aws_s3_bucket.data\n")
+	assert.Contains(t, output, `~ instance_type = "t3.micro" -> "t3.large"|t3.large`)
+	assert.Contains(t, output, "~ password = (sensitive, changed)|(sensitive value)")
+}
+
+func TestFormatter_RenderTemplate_ResourceFilter(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.Filter.Providers = []string{"azurerm"}
+	path := writeTemplate(t, "filtered.tmpl", `{{ range .ResourceChanges }}{{ .Address }} {{ end }}`)
+
+	var rendered bytes.Buffer
+	require.NoError(t, NewFormatter(cfg).RenderTemplate(&rendered, templateTestSummary(), path))
+	assert.Equal(t, "module.network.azurerm_subnet.main ", rendered.String())
+}
+
+func TestFormatter_RenderTemplate_HTML(t *testing.T) {
+	summary := templateTestSummary()
+	summary.ResourceChanges[0].DangerReason = "<script>alert(1)</script>"
+	source := `{{ (index .ResourceChanges 0).DangerReason }}`
+
+	var rendered bytes.Buffer
+	formatter := NewFormatter(config.GetDefaultConfig())
+	require.NoError(t, formatter.RenderTemplate(&rendered, summary, writeTemplate(t, "report.html.tmpl", source)))
+	assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt;", rendered.String())
+
+	rendered.Reset()
+	require.NoError(t, formatter.RenderTemplate(&rendered, summary, writeTemplate(t, "report.txt", source)))
+	assert.Equal(t, "<script>alert(1)</script>", rendered.String())
+}
+
+func TestFormatter_RenderTemplate_Errors(t *testing.T) {
+	formatter := NewFormatter(config.GetDefaultConfig())
+	var rendered bytes.Buffer
+
+	assert.ErrorContains(t, formatter.RenderTemplate(&rendered, templateTestSummary(), ""), "requires a template file")
+	assert.ErrorContains(t, formatter.RenderTemplate(&rendered, templateTestSummary(), "missing.tmpl"), "failed to read template")
+	assert.ErrorContains(t, formatter.RenderTemplate(&rendered, templateTestSummary(), writeTemplate(t, "bad.tmpl", "{{ .Nope")), "failed to parse template")
+	assert.ErrorContains(t, formatter.RenderTemplate(&rendered, templateTestSummary(), writeTemplate(t, "fails.tmpl", "partial {{ .Nope }}")), "failed to execute template")
+	assert.Empty(t, rendered.String(), "failed templates must not write partial output")
+}
+
+func TestFormatter_OutputSummary_TemplateFile(t *testing.T) {
+	cfg := config.GetDefaultConfig()
+	outputFile := filepath.Join(t.TempDir(), "notes.txt")
+	outputConfig := cfg.NewOutputConfigurationForFormat("markdown")
+	outputConfig.OutputFile = outputFile
+	outputConfig.OutputFileFormat = "template"
+	outputConfig.Template = writeTemplate(t, "notes.tmpl", `{{ len .ResourceChanges }} changes in {{ .PlanFile }}`)
+
+	require.NoError(t, NewFormatter(cfg).OutputSummary(templateTestSummary(), outputConfig, false))
+	written, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "4 changes in test.tfplan", string(written))
+}
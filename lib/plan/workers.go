@@ -0,0 +1,167 @@
+package plan
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// analyzeResourceChangesContext analyses the resource changes of the plan with a bounded pool of workers.
+// The results are in the same order as the plan, whatever order the workers finish in. When the context is
+// cancelled, no further resources are started and the context's error is returned.
+func (a *Analyzer) analyzeResourceChangesContext(ctx context.Context) ([]ResourceChange, error) {
+	pool := a.newResourcePool(ctx)
+	for _, resource := range a.plan.ResourceChanges {
+		if err := pool.add(resource); err != nil {
+			break
+		}
+	}
+	return pool.wait()
+}
+
+// resourcePool analyses resource changes with a bounded pool of workers as they are added, so a plan can
+// be analysed while it's decoded and the decoded changes are released once they're analysed
+type resourcePool struct {
+	analyzer  *Analyzer
+	ctx       context.Context
+	resources chan indexedResource
+	budget    *memoryBudget
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	changes   []ResourceChange // Analysed changes, in the order they were added
+}
+
+// indexedResource is a resource change with its position in the plan
+type indexedResource struct {
+	index    int
+	resource *tfjson.ResourceChange
+}
+
+// newResourcePool starts the workers of a pool, which run until wait is called
+func (a *Analyzer) newResourcePool(ctx context.Context) *resourcePool {
+	pool := &resourcePool{
+		analyzer:  a,
+		ctx:       ctx,
+		resources: make(chan indexedResource),
+		budget:    newMemoryBudget(a.maxTotalMemory()),
+		changes:   []ResourceChange{},
+	}
+	for range max(a.workerCount(), 1) {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+	return pool
+}
+
+// work analyses resource changes until the pool is closed. The analysis is done outside the lock,
+// so only storing the result is serialised.
+func (p *resourcePool) work() {
+	defer p.wg.Done()
+	for item := range p.resources {
+		change := p.analyzer.analyzeResourceChange(item.resource)
+		p.budget.charge(item.index, &change.PropertyChanges)
+		p.mu.Lock()
+		p.changes[item.index] = change
+		p.mu.Unlock()
+	}
+}
+
+// add hands a resource change to the next free worker, blocking until one is available.
+// It returns the context's error without adding the change when the context is cancelled.
+func (p *resourcePool) add(resource *tfjson.ResourceChange) error {
+	p.mu.Lock()
+	index := len(p.changes)
+	p.changes = append(p.changes, ResourceChange{})
+	p.mu.Unlock()
+
+	select {
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	case p.resources <- indexedResource{index, resource}:
+		return nil
+	}
+}
+
+// wait stops the workers once the added changes are analysed and returns the results in the order the
+// changes were added, or the context's error when the context was cancelled
+func (p *resourcePool) wait() ([]ResourceChange, error) {
+	close(p.resources)
+	p.wg.Wait()
+	if err := p.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.changes, nil
+}
+
+// workerCount returns the number of resources that are analysed at the same time
+func (a *Analyzer) workerCount() int {
+	if a.config == nil {
+		return runtime.GOMAXPROCS(0)
+	}
+	return a.config.GetPerformanceLimitsWithDefaults().Workers
+}
+
+// maxTotalMemory returns the memory limit for the property changes of all resources together
+func (a *Analyzer) maxTotalMemory() int64 {
+	if a.config == nil {
+		return 0
+	}
+	return a.config.GetPerformanceLimitsWithDefaults().MaxTotalMemory
+}
+
+// memoryBudget enforces the total memory limit for property changes across workers. Resources are charged
+// in plan order, so the same resources are truncated however the work is scheduled. A worker waits for its
+// turn before it takes the next resource, so at most one analysed resource per worker is held on top of
+// the limit.
+type memoryBudget struct {
+	mu    sync.Mutex
+	turn  *sync.Cond
+	next  int   // Index of the next resource to charge
+	used  int64 // Estimated size of the property changes charged so far
+	limit int64 // Zero when there is no limit
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	budget := &memoryBudget{limit: limit}
+	budget.turn = sync.NewCond(&budget.mu)
+	return budget
+}
+
+// charge adds the size of a resource's property changes to the budget once all earlier resources have
+// been charged. Property changes that don't fit in the remaining budget are dropped and released, and
+// the analysis is marked as truncated.
+func (b *memoryBudget) charge(index int, analysis *PropertyChangeAnalysis) {
+	if b.limit <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.next != index {
+		b.turn.Wait()
+	}
+	defer func() {
+		b.next++
+		b.turn.Broadcast()
+	}()
+
+	kept := 0
+	for _, change := range analysis.Changes {
+		if b.used+int64(change.Size) > b.limit {
+			analysis.Truncated = true
+			break
+		}
+		b.used += int64(change.Size)
+		kept++
+	}
+	if kept < len(analysis.Changes) {
+		clear(analysis.Changes[kept:])
+		analysis.Changes = analysis.Changes[:kept]
+		analysis.Count = kept
+		analysis.TotalSize = 0
+		for _, change := range analysis.Changes {
+			analysis.TotalSize += change.Size
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package plan
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// propertyHeavyPlan returns a plan with many resources that each change many properties
+func propertyHeavyPlan(resources, properties, size int) *tfjson.Plan {
+	builder := NewPlanBuilder()
+	for i := range resources {
+		builder.AddPropertyHeavyResource("aws_instance", fmt.Sprintf("web_%d", i), properties, size)
+	}
+	return builder.Build()
+}
+
+func analyzerWithWorkers(plan *tfjson.Plan, workers int) *Analyzer {
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.PerformanceLimits.Workers = workers
+	return NewAnalyzer(plan, cfg)
+}
+
+func TestAnalyzer_AnalyzeResourceChanges_Ordering(t *testing.T) {
+	plan := NewPlanBuilder().AddMultiProviderResources(200).Build()
+
+	sequential := analyzerWithWorkers(plan, 1).analyzeResourceChanges()
+	concurrent := analyzerWithWorkers(plan, 8).analyzeResourceChanges()
+
+	require.Len(t, concurrent, 200)
+	for i, change := range concurrent {
+		assert.Equal(t, plan.ResourceChanges[i].Address, change.Address)
+	}
+	assert.Equal(t, sequential, concurrent)
+}
+
+func TestAnalyzer_SummarizePlanContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	summary, err := analyzerWithWorkers(propertyHeavyPlan(50, 10, 10), 2).SummarizePlanContext(ctx, "cancelled")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, summary)
+}
+
+func TestAnalyzer_AnalyzeResourceChanges_MaxTotalMemory(t *testing.T) {
+	// 20 resources with 10 properties of about 2KB each, so roughly 400KB of property values
+	plan := propertyHeavyPlan(20, 10, 1000)
+	limit := int64(100 * 1024)
+
+	analyze := func(workers int) []ResourceChange {
+		analyzer := analyzerWithWorkers(plan, workers)
+		analyzer.config.Plan.PerformanceLimits.MaxTotalMemory = limit
+		return analyzer.analyzeResourceChanges()
+	}
+
+	changes := analyze(8)
+
+	var total int64
+	for _, change := range changes {
+		total += int64(change.PropertyChanges.TotalSize)
+		assert.Equal(t, len(change.PropertyChanges.Changes), change.PropertyChanges.Count)
+	}
+	assert.LessOrEqual(t, total, limit)
+	assert.Len(t, changes[0].PropertyChanges.Changes, 10, "resources are charged in plan order")
+	assert.True(t, changes[len(changes)-1].PropertyChanges.Truncated)
+	assert.Empty(t, changes[len(changes)-1].PropertyChanges.Changes)
+
+	// The same resources are truncated however the work is scheduled
+	assert.Equal(t, analyze(1), changes)
+	for range 10 {
+		assert.Equal(t, changes, analyze(8))
+	}
+}
+
+func TestMemoryBudget_ChargesInOrder(t *testing.T) {
+	budget := newMemoryBudget(25)
+	analyses := make([]PropertyChangeAnalysis, 4)
+	for i := range analyses {
+		analyses[i] = PropertyChangeAnalysis{
+			Changes:   []PropertyChange{{Name: "a", Size: 5}, {Name: "b", Size: 5}},
+			Count:     2,
+			TotalSize: 10,
+		}
+	}
+
+	// Charge in reverse order, which must still be applied in index order
+	var wg sync.WaitGroup
+	for i := len(analyses) - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			budget.charge(i, &analyses[i])
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int{2, 2, 1, 0}, []int{analyses[0].Count, analyses[1].Count, analyses[2].Count, analyses[3].Count})
+	assert.False(t, analyses[1].Truncated)
+	assert.True(t, analyses[2].Truncated)
+	assert.Equal(t, 5, analyses[2].TotalSize)
+	assert.Empty(t, analyses[3].Changes)
+	assert.Equal(t, int64(25), budget.used)
+}
+
+func TestAnalyzer_SummarizeReader_MatchesLoadedPlan(t *testing.T) {
+	for _, sample := range []string{"../../testdata/dependencies_plan.json", "../../samples/danger-sample.json"} {
+		t.Run(sample, func(t *testing.T) {
+			tfPlan, err := NewParser(sample).LoadPlan()
+			require.NoError(t, err)
+			expected := analyzerWithWorkers(tfPlan, 4).SummarizePlan(sample)
+
+			file, err := os.Open(sample)
+			require.NoError(t, err)
+			defer file.Close()
+			streamed, err := analyzerWithWorkers(nil, 4).SummarizeReader(context.Background(), file, sample)
+			require.NoError(t, err)
+
+			// Output changes are analysed from a map, so their order isn't stable
+			assert.ElementsMatch(t, expected.OutputChanges, streamed.OutputChanges)
+			expected.OutputChanges, streamed.OutputChanges = nil, nil
+			assert.Equal(t, expected, streamed)
+		})
+	}
+}
+
+func TestAnalyzer_SummarizeReader_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	file, err := os.Open("../../testdata/dependencies_plan.json")
+	require.NoError(t, err)
+	defer file.Close()
+	summary, err := analyzerWithWorkers(nil, 2).SummarizeReader(ctx, file, "cancelled")
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, summary)
+}
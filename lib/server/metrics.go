@@ -0,0 +1,107 @@
+package server
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// requestKey identifies a request counter by endpoint and status code
+type requestKey struct {
+	endpoint string
+	code     int
+}
+
+// metrics collects request metrics and serves them in the Prometheus text format
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[string]float64 // Total request duration in seconds by endpoint
+	inFlight  atomic.Int64
+	rejected  atomic.Uint64 // Summary requests and run task runs rejected by the concurrency limit
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  map[requestKey]uint64{},
+		durations: map[string]float64{},
+	}
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// instrument records the count, status, and duration of requests to an endpoint
+func (m *metrics) instrument(endpoint string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inFlight.Add(1)
+		defer m.inFlight.Add(-1)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		m.mu.Lock()
+		m.requests[requestKey{endpoint, recorder.code}]++
+		m.durations[endpoint] += time.Since(start).Seconds()
+		m.mu.Unlock()
+	})
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	m.mu.Lock()
+	keys := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b requestKey) int {
+		if c := strings.Compare(a.endpoint, b.endpoint); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.code, b.code)
+	})
+	counts := map[string]uint64{}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprintln(w, "# HELP strata_http_requests_total Requests handled, by endpoint and status code.")
+	fmt.Fprintln(w, "# TYPE strata_http_requests_total counter")
+	for _, key := range keys {
+		counts[key.endpoint] += m.requests[key]
+		fmt.Fprintf(w, "strata_http_requests_total{endpoint=%q,code=%q} %d\n", key.endpoint, strconv.Itoa(key.code), m.requests[key])
+	}
+
+	endpoints := make([]string, 0, len(m.durations))
+	for endpoint := range m.durations {
+		endpoints = append(endpoints, endpoint)
+	}
+	slices.Sort(endpoints)
+	fmt.Fprintln(w, "# HELP strata_http_request_duration_seconds Time spent handling requests, by endpoint.")
+	fmt.Fprintln(w, "# TYPE strata_http_request_duration_seconds summary")
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(w, "strata_http_request_duration_seconds_sum{endpoint=%q} %g\n", endpoint, m.durations[endpoint])
+		fmt.Fprintf(w, "strata_http_request_duration_seconds_count{endpoint=%q} %d\n", endpoint, counts[endpoint])
+	}
+	m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP strata_http_requests_in_flight Requests currently being handled.")
+	fmt.Fprintln(w, "# TYPE strata_http_requests_in_flight gauge")
+	fmt.Fprintf(w, "strata_http_requests_in_flight %d\n", m.inFlight.Load())
+	fmt.Fprintln(w, "# HELP strata_summaries_rejected_total Summary requests and run task runs rejected because of the concurrency limit.")
+	fmt.Fprintln(w, "# TYPE strata_summaries_rejected_total counter")
+	fmt.Fprintf(w, "strata_summaries_rejected_total %d\n", m.rejected.Load())
+}
@@ -0,0 +1,405 @@
+// Package server implements the HTTP integrations that run Strata as a service.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+)
+
+const (
+	// RunTaskSignatureHeader holds the HMAC-SHA512 of the request body, when the run task has an HMAC key
+	RunTaskSignatureHeader = "X-Tfc-Task-Signature"
+
+	// runTaskVerificationToken is the access token Terraform Cloud sends when a run task is created or updated
+	runTaskVerificationToken = "test-token"
+
+	// Run task result statuses
+	runTaskStatusPassed = "passed"
+	runTaskStatusFailed = "failed"
+
+	// jsonAPIContentType is the media type of the Terraform Cloud API
+	jsonAPIContentType = "application/vnd.api+json"
+
+	// maxRunTaskRequestSize limits the webhook payload, which only contains metadata and URLs
+	maxRunTaskRequestSize = 1024 * 1024
+
+	// maxRunTaskPlanSize limits the plan JSON downloaded for a run
+	maxRunTaskPlanSize = 512 * 1024 * 1024
+
+	// maxRunTaskOutcomes limits the outcomes sent for a single run, including the summary
+	maxRunTaskOutcomes = 100
+
+	// runTaskTimeout bounds the download, analysis, and callback for a single run
+	runTaskTimeout = 5 * time.Minute
+)
+
+// RunTaskRequest is the webhook payload Terraform Cloud and Enterprise send to a run task
+type RunTaskRequest struct {
+	PayloadVersion         int    `json:"payload_version"`
+	AccessToken            string `json:"access_token"`
+	Stage                  string `json:"stage"`
+	IsSpeculative          bool   `json:"is_speculative"`
+	TaskResultID           string `json:"task_result_id"`
+	TaskResultCallbackURL  string `json:"task_result_callback_url"`
+	TaskResultEnforcement  string `json:"task_result_enforcement_level"`
+	RunAppURL              string `json:"run_app_url"`
+	RunID                  string `json:"run_id"`
+	RunMessage             string `json:"run_message"`
+	WorkspaceID            string `json:"workspace_id"`
+	WorkspaceName          string `json:"workspace_name"`
+	OrganizationName       string `json:"organization_name"`
+	PlanJSONAPIURL         string `json:"plan_json_api_url"`
+	VCSCommitURL           string `json:"vcs_commit_url"`
+	ConfigurationVersionID string `json:"configuration_version_id"`
+}
+
+// runTaskResult is the JSON:API document sent to the task result callback
+type runTaskResult struct {
+	Data runTaskResultData `json:"data"`
+}
+
+type runTaskResultData struct {
+	Type          string                     `json:"type"`
+	Attributes    runTaskResultAttributes    `json:"attributes"`
+	Relationships *runTaskResultRelationship `json:"relationships,omitempty"`
+}
+
+type runTaskResultAttributes struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+}
+
+type runTaskResultRelationship struct {
+	Outcomes struct {
+		Data []runTaskOutcome `json:"data"`
+	} `json:"outcomes"`
+}
+
+type runTaskOutcome struct {
+	Type       string                   `json:"type"`
+	Attributes runTaskOutcomeAttributes `json:"attributes"`
+}
+
+type runTaskOutcomeAttributes struct {
+	OutcomeID   string                    `json:"outcome-id"`
+	Description string                    `json:"description"`
+	Body        string                    `json:"body,omitempty"`
+	URL         string                    `json:"url,omitempty"`
+	Tags        map[string][]runTaskLabel `json:"tags,omitempty"`
+}
+
+type runTaskLabel struct {
+	Label string `json:"label"`
+	Level string `json:"level,omitempty"` // "none", "info", "warning", or "error"
+}
+
+// ErrMissingHMACKey is returned when a run task handler is created without an HMAC key. Webhooks make
+// Strata download plans and send results with the URLs and token in the request, so they must be signed.
+var ErrMissingHMACKey = errors.New("the run task requires an HMAC key")
+
+// RunTaskHandler handles Terraform Cloud run task webhooks. Plans are analysed in the background
+// after the webhook is acknowledged, and the result is sent to the callback URL of the task result.
+type RunTaskHandler struct {
+	cfg          *config.Config
+	hmacKey      []byte
+	failOnDanger bool
+	client       *http.Client
+	logger       *log.Logger
+	runs         sync.WaitGroup
+	slots        chan struct{}  // Limits the runs analysed at the same time, shared with the server when registered
+	rejected     *atomic.Uint64 // Runs rejected by the concurrency limit
+}
+
+// NewRunTaskHandler creates a run task handler. Requests must be signed with the HMAC key, which is required.
+// When failOnDanger is set, runs with high-risk changes fail, which blocks them for mandatory run tasks.
+// At most DefaultMaxConcurrent runs are analysed at the same time, unless the handler is registered with
+// Server.HandleRunTask, which shares the limit of the server.
+func NewRunTaskHandler(cfg *config.Config, hmacKey string, failOnDanger bool, logger *log.Logger) (*RunTaskHandler, error) {
+	if hmacKey == "" {
+		return nil, ErrMissingHMACKey
+	}
+	return &RunTaskHandler{
+		cfg:          cfg,
+		hmacKey:      []byte(hmacKey),
+		failOnDanger: failOnDanger,
+		client:       &http.Client{Timeout: runTaskTimeout},
+		logger:       logger,
+		slots:        make(chan struct{}, DefaultMaxConcurrent),
+		rejected:     &atomic.Uint64{},
+	}, nil
+}
+
+// ServeHTTP acknowledges a run task webhook and starts analysing the plan
+func (h *RunTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRunTaskRequestSize))
+	if err != nil {
+		http.Error(w, "failed to read request", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !h.validSignature(body, r.Header.Get(RunTaskSignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var request RunTaskRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid run task payload", http.StatusBadRequest)
+		return
+	}
+	// Terraform Cloud checks that the endpoint is reachable when the run task is configured
+	if request.AccessToken == runTaskVerificationToken {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if request.AccessToken == "" || request.TaskResultCallbackURL == "" {
+		http.Error(w, "run task payload is missing the access token or callback URL", http.StatusBadRequest)
+		return
+	}
+
+	// Analyses are CPU and memory intensive, so runs beyond the limit are rejected instead of queued
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		h.rejected.Add(1)
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "too many concurrent runs", http.StatusServiceUnavailable)
+		return
+	}
+
+	// The callback has to be sent within the run task timeout, so the work continues after this request ends
+	h.runs.Add(1)
+	go func() {
+		defer h.runs.Done()
+		defer func() { <-h.slots }()
+		ctx, cancel := context.WithTimeout(context.Background(), runTaskTimeout)
+		defer cancel()
+		h.process(ctx, &request)
+	}()
+	w.WriteHeader(http.StatusOK)
+}
+
+// Wait blocks until all runs that are being analysed have sent their results
+func (h *RunTaskHandler) Wait() {
+	h.runs.Wait()
+}
+
+// validSignature checks the HMAC-SHA512 signature of the request body
+func (h *RunTaskHandler) validSignature(body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha512.New, h.hmacKey)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// process analyses the plan of a run and reports the result. Failures are reported to Terraform Cloud
+// as a failed result, so runs don't wait for the run task to time out.
+func (h *RunTaskHandler) process(ctx context.Context, request *RunTaskRequest) {
+	result, err := h.analyse(ctx, request)
+	if ctx.Err() != nil {
+		// The run task timed out, so the result can't be sent anymore and Terraform Cloud fails the run
+		h.logger.Printf("run task %s: stopped: %v", request.RunID, ctx.Err())
+		return
+	}
+	if err != nil {
+		h.logger.Printf("run task %s: %v", request.RunID, err)
+		result = newRunTaskResult(runTaskStatusFailed, fmt.Sprintf("Strata could not analyse the plan: %v", err))
+	}
+	if err := h.sendResult(ctx, request, result); err != nil {
+		h.logger.Printf("run task %s: failed to send result: %v", request.RunID, err)
+		return
+	}
+	h.logger.Printf("run task %s: %s", request.RunID, result.Data.Attributes.Status)
+}
+
+// analyse downloads and summarises the plan of a run
+func (h *RunTaskHandler) analyse(ctx context.Context, request *RunTaskRequest) (*runTaskResult, error) {
+	if request.PlanJSONAPIURL == "" {
+		return newRunTaskResult(runTaskStatusPassed, fmt.Sprintf("No plan to analyse in the %s stage", request.Stage)), nil
+	}
+
+	body, err := h.downloadPlan(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	// The plan is analysed while it's downloaded. It was downloaded, so the local workspace and plan
+	// file don't describe it.
+	summary, err := plan.NewAnalyzer(nil, h.cfg).SummarizeReader(ctx, io.LimitReader(body, maxRunTaskPlanSize), request.RunID)
+	if err != nil {
+		return nil, err
+	}
+	summary.Workspace = request.WorkspaceName
+	summary.Backend = plan.BackendInfo{Type: "cloud", Location: request.OrganizationName + "/" + request.WorkspaceName}
+	summary.CreatedAt = time.Now()
+
+	var markdown bytes.Buffer
+	outputConfig := h.cfg.NewOutputConfigurationForFormat("markdown")
+	if err := plan.NewFormatter(h.cfg).RenderSummary(&markdown, summary, outputConfig, h.cfg.Plan.ShowDetails); err != nil {
+		return nil, err
+	}
+	return h.summaryResult(summary, markdown.String()), nil
+}
+
+// downloadPlan requests the plan JSON with the access token of the run and returns the response body,
+// which the caller must close. The API redirects to a temporary download URL, which the client follows.
+func (h *RunTaskHandler) downloadPlan(ctx context.Context, request *RunTaskRequest) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, request.PlanJSONAPIURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid plan URL: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+request.AccessToken)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download plan: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download plan: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// sendResult sends the task result to the callback URL of the run
+func (h *RunTaskHandler) sendResult(ctx context.Context, request *RunTaskRequest, result *runTaskResult) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, request.TaskResultCallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+request.AccessToken)
+	req.Header.Set("Content-Type", jsonAPIContentType)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned %s", resp.Status)
+	}
+	return nil
+}
+
+// newRunTaskResult creates a task result without outcomes
+func newRunTaskResult(status, message string) *runTaskResult {
+	return &runTaskResult{Data: runTaskResultData{
+		Type:       "task-results",
+		Attributes: runTaskResultAttributes{Status: status, Message: message},
+	}}
+}
+
+// summaryResult creates the task result for a plan summary, with the full summary as the first outcome
+// followed by an outcome for every changed resource
+func (h *RunTaskHandler) summaryResult(summary *plan.PlanSummary, markdown string) *runTaskResult {
+	stats := summary.Statistics
+	status := runTaskStatusPassed
+	if h.failOnDanger && stats.HighRisk > 0 {
+		status = runTaskStatusFailed
+	}
+	message := fmt.Sprintf("%d to add, %d to change, %d to destroy, %d replacements, %d high-risk",
+		stats.ToAdd, stats.ToChange, stats.ToDestroy, stats.Replacements, stats.HighRisk)
+
+	result := newRunTaskResult(status, message)
+	result.Data.Relationships = &runTaskResultRelationship{}
+	outcomes := []runTaskOutcome{{
+		Type: "task-result-outcomes",
+		Attributes: runTaskOutcomeAttributes{
+			OutcomeID:   "summary",
+			Description: "Plan summary",
+			Body:        markdown,
+		},
+	}}
+
+	skipped := 0
+	for _, change := range summary.ResourceChanges {
+		if change.ChangeType == plan.ChangeTypeNoOp || change.IsNoOp {
+			continue
+		}
+		if len(outcomes) >= maxRunTaskOutcomes {
+			skipped++
+			continue
+		}
+		outcomes = append(outcomes, resourceOutcome(change))
+	}
+	if skipped > 0 {
+		result.Data.Attributes.Message += fmt.Sprintf(" (%d resources not listed)", skipped)
+	}
+
+	result.Data.Relationships.Outcomes.Data = outcomes
+	return result
+}
+
+// resourceOutcome describes a single resource change, tagged with its action and risk
+func resourceOutcome(change plan.ResourceChange) runTaskOutcome {
+	actionLevel := "info"
+	if change.ChangeType.IsDestructive() {
+		actionLevel = "warning"
+	}
+	risk := runTaskLabel{Label: "Low", Level: "none"}
+	if change.IsDangerous {
+		risk = runTaskLabel{Label: "High", Level: "error"}
+	}
+
+	var body strings.Builder
+	if change.IsDangerous {
+		fmt.Fprintf(&body, "**Danger:** %s", change.DangerReason)
+		if len(change.DangerProperties) > 0 {
+			fmt.Fprintf(&body, " (%s)", strings.Join(change.DangerProperties, ", "))
+		}
+		body.WriteString("\n\n")
+	}
+	if len(change.ReplacementHints) > 0 {
+		fmt.Fprintf(&body, "**Replaced because:** %s\n\n", strings.Join(change.ReplacementHints, ", "))
+	}
+	for _, property := range change.PropertyChanges.Changes {
+		name := property.Name
+		if len(property.Path) > 0 {
+			name = strings.Join(property.Path, ".")
+		}
+		fmt.Fprintf(&body, "- `%s` (%s)\n", name, property.Action)
+	}
+
+	return runTaskOutcome{
+		Type: "task-result-outcomes",
+		Attributes: runTaskOutcomeAttributes{
+			OutcomeID:   change.Address,
+			Description: fmt.Sprintf("%s %s", change.ChangeType, change.Address),
+			Body:        body.String(),
+			Tags: map[string][]runTaskLabel{
+				"Action": {{Label: string(change.ChangeType), Level: actionLevel}},
+				"Risk":   {risk},
+			},
+		},
+	}
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRunTaskToken   = "run-token"
+	testRunTaskHMACKey = "run-task-secret"
+	testRunTaskPlan    = "../../testdata/high_risk_plan.json"
+)
+
+// fakeTFC serves the plan JSON and task result callback endpoints of Terraform Cloud
+type fakeTFC struct {
+	*httptest.Server
+	plan    []byte
+	mu      sync.Mutex
+	results []runTaskResult
+}
+
+func newFakeTFC(t *testing.T, planFile string) *fakeTFC {
+	t.Helper()
+	plan, err := os.ReadFile(planFile)
+	require.NoError(t, err)
+
+	tfc := &fakeTFC{plan: plan}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v2/plans/plan-1/json-output", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testRunTaskToken {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		// Like Terraform Cloud, redirect to a temporary download URL
+		http.Redirect(w, r, "/archivist/plan-1", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("GET /archivist/plan-1", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(tfc.plan)
+	})
+	mux.HandleFunc("PATCH /api/v2/task-results/taskrs-1/callback", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testRunTaskToken || r.Header.Get("Content-Type") != jsonAPIContentType {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var result runTaskResult
+		if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tfc.mu.Lock()
+		tfc.results = append(tfc.results, result)
+		tfc.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	})
+	tfc.Server = httptest.NewServer(mux)
+	t.Cleanup(tfc.Close)
+	return tfc
+}
+
+func (tfc *fakeTFC) request(planURL string) []byte {
+	body, _ := json.Marshal(RunTaskRequest{
+		PayloadVersion:        1,
+		AccessToken:           testRunTaskToken,
+		Stage:                 "post_plan",
+		TaskResultID:          "taskrs-1",
+		TaskResultCallbackURL: tfc.URL + "/api/v2/task-results/taskrs-1/callback",
+		RunID:                 "run-1",
+		WorkspaceName:         "production",
+		PlanJSONAPIURL:        planURL,
+	})
+	return body
+}
+
+func signRunTask(body []byte, key string) string {
+	mac := hmac.New(sha512.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sendRunTask(t *testing.T, handler http.Handler, body []byte, signature string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/run-task", bytes.NewReader(body))
+	req.Header.Set(RunTaskSignatureHeader, signature)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func newTestRunTaskHandler(t *testing.T, failOnDanger bool) *RunTaskHandler {
+	t.Helper()
+	cfg := config.GetDefaultConfig()
+	cfg.SensitiveResources = []config.SensitiveResource{{ResourceType: "aws_db_instance"}}
+	handler, err := NewRunTaskHandler(cfg, testRunTaskHMACKey, failOnDanger, log.New(io.Discard, "", 0))
+	require.NoError(t, err)
+	return handler
+}
+
+func TestRunTaskHandler_EndToEnd(t *testing.T) {
+	tfc := newFakeTFC(t, testRunTaskPlan)
+	handler := newTestRunTaskHandler(t, true)
+
+	body := tfc.request(tfc.URL + "/api/v2/plans/plan-1/json-output")
+	resp := sendRunTask(t, handler, body, signRunTask(body, testRunTaskHMACKey))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	handler.Wait()
+
+	require.Len(t, tfc.results, 1)
+	result := tfc.results[0].Data
+	assert.Equal(t, "task-results", result.Type)
+	assert.Equal(t, runTaskStatusFailed, result.Attributes.Status, "high-risk changes fail the run")
+	assert.Contains(t, result.Attributes.Message, "high-risk")
+
+	require.NotNil(t, result.Relationships)
+	outcomes := result.Relationships.Outcomes.Data
+	require.Greater(t, len(outcomes), 1)
+	assert.Equal(t, "summary", outcomes[0].Attributes.OutcomeID)
+	assert.Contains(t, outcomes[0].Attributes.Body, "| Workspace |")
+	assert.Contains(t, outcomes[0].Attributes.Body, "production")
+
+	var database *runTaskOutcome
+	for i, outcome := range outcomes {
+		if outcome.Attributes.OutcomeID == "aws_db_instance.main_postgres" {
+			database = &outcomes[i]
+		}
+	}
+	require.NotNil(t, database)
+	assert.Equal(t, "replace aws_db_instance.main_postgres", database.Attributes.Description)
+	assert.Equal(t, []runTaskLabel{{Label: "High", Level: "error"}}, database.Attributes.Tags["Risk"])
+	assert.Contains(t, database.Attributes.Body, "**Danger:**")
+}
+
+func TestRunTaskHandler_AdvisoryMode(t *testing.T) {
+	tfc := newFakeTFC(t, testRunTaskPlan)
+	handler := newTestRunTaskHandler(t, false)
+
+	body := tfc.request(tfc.URL + "/api/v2/plans/plan-1/json-output")
+	sendRunTask(t, handler, body, signRunTask(body, testRunTaskHMACKey))
+	handler.Wait()
+
+	require.Len(t, tfc.results, 1)
+	assert.Equal(t, runTaskStatusPassed, tfc.results[0].Data.Attributes.Status)
+}
+
+func TestRunTaskHandler_PlanDownloadFailure(t *testing.T) {
+	tfc := newFakeTFC(t, testRunTaskPlan)
+	handler := newTestRunTaskHandler(t, true)
+
+	body := tfc.request(tfc.URL + "/api/v2/plans/missing/json-output")
+	sendRunTask(t, handler, body, signRunTask(body, testRunTaskHMACKey))
+	handler.Wait()
+
+	require.Len(t, tfc.results, 1)
+	assert.Equal(t, runTaskStatusFailed, tfc.results[0].Data.Attributes.Status)
+	assert.Contains(t, tfc.results[0].Data.Attributes.Message, "failed to download plan: 404")
+}
+
+func TestRunTaskHandler_ProcessCancelled(t *testing.T) {
+	tfc := newFakeTFC(t, testRunTaskPlan)
+	handler := newTestRunTaskHandler(t, true)
+
+	var request RunTaskRequest
+	require.NoError(t, json.Unmarshal(tfc.request(tfc.URL+"/api/v2/plans/plan-1/json-output"), &request))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // The run task timed out
+
+	handler.process(ctx, &request)
+	assert.Empty(t, tfc.results, "a cancelled run stops without analysing the plan or sending a result")
+}
+
+func TestRunTaskHandler_Requests(t *testing.T) {
+	tfc := newFakeTFC(t, testRunTaskPlan)
+	handler := newTestRunTaskHandler(t, true)
+	body := tfc.request(tfc.URL + "/api/v2/plans/plan-1/json-output")
+
+	t.Run("invalid signature", func(t *testing.T) {
+		resp := sendRunTask(t, handler, body, signRunTask(body, "other-key"))
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		resp := sendRunTask(t, handler, body, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	})
+
+	t.Run("verification request", func(t *testing.T) {
+		verification := []byte(`{"payload_version":1,"access_token":"test-token","task_result_callback_url":""}`)
+		resp := sendRunTask(t, handler, verification, signRunTask(verification, testRunTaskHMACKey))
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/run-task", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		invalid := []byte(`{"access_token":`)
+		resp := sendRunTask(t, handler, invalid, signRunTask(invalid, testRunTaskHMACKey))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	handler.Wait()
+	assert.Empty(t, tfc.results, "rejected requests don't send results")
+}
+
+func TestNewRunTaskHandler_RequiresHMACKey(t *testing.T) {
+	_, err := NewRunTaskHandler(config.GetDefaultConfig(), "", true, log.New(io.Discard, "", 0))
+	assert.ErrorIs(t, err, ErrMissingHMACKey)
+}
+
+func TestRunTaskHandler_SharesServerConcurrencyLimit(t *testing.T) {
+	tfc := newFakeTFC(t, testRunTaskPlan)
+	handler := newTestRunTaskHandler(t, true)
+	api := New(config.GetDefaultConfig(), Options{MaxConcurrent: 1}, log.New(io.Discard, "", 0))
+	api.HandleRunTask("/run-task", handler)
+
+	// Occupy the only slot, as a summary request being generated would
+	api.slots <- struct{}{}
+	body := tfc.request(tfc.URL + "/api/v2/plans/plan-1/json-output")
+	resp := sendRunTask(t, api, body, signRunTask(body, testRunTaskHMACKey))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+	assert.Equal(t, uint64(1), api.metrics.rejected.Load())
+
+	<-api.slots
+	resp = sendRunTask(t, api, body, signRunTask(body, testRunTaskHMACKey))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	handler.Wait()
+	require.Len(t, tfc.results, 1)
+	assert.Empty(t, api.slots, "the slot is released when the run is done")
+}
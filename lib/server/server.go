@@ -0,0 +1,273 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+)
+
+const (
+	// DefaultMaxRequestSize is the default limit for uploaded plans
+	DefaultMaxRequestSize = 50 * 1024 * 1024
+
+	// DefaultMaxConcurrent is the default number of summaries generated at the same time
+	DefaultMaxConcurrent = 4
+
+	// uploadedPlanName is shown as the plan file of uploaded plans
+	uploadedPlanName = "(upload)"
+)
+
+// summaryMediaTypes maps the media types accepted by the summaries endpoint to output formats
+var summaryMediaTypes = map[string]string{
+	"application/json": "json",
+	"text/markdown":    "markdown",
+	"text/html":        "html",
+	"text/csv":         "csv",
+	"text/plain":       "table",
+}
+
+// summaryContentTypes returns the Content-Type of every output format
+var summaryContentTypes = map[string]string{
+	"json":     "application/json",
+	"markdown": "text/markdown; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+	"csv":      "text/csv; charset=utf-8",
+	"table":    "text/plain; charset=utf-8",
+}
+
+// Options configures the limits of the API server
+type Options struct {
+	MaxRequestSize int64 // Maximum size of an uploaded plan in bytes (default: DefaultMaxRequestSize)
+	MaxConcurrent  int   // Maximum number of summaries generated at the same time (default: DefaultMaxConcurrent)
+}
+
+// Server is the HTTP API that summarises uploaded plans. Each request is analysed with a copy of
+// the server configuration, so requests can override settings without affecting each other.
+type Server struct {
+	cfg     *config.Config
+	options Options
+	slots   chan struct{}
+	metrics *metrics
+	mux     *http.ServeMux
+	logger  *log.Logger
+}
+
+// New creates an API server with the summaries, health, and metrics endpoints
+func New(cfg *config.Config, options Options, logger *log.Logger) *Server {
+	if options.MaxRequestSize <= 0 {
+		options.MaxRequestSize = DefaultMaxRequestSize
+	}
+	if options.MaxConcurrent <= 0 {
+		options.MaxConcurrent = DefaultMaxConcurrent
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		options: options,
+		slots:   make(chan struct{}, options.MaxConcurrent),
+		metrics: newMetrics(),
+		mux:     http.NewServeMux(),
+		logger:  logger,
+	}
+	s.Handle("/v1/summaries", http.HandlerFunc(s.handleSummaries))
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.Handle("/metrics", s.metrics)
+	return s
+}
+
+// Handle registers an additional endpoint, such as the run task handler, with request metrics
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, s.metrics.instrument(pattern, handler))
+}
+
+// HandleRunTask registers a run task handler, which then shares the concurrency limit of the server
+func (s *Server) HandleRunTask(pattern string, handler *RunTaskHandler) {
+	handler.slots = s.slots
+	handler.rejected = &s.metrics.rejected
+	s.Handle(pattern, handler)
+}
+
+// ServeHTTP routes requests to the endpoints of the server
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleHealth reports that the server is able to handle requests
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, `{"status":"ok"}`+"\n")
+}
+
+// handleSummaries summarises the plan JSON in the request body and renders it in the negotiated format
+func (s *Server) handleSummaries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		writeError(w, http.StatusNotAcceptable, err.Error())
+		return
+	}
+	cfg, err := s.requestConfig(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Summaries are CPU and memory intensive, so requests beyond the limit are rejected instead of queued
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	default:
+		s.metrics.rejected.Add(1)
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "too many concurrent requests")
+		return
+	}
+
+	// The plan is analysed while it's uploaded, so neither the request body nor the decoded resource
+	// changes are held in memory
+	body := http.MaxBytesReader(w, r.Body, s.options.MaxRequestSize)
+	summary, err := plan.NewAnalyzer(nil, cfg).SummarizeReader(r.Context(), body, uploadedPlanName)
+	if err != nil {
+		if r.Context().Err() != nil {
+			// The client went away, so nobody is waiting for the summary
+			return
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("plan exceeds the maximum size of %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	summary.Workspace = r.URL.Query().Get("workspace")
+	summary.CreatedAt = time.Now()
+
+	// Render into a buffer, so rendering errors can still be reported with an error status
+	var rendered bytes.Buffer
+	outputConfig := cfg.NewOutputConfigurationForFormat(format)
+	if err := plan.NewFormatter(cfg).RenderSummary(&rendered, summary, outputConfig, cfg.Plan.ShowDetails); err != nil {
+		s.logger.Printf("failed to render summary: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to render summary")
+		return
+	}
+
+	w.Header().Set("Content-Type", summaryContentTypes[format])
+	w.Header().Set("Vary", "Accept")
+	_, _ = w.Write(rendered.Bytes())
+}
+
+// requestConfig returns a copy of the server configuration with the overrides from the query string.
+// Only display settings can be overridden; danger rules and limits stay as configured for the server.
+func (s *Server) requestConfig(r *http.Request) (*config.Config, error) {
+	cfg := *s.cfg
+	query := r.URL.Query()
+
+	boolOverrides := map[string]*bool{
+		"details":           &cfg.Plan.ShowDetails,
+		"show_no_ops":       &cfg.Plan.ShowNoOps,
+		"expand_all":        &cfg.ExpandAll,
+		"group_by_provider": &cfg.Plan.Grouping.Enabled,
+		"use_emoji":         &cfg.UseEmoji,
+	}
+	for name, target := range boolOverrides {
+		if !query.Has(name) {
+			continue
+		}
+		value, err := strconv.ParseBool(query.Get(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %q", name, query.Get(name))
+		}
+		*target = value
+	}
+	return &cfg, nil
+}
+
+// negotiateFormat picks the output format from the format query parameter or the Accept header.
+// Summaries are returned as JSON when the client accepts any format.
+func negotiateFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get("format")); format != "" {
+		if _, ok := summaryContentTypes[format]; !ok {
+			return "", fmt.Errorf("unsupported format %q", format)
+		}
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return "json", nil
+	}
+
+	type candidate struct {
+		mediaType string
+		quality   float64
+	}
+	var candidates []candidate
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{mediaType, quality})
+		}
+	}
+	// The stable sort keeps the client's order for media types with the same quality
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		}
+		return 0
+	})
+
+	for _, c := range candidates {
+		if format, ok := summaryMediaTypes[c.mediaType]; ok {
+			return format, nil
+		}
+		if c.mediaType == "*/*" || c.mediaType == "application/*" {
+			return "json", nil
+		}
+		if c.mediaType == "text/*" {
+			return "markdown", nil
+		}
+	}
+	return "", fmt.Errorf("none of the accepted media types are supported: %s", accept)
+}
+
+// writeError writes an error response as JSON
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServerPlan = "../../testdata/simple_plan.json"
+
+func newTestServer(t *testing.T, options Options) (*Server, []byte) {
+	t.Helper()
+	body, err := os.ReadFile(testServerPlan)
+	require.NoError(t, err)
+	return New(config.GetDefaultConfig(), options, log.New(io.Discard, "", 0)), body
+}
+
+func postSummary(s *Server, target string, accept string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestServer_SummariesContentNegotiation(t *testing.T) {
+	s, body := newTestServer(t, Options{})
+
+	tests := []struct {
+		name        string
+		target      string
+		accept      string
+		contentType string
+		contains    string
+	}{
+		{"default is JSON", "/v1/summaries", "", "application/json", `"schema_version": "1"`},
+		{"markdown", "/v1/summaries", "text/markdown", "text/markdown; charset=utf-8", "### Resource Changes"},
+		{"html", "/v1/summaries", "text/html", "text/html; charset=utf-8", "<table"},
+		{"csv", "/v1/summaries", "text/csv", "text/csv; charset=utf-8", "aws_instance.legacy_server"},
+		{"quality values", "/v1/summaries", "text/html;q=0.5, text/markdown;q=0.9", "text/markdown; charset=utf-8", "###"},
+		{"format parameter overrides Accept", "/v1/summaries?format=csv", "text/html", "text/csv; charset=utf-8", "aws_instance.legacy_server"},
+		{"wildcard", "/v1/summaries", "image/png, */*;q=0.1", "application/json", "{"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := postSummary(s, tc.target, tc.accept, body)
+			require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+			assert.Equal(t, tc.contentType, resp.Header().Get("Content-Type"))
+			assert.Contains(t, resp.Body.String(), tc.contains)
+		})
+	}
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		resp := postSummary(s, "/v1/summaries", "image/png", body)
+		assert.Equal(t, http.StatusNotAcceptable, resp.Code)
+	})
+
+	t.Run("unsupported format parameter", func(t *testing.T) {
+		resp := postSummary(s, "/v1/summaries?format=pdf", "", body)
+		assert.Equal(t, http.StatusNotAcceptable, resp.Code)
+	})
+}
+
+func TestServer_SummariesRequestConfig(t *testing.T) {
+	s, body := newTestServer(t, Options{})
+
+	resp := postSummary(s, "/v1/summaries?expand_all=true", "text/markdown", body)
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "<details><summary>")
+
+	// Overrides only apply to the request they were sent with
+	resp = postSummary(s, "/v1/summaries", "text/markdown", body)
+	assert.Contains(t, resp.Body.String(), "<details><summary>")
+	assert.False(t, s.cfg.ExpandAll)
+
+	resp = postSummary(s, "/v1/summaries?expand_all=maybe", "", body)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestServer_SummariesLimits(t *testing.T) {
+	t.Run("request size", func(t *testing.T) {
+		s, body := newTestServer(t, Options{MaxRequestSize: 64})
+		resp := postSummary(s, "/v1/summaries", "", body)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		s, body := newTestServer(t, Options{MaxConcurrent: 1})
+		s.slots <- struct{}{} // A summary that is still being generated
+		resp := postSummary(s, "/v1/summaries", "", body)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+		assert.Equal(t, "1", resp.Header().Get("Retry-After"))
+
+		<-s.slots
+		resp = postSummary(s, "/v1/summaries", "", body)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+}
+
+func TestServer_SummariesCancelled(t *testing.T) {
+	s, body := newTestServer(t, Options{MaxConcurrent: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // The client went away before the plan was summarised
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/v1/summaries", bytes.NewReader(body))
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, req)
+	assert.Empty(t, recorder.Body.String(), "no summary is rendered for a cancelled request")
+
+	// The slot is released, so the next request is summarised
+	assert.Equal(t, http.StatusOK, postSummary(s, "/v1/summaries", "", body).Code)
+}
+
+func TestServer_SummariesInvalidRequests(t *testing.T) {
+	s, _ := newTestServer(t, Options{})
+
+	resp := postSummary(s, "/v1/summaries", "", []byte(`{"format_version":`))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	var errorBody map[string]string
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorBody))
+	assert.Contains(t, errorBody["error"], "failed to parse plan JSON")
+
+	resp = postSummary(s, "/v1/summaries", "", []byte(`{"resource_changes": []}`))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/summaries", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestServer_HealthAndMetrics(t *testing.T) {
+	s, body := newTestServer(t, Options{})
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+
+	postSummary(s, "/v1/summaries", "", body)
+	postSummary(s, "/v1/summaries", "", []byte(`{`))
+
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	metrics := recorder.Body.String()
+	assert.Contains(t, metrics, `strata_http_requests_total{endpoint="/v1/summaries",code="200"} 1`)
+	assert.Contains(t, metrics, `strata_http_requests_total{endpoint="/v1/summaries",code="400"} 1`)
+	assert.Contains(t, metrics, `strata_http_request_duration_seconds_count{endpoint="/v1/summaries"} 2`)
+	assert.Contains(t, metrics, "strata_http_requests_in_flight 0")
+}
@@ -0,0 +1,29 @@
+package strata_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ArjenSchwarz/strata/lib/strata"
+)
+
+func Example() {
+	planJSON, err := os.Open("../../testdata/simple_plan.json")
+	if err != nil {
+		panic(err)
+	}
+	defer planJSON.Close()
+
+	ctx := context.Background()
+	summary, err := strata.Summarize(ctx, planJSON, strata.Options{PlanName: "simple"})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%d to add, %d to change, %d to destroy\n",
+		summary.Statistics.ToAdd, summary.Statistics.ToChange, summary.Statistics.ToDestroy)
+
+	if err := strata.Render(ctx, summary, os.Stdout, strata.RenderOptions{Format: "markdown"}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,123 @@
+// Package strata is the Go API for summarising Terraform plans, for embedding Strata in other tools.
+//
+// Summarize analyses a plan in Terraform's JSON format and Render writes the summary in any of the
+// output formats of the command line tool. Both take their settings from the options they are given
+// instead of from configuration files, flags, or environment variables, and they don't keep any state
+// between calls, so they are safe for concurrent use.
+//
+//	summary, err := strata.Summarize(ctx, planJSON, strata.Options{PlanName: "production"})
+//	if err != nil {
+//		return err
+//	}
+//	return strata.Render(ctx, summary, os.Stdout, strata.RenderOptions{Format: "markdown"})
+package strata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/ArjenSchwarz/strata/lib/plan"
+)
+
+// PlanSummary is the summary of a Terraform plan, as returned by Summarize
+type PlanSummary = plan.PlanSummary
+
+// ResourceChange is a change to a single resource in a plan summary
+type ResourceChange = plan.ResourceChange
+
+// OutputChange is a change to a single output in a plan summary
+type OutputChange = plan.OutputChange
+
+// Options configures how a plan is summarised
+type Options struct {
+	// Config holds the analysis settings, such as the sensitive resources and properties and the
+	// resource filter. The default configuration is used when it's nil.
+	Config *config.Config
+	// PlanName is shown as the plan file of the summary
+	PlanName string
+	// Workspace is the Terraform workspace the plan was made for, which is shown in the summary
+	Workspace string
+	// CreatedAt is the creation time of the plan. The current time is used when it's zero.
+	CreatedAt time.Time
+}
+
+// RenderOptions configures how a summary is rendered
+type RenderOptions struct {
+	// Format is the output format: table, json, csv, markdown, html, template, or report.
+	// Summaries are rendered as tables when it's empty.
+	Format string
+	// Config holds the display settings, such as whether details and no-op resources are shown.
+	// The default configuration is used when it's nil.
+	Config *config.Config
+	// Template is the Go template file used by the template format
+	Template string
+}
+
+// Summarize reads a Terraform plan in JSON format, as written by terraform show -json, and returns its summary.
+// Binary plan files aren't supported, as they can only be converted by Terraform in the plan's working directory.
+func Summarize(ctx context.Context, r io.Reader, options Options) (*PlanSummary, error) {
+	cfg, err := configOrDefault(options.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resource changes are analysed while the plan is decoded, so they aren't all held in memory
+	summary, err := plan.NewAnalyzer(nil, cfg).SummarizeReader(ctx, r, options.PlanName)
+	if err != nil {
+		return nil, err
+	}
+	summary.Workspace = options.Workspace
+	summary.CreatedAt = options.CreatedAt
+	if summary.CreatedAt.IsZero() {
+		summary.CreatedAt = time.Now()
+	}
+	return summary, nil
+}
+
+// Render writes a plan summary to w in the requested output format. Output is written without colours,
+// and nothing is written when rendering fails.
+func Render(ctx context.Context, summary *PlanSummary, w io.Writer, options RenderOptions) error {
+	if summary == nil {
+		return fmt.Errorf("plan summary cannot be nil")
+	}
+	cfg, err := configOrDefault(options.Config)
+	if err != nil {
+		return err
+	}
+	format := options.Format
+	if format == "" {
+		format = "table"
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	outputConfig := cfg.NewOutputConfigurationForFormat(format)
+	outputConfig.Template = options.Template
+	var rendered bytes.Buffer
+	if err := plan.NewFormatter(cfg).RenderSummary(&rendered, summary, outputConfig, cfg.Plan.ShowDetails); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := w.Write(rendered.Bytes()); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
+}
+
+// configOrDefault returns the default configuration when cfg is nil, and validates cfg otherwise
+func configOrDefault(cfg *config.Config) (*config.Config, error) {
+	if cfg == nil {
+		return config.GetDefaultConfig(), nil
+	}
+	if err := cfg.ValidateConfiguration(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,140 @@
+package strata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ArjenSchwarz/strata/config"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openPlan(t *testing.T, name string) *os.File {
+	t.Helper()
+	file, err := os.Open("../../testdata/" + name)
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+	return file
+}
+
+func TestSummarize(t *testing.T) {
+	createdAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	summary, err := Summarize(context.Background(), openPlan(t, "simple_plan.json"), Options{
+		PlanName:  "simple",
+		Workspace: "production",
+		CreatedAt: createdAt,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "simple", summary.PlanFile)
+	assert.Equal(t, "production", summary.Workspace)
+	assert.Equal(t, createdAt, summary.CreatedAt)
+	assert.NotEmpty(t, summary.ResourceChanges)
+	assert.Equal(t, len(summary.ResourceChanges), summary.Statistics.Total)
+}
+
+func TestSummarize_Errors(t *testing.T) {
+	_, err := Summarize(context.Background(), strings.NewReader("not json"), Options{})
+	assert.ErrorContains(t, err, "failed to parse plan JSON")
+
+	_, err = Summarize(context.Background(), strings.NewReader(`{"resource_changes": []}`), Options{})
+	assert.ErrorContains(t, err, "format version is missing")
+
+	cfg := config.GetDefaultConfig()
+	cfg.Plan.Grouping.Threshold = 0
+	_, err = Summarize(context.Background(), openPlan(t, "simple_plan.json"), Options{Config: cfg})
+	assert.ErrorContains(t, err, "invalid configuration")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Summarize(ctx, openPlan(t, "simple_plan.json"), Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRender(t *testing.T) {
+	summary, err := Summarize(context.Background(), openPlan(t, "simple_plan.json"), Options{PlanName: "simple"})
+	require.NoError(t, err)
+
+	var rendered bytes.Buffer
+	require.NoError(t, Render(context.Background(), summary, &rendered, RenderOptions{Format: "json"}))
+	assert.True(t, json.Valid(rendered.Bytes()), "json output should be valid JSON")
+
+	rendered.Reset()
+	require.NoError(t, Render(context.Background(), summary, &rendered, RenderOptions{}))
+	assert.Contains(t, rendered.String(), summary.ResourceChanges[0].Address)
+	assert.NotContains(t, rendered.String(), "\033[", "output should not contain colours")
+
+	rendered.Reset()
+	require.NoError(t, Render(context.Background(), summary, &rendered, RenderOptions{Format: "report"}))
+	assert.Contains(t, rendered.String(), "<title>Plan report: simple</title>")
+
+	rendered.Reset()
+	assert.ErrorContains(t, Render(context.Background(), summary, &rendered, RenderOptions{Format: "yaml"}), "unsupported output format")
+	assert.Empty(t, rendered.String())
+}
+
+func TestRender_MultilineDiffWithoutColours(t *testing.T) {
+	// Colours would otherwise be enabled when the calling program runs in a terminal
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	input := `{
+		"format_version": "1.2",
+		"resource_changes": [{
+			"address": "aws_instance.web", "type": "aws_instance", "name": "web",
+			"change": {"actions": ["update"], "before": {"user_data": "a\nb\nc"}, "after": {"user_data": "a\nx\nc"}}
+		}]
+	}`
+	summary, err := Summarize(context.Background(), strings.NewReader(input), Options{})
+	require.NoError(t, err)
+
+	cfg := config.GetDefaultConfig()
+	cfg.ExpandAll = true
+	var rendered bytes.Buffer
+	require.NoError(t, Render(context.Background(), summary, &rendered, RenderOptions{Config: cfg}))
+	assert.Contains(t, rendered.String(), "+x")
+	assert.NotContains(t, rendered.String(), "\033[", "output should not contain colours")
+}
+
+// TestConcurrentUse summarises and renders the same plan from several goroutines, which should give
+// identical results. Run with -race to check for shared state.
+func TestConcurrentUse(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/dependencies_plan.json")
+	require.NoError(t, err)
+	cfg := config.GetDefaultConfig()
+	createdAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	const workers = 8
+	results := make([]string, workers)
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			summary, err := Summarize(context.Background(), bytes.NewReader(data), Options{Config: cfg, CreatedAt: createdAt})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			var rendered bytes.Buffer
+			errs[i] = Render(context.Background(), summary, &rendered, RenderOptions{Format: "markdown", Config: cfg})
+			results[i] = rendered.String()
+		}()
+	}
+	wg.Wait()
+
+	for i := range workers {
+		require.NoError(t, errs[i])
+		assert.Equal(t, results[0], results[i])
+	}
+	assert.NotEmpty(t, results[0])
+}